@@ -27,6 +27,8 @@ const JWT_SECRET =
 const TLS_CA_FILE = process.env.TLS_CA_FILE || '/certs/tls.crt';
 const UPSTREAM_TIMEOUT_MS = Number.parseInt(process.env.UPSTREAM_TIMEOUT_MS || '3000', 10);
 const USER_RESPONSE_TIMEOUT_MS = Number.parseInt(process.env.USER_RESPONSE_TIMEOUT_MS || '5000', 10);
+// Chunks of resumable uploads and their completion move far more data than other requests.
+const UPLOAD_TIMEOUT_MS = Number.parseInt(process.env.UPLOAD_TIMEOUT_MS || '300000', 10);
 const CIRCUIT_OPEN_MS = Number.parseInt(process.env.CIRCUIT_OPEN_MS || '15000', 10);
 const CIRCUIT_FAILURE_THRESHOLD = Number.parseInt(process.env.CIRCUIT_FAILURE_THRESHOLD || '3', 10);
 const RETRY_MAX_ATTEMPTS = Number.parseInt(process.env.RETRY_MAX_ATTEMPTS || '2', 10);
@@ -103,6 +105,7 @@ function isRetryableAxiosError(err) {
 app.use((req, res, next) => {
     const ct = String(req.headers['content-type'] || '');
     if (ct.startsWith('multipart/form-data')) return next();
+    if (ct.startsWith('application/offset+octet-stream')) return next();
     express.json()(req, res, next);
 });
 
@@ -155,8 +158,21 @@ app.get('/health', (req, res) => {
     res.json({ status: 'gateway up' });
 });
 
+// Headers of the resumable upload protocol that clients need to see.
+const UPLOAD_RESPONSE_HEADERS = ['location', 'upload-offset', 'upload-length', 'upload-expires'];
+
+function copyUploadHeaders(upstreamHeaders, res) {
+    for (const key of UPLOAD_RESPONSE_HEADERS) {
+        if (upstreamHeaders[key] !== undefined) {
+            res.setHeader(key, upstreamHeaders[key]);
+        }
+    }
+}
+
 async function proxy(req, res, targetUrl) {
-    res.setTimeout(USER_RESPONSE_TIMEOUT_MS);
+    const isUpload = /^\/api\/content\/(uploads\/|songs\/[^/]+\/uploads$)/.test(req.originalUrl);
+    const responseTimeout = isUpload ? UPLOAD_TIMEOUT_MS : USER_RESPONSE_TIMEOUT_MS;
+    res.setTimeout(responseTimeout);
 
     try {
         const isHttps = String(targetUrl || '').startsWith('https://');
@@ -173,19 +189,22 @@ async function proxy(req, res, targetUrl) {
 
         const wantsStream =
             req.method === 'GET' &&
-            /^\/api\/content\/songs\/[^/]+\/audio$/.test(req.originalUrl);
+            (/^\/api\/content\/songs\/[^/]+\/audio\/signed\?/.test(req.originalUrl) ||
+                /^\/api\/content\/songs\/[^/]+\/stream(\.m3u8|\/[^/]+\/[^/]+)$/.test(req.originalUrl) ||
+                /^\/api\/content\/images\/[^/]+\/[^/]+$/.test(req.originalUrl));
 
         const contentType = String(req.headers['content-type'] || '');
         const isMultipart = contentType.startsWith('multipart/form-data');
+        const isUploadChunk = contentType.startsWith('application/offset+octet-stream');
 
         const controller = new AbortController();
-        const userTimeout = setTimeout(() => controller.abort(), USER_RESPONSE_TIMEOUT_MS);
+        const userTimeout = setTimeout(() => controller.abort(), responseTimeout);
 
         const baseAxiosConfig = {
             method: req.method,
             url,
             headers: req.headers,
-            timeout: UPSTREAM_TIMEOUT_MS,
+            timeout: isUpload ? UPLOAD_TIMEOUT_MS : UPSTREAM_TIMEOUT_MS,
             signal: controller.signal,
             maxBodyLength: Infinity,
             maxContentLength: Infinity,
@@ -217,7 +236,7 @@ async function proxy(req, res, targetUrl) {
                 return;
             }
 
-            if (isMultipart) {
+            if (isMultipart || isUploadChunk) {
                 const response = await axios({
                     ...baseAxiosConfig,
                     data: req,
@@ -226,6 +245,9 @@ async function proxy(req, res, targetUrl) {
                 if (response.headers['set-cookie']) {
                     res.setHeader('set-cookie', response.headers['set-cookie']);
                 }
+                if (isUpload) {
+                    copyUploadHeaders(response.headers, res);
+                }
                 breaker.success();
                 return res.status(response.status).json(response.data);
             }
@@ -254,6 +276,9 @@ async function proxy(req, res, targetUrl) {
             if (response.headers['set-cookie']) {
                 res.setHeader('set-cookie', response.headers['set-cookie']);
             }
+            if (isUpload) {
+                copyUploadHeaders(response.headers, res);
+            }
 
             breaker.success();
             res.status(response.status).json(response.data);
@@ -283,6 +308,11 @@ app.use('/api/content/artists', (req, res) =>
     proxy(req, res, CONTENT_SERVICE_URL)
 );
 
+// Signed stream URLs carry their own authorization and are checked by content-service.
+app.get(/^\/api\/content\/songs\/[^/]+\/audio\/signed$/, (req, res) =>
+    proxy(req, res, CONTENT_SERVICE_URL)
+);
+
 app.use('/api/content', authMiddleware, (req, res) =>
     proxy(req, res, CONTENT_SERVICE_URL)
 );
@@ -291,6 +321,10 @@ app.use('/api/notifications', authMiddleware, (req, res) =>
     proxy(req, res, NOTIFICATIONS_SERVICE_URL)
 );
 
+app.get(/^\/api\/recommendations\/(songs|artists)\/[^/]+\/similar\/?$/, (req, res) =>
+    proxy(req, res, RECOMMENDATION_SERVICE_URL)
+);
+
 app.use('/api/recommendations', authMiddleware, (req, res) =>
     proxy(req, res, RECOMMENDATION_SERVICE_URL)
 );
@@ -0,0 +1,115 @@
+// Package analysis decodes uploaded audio and measures what the player needs
+// before playback: a peak waveform to draw the scrubber and the integrated
+// loudness with a ReplayGain value to even out volume between songs.
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Result is the outcome of analysing one audio file. Waveform holds the
+// peaks scaled to 0-255; SamplePeak is the highest absolute sample, 1.0
+// being full scale.
+type Result struct {
+	Waveform   []byte
+	Loudness   float64
+	ReplayGain float64
+	SamplePeak float64
+}
+
+// Analyze decodes input with ffmpeg into 32-bit float PCM at SampleRate and
+// measures it. channels is how many channels to decode, 1 or 2; sources with
+// more are mixed down. The waveform has at most points points.
+func Analyze(ctx context.Context, ffmpeg, input string, channels, points int) (*Result, error) {
+	if channels < 1 || channels > 2 {
+		channels = 2
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-i", input,
+		"-map", "0:a:0", "-vn",
+		"-ac", strconv.Itoa(channels), "-ar", strconv.Itoa(SampleRate),
+		"-f", "f32le", "-",
+	)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("decoding: %v", err)
+	}
+
+	result, readErr := Read(stdout, channels, points)
+	if readErr != nil {
+		// Let ffmpeg exit instead of blocking on a full pipe.
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("decoding: %v: %s", err, lastLine(stderr.String()))
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return result, nil
+}
+
+// Read measures interleaved little-endian float32 samples at SampleRate.
+func Read(r io.Reader, channels, points int) (*Result, error) {
+	meter := NewMeter(channels)
+	peaks := &peaks{channels: channels}
+
+	br := bufio.NewReaderSize(r, 64*1024)
+	frameBytes := 4 * channels
+	buf := make([]byte, 4096*frameBytes)
+	samples := make([]float32, 4096*channels)
+	total := 0
+	for {
+		n, err := io.ReadFull(br, buf)
+		n -= n % frameBytes
+		for i := 0; i < n/4; i++ {
+			samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+		}
+		meter.Write(samples[:n/4])
+		peaks.write(samples[:n/4])
+		total += n / frameBytes
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading decoded audio: %w", err)
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no audio decoded")
+	}
+	peaks.flush()
+
+	lufs := meter.Integrated()
+	return &Result{
+		Waveform:   Downsample(quantize(peaks.values), points),
+		Loudness:   math.Round(lufs*100) / 100,
+		ReplayGain: math.Round(ReplayGain(lufs)*100) / 100,
+		SamplePeak: math.Round(peaks.max*10000) / 10000,
+	}, nil
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
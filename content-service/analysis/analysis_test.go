@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// sine returns seconds of a 1 kHz tone with the given peak level in dBFS on
+// every channel.
+func sine(channels int, dbfs, seconds float64) []float32 {
+	amp := math.Pow(10, dbfs/20)
+	frames := int(seconds * SampleRate)
+	out := make([]float32, 0, frames*channels)
+	for i := 0; i < frames; i++ {
+		v := float32(amp * math.Sin(2*math.Pi*1000*float64(i)/SampleRate))
+		for ch := 0; ch < channels; ch++ {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func measure(channels int, parts ...[]float32) float64 {
+	m := NewMeter(channels)
+	for _, p := range parts {
+		m.Write(p)
+	}
+	return m.Integrated()
+}
+
+// The cases follow EBU Tech 3341, which allows +-0.1 LU.
+func TestIntegratedLoudness(t *testing.T) {
+	cases := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"stereo -23 dBFS", measure(2, sine(2, -23, 20)), -23},
+		{"stereo -33 dBFS", measure(2, sine(2, -33, 20)), -33},
+		{"gated quiet parts", measure(2, sine(2, -36, 10), sine(2, -23, 60), sine(2, -36, 10)), -23},
+		{"mono full scale", measure(1, sine(1, 0, 10)), -3.01},
+		// The relative gate falls to -72 LUFS here; the absolute gate still
+		// keeps the -71 dBFS part out.
+		{"quiet material", measure(2, sine(2, -62, 20), sine(2, -71, 20)), -62},
+	}
+	for _, tc := range cases {
+		if math.Abs(tc.got-tc.want) > 0.1 {
+			t.Errorf("%s: %.2f LUFS, want %.2f", tc.name, tc.got, tc.want)
+		}
+	}
+
+	if got := measure(2, make([]float32, 2*SampleRate*5)); got != SilenceLUFS {
+		t.Errorf("silence: %.2f LUFS, want %.0f", got, SilenceLUFS)
+	}
+	if got := ReplayGain(-23); got != 5 {
+		t.Errorf("ReplayGain(-23) = %.2f, want 5", got)
+	}
+}
+
+func encode(samples []float32) []byte {
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.LittleEndian, samples)
+	return b.Bytes()
+}
+
+func TestReadBuildsWaveform(t *testing.T) {
+	pcm := append(make([]float32, 2*SampleRate*2), sine(2, -6, 2)...)
+	result, err := Read(bytes.NewReader(encode(pcm)), 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Waveform) != 100 {
+		t.Fatalf("waveform has %d points, want 100", len(result.Waveform))
+	}
+	if result.Waveform[0] != 0 || result.Waveform[49] != 0 {
+		t.Fatalf("silent half drawn as %v", result.Waveform[:50])
+	}
+	if w := result.Waveform[99]; w < 125 || w > 129 {
+		t.Fatalf("-6 dBFS drawn as %d, want about 127", w)
+	}
+	if math.Abs(result.SamplePeak-0.5012) > 0.001 {
+		t.Fatalf("SamplePeak = %v, want 0.5012", result.SamplePeak)
+	}
+	if math.Abs(result.ReplayGain-(ReferenceLUFS-result.Loudness)) > 0.011 {
+		t.Fatalf("ReplayGain %v does not match loudness %v", result.ReplayGain, result.Loudness)
+	}
+
+	if _, err := Read(bytes.NewReader(nil), 2, 100); err == nil {
+		t.Fatal("Read accepted empty input")
+	}
+}
+
+func TestDownsampleKeepsPeaks(t *testing.T) {
+	got := Downsample([]byte{1, 9, 2, 3, 0, 0, 7, 1}, 4)
+	if want := []byte{9, 3, 0, 7}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Downsample = %v, want %v", got, want)
+	}
+	if got := Downsample([]byte{1, 2}, 4); len(got) != 2 {
+		t.Fatalf("Downsample grew the waveform to %v", got)
+	}
+	if got := Levels([]byte{0, 255, 51}); !reflect.DeepEqual(got, []float64{0, 1, 0.2}) {
+		t.Fatalf("Levels = %v", got)
+	}
+}
+
+func TestAnalyzeReportsDecoderFailure(t *testing.T) {
+	_, err := Analyze(context.Background(), filepath.Join(t.TempDir(), "missing-ffmpeg"), "in.mp3", 2, 100)
+	if err == nil {
+		t.Fatal("Analyze succeeded without a decoder")
+	}
+}
@@ -0,0 +1,143 @@
+package analysis
+
+import "math"
+
+// Loudness is measured as in ITU-R BS.1770-4 / EBU R128: the signal is
+// K-weighted, its power taken over 400 ms blocks overlapping by 75 %, and the
+// integrated value averaged over the blocks that pass an absolute gate at
+// -70 LUFS and a relative gate 10 LU below the loudness of those blocks.
+const (
+	// SampleRate is the rate audio is decoded at; the K-weighting
+	// coefficients below are the ones the standard gives for it.
+	SampleRate = 48000
+
+	// SilenceLUFS is reported for audio with no block above the absolute
+	// gate.
+	SilenceLUFS = -70.0
+
+	// ReferenceLUFS is the ReplayGain 2.0 target loudness.
+	ReferenceLUFS = -18.0
+
+	stepSamples      = SampleRate / 10 // 100 ms
+	stepsPerBlock    = 4               // 400 ms blocks
+	relativeGateLU   = -10.0
+	loudnessOffset   = -0.691
+	absoluteGateLUFS = SilenceLUFS
+)
+
+// biquad is a second order IIR filter in direct form I.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the two stages of the K filter at 48 kHz: a high shelf
+// modelling the head and a high pass.
+func kWeighting() [2]biquad {
+	return [2]biquad{
+		{b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285, a1: -1.69065929318241, a2: 0.73248077421585},
+		{b0: 1.0, b1: -2.0, b2: 1.0, a1: -1.99004745483398, a2: 0.99007225036621},
+	}
+}
+
+// Meter measures the integrated loudness of interleaved samples at
+// SampleRate. Up to two channels are supported, both weighted 1.0, which is
+// what BS.1770 gives for left and right.
+type Meter struct {
+	channels int
+	filters  [][2]biquad
+
+	// stepSum is the K-weighted energy of the current 100 ms step, summed
+	// over channels; steps holds the finished ones.
+	stepSum   float64
+	stepCount int
+	steps     []float64
+}
+
+func NewMeter(channels int) *Meter {
+	m := &Meter{channels: channels, filters: make([][2]biquad, channels)}
+	for i := range m.filters {
+		m.filters[i] = kWeighting()
+	}
+	return m
+}
+
+// Write adds interleaved samples. A trailing partial frame is ignored.
+func (m *Meter) Write(samples []float32) {
+	for i := 0; i+m.channels <= len(samples); i += m.channels {
+		for ch := 0; ch < m.channels; ch++ {
+			f := &m.filters[ch]
+			y := f[1].process(f[0].process(float64(samples[i+ch])))
+			m.stepSum += y * y
+		}
+		m.stepCount++
+		if m.stepCount == stepSamples {
+			m.steps = append(m.steps, m.stepSum)
+			m.stepSum, m.stepCount = 0, 0
+		}
+	}
+}
+
+// Integrated returns the gated loudness in LUFS of everything written, or
+// SilenceLUFS when nothing passes the absolute gate.
+func (m *Meter) Integrated() float64 {
+	if len(m.steps) < stepsPerBlock {
+		return SilenceLUFS
+	}
+
+	// Mean square power of each block, summed over channels.
+	blocks := make([]float64, 0, len(m.steps)-stepsPerBlock+1)
+	for i := 0; i+stepsPerBlock <= len(m.steps); i++ {
+		sum := 0.0
+		for _, s := range m.steps[i : i+stepsPerBlock] {
+			sum += s
+		}
+		blocks = append(blocks, sum/(stepsPerBlock*stepSamples))
+	}
+
+	gated := gatedMean(blocks, absoluteGateLUFS)
+	if gated == 0 {
+		return SilenceLUFS
+	}
+	// The relative gate never drops below the absolute one.
+	gated = gatedMean(blocks, math.Max(absoluteGateLUFS, loudness(gated)+relativeGateLU))
+	if gated == 0 {
+		return SilenceLUFS
+	}
+	return loudness(gated)
+}
+
+// gatedMean averages the block powers whose loudness is above gate.
+func gatedMean(blocks []float64, gate float64) float64 {
+	sum, n := 0.0, 0
+	for _, p := range blocks {
+		if loudness(p) > gate {
+			sum += p
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+func loudness(power float64) float64 {
+	if power <= 0 {
+		return math.Inf(-1)
+	}
+	return loudnessOffset + 10*math.Log10(power)
+}
+
+// ReplayGain returns the gain in dB that brings audio of the given integrated
+// loudness to ReferenceLUFS.
+func ReplayGain(lufs float64) float64 {
+	return ReferenceLUFS - lufs
+}
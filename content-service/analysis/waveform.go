@@ -0,0 +1,79 @@
+package analysis
+
+import "math"
+
+// peakWindow is the resolution peaks are collected at before the waveform is
+// reduced to its final number of points: 10 ms.
+const peakWindow = SampleRate / 100
+
+// peaks collects the highest absolute sample, over all channels, of every
+// peakWindow frames.
+type peaks struct {
+	channels int
+	values   []float64
+	current  float64
+	count    int
+	max      float64
+}
+
+func (p *peaks) write(samples []float32) {
+	for i := 0; i+p.channels <= len(samples); i += p.channels {
+		for _, s := range samples[i : i+p.channels] {
+			if a := math.Abs(float64(s)); a > p.current {
+				p.current = a
+			}
+		}
+		p.count++
+		if p.count == peakWindow {
+			p.flush()
+		}
+	}
+}
+
+func (p *peaks) flush() {
+	if p.count == 0 {
+		return
+	}
+	if p.current > p.max {
+		p.max = p.current
+	}
+	p.values = append(p.values, p.current)
+	p.current, p.count = 0, 0
+}
+
+// Downsample reduces a waveform to at most n points, each the highest of the
+// points it covers, so short transients stay visible.
+func Downsample(waveform []byte, n int) []byte {
+	if n <= 0 || len(waveform) <= n {
+		return waveform
+	}
+	out := make([]byte, n)
+	for i := range out {
+		from, to := i*len(waveform)/n, (i+1)*len(waveform)/n
+		for _, v := range waveform[from:to] {
+			if v > out[i] {
+				out[i] = v
+			}
+		}
+	}
+	return out
+}
+
+// quantize stores peaks between 0 and 1 as bytes, which is all the
+// precision a drawn waveform needs and keeps it small on the song.
+func quantize(values []float64) []byte {
+	out := make([]byte, len(values))
+	for i, v := range values {
+		out[i] = byte(math.Round(math.Min(v, 1) * 255))
+	}
+	return out
+}
+
+// Levels converts a stored waveform back to peaks between 0 and 1.
+func Levels(waveform []byte) []float64 {
+	out := make([]float64, len(waveform))
+	for i, v := range waveform {
+		out[i] = math.Round(float64(v)/255*1000) / 1000
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+// Package audiometa reads duration, bitrate, sample rate, channels and basic
+// tags from MP3, WAV and FLAC files without decoding any audio.
+package audiometa
+
+import (
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	FormatMP3  = "mp3"
+	FormatWAV  = "wav"
+	FormatFLAC = "flac"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unrecognised audio format")
+	ErrMalformed     = errors.New("malformed audio file")
+)
+
+// Metadata describes an audio file. Bitrate is in bits per second; for
+// variable bitrate files it is the average. Title and TrackNo come from the
+// file's tags and are empty when it has none.
+type Metadata struct {
+	Format     string
+	Duration   time.Duration
+	Bitrate    int
+	SampleRate int
+	Channels   int
+	Title      string
+	TrackNo    int
+}
+
+// ContentType is the MIME type uploads of the format are stored under.
+func ContentType(format string) string {
+	switch format {
+	case FormatMP3:
+		return "audio/mpeg"
+	case FormatWAV:
+		return "audio/wav"
+	case FormatFLAC:
+		return "audio/flac"
+	}
+	return ""
+}
+
+// Parse identifies the format of r from its first bytes and reads its
+// metadata. size is the length of the whole file.
+func Parse(r io.ReaderAt, size int64) (*Metadata, error) {
+	head := make([]byte, 12)
+	n, err := r.ReadAt(head, 0)
+	if n < len(head) {
+		if err == nil || err == io.EOF {
+			return nil, ErrUnknownFormat
+		}
+		return nil, err
+	}
+
+	switch {
+	case string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return parseWAV(r, size)
+	case string(head[0:4]) == "fLaC":
+		return parseFLAC(r, size, 0)
+	case string(head[0:3]) == "ID3":
+		tag, err := readID3v2(r)
+		if err != nil {
+			return nil, err
+		}
+		var magic [4]byte
+		if _, err := r.ReadAt(magic[:], tag.end); err == nil && string(magic[:]) == "fLaC" {
+			return parseFLAC(r, size, tag.end)
+		}
+		return parseMP3(r, size, tag)
+	case head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		return parseMP3(r, size, nil)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// maxSeconds is the longest duration, in whole seconds, a time.Duration holds.
+const maxSeconds = math.MaxInt64 / int64(time.Second)
+
+// durationOf is how long samples last at sampleRate, or 0 when that does not
+// fit in a time.Duration. Whole seconds and the remainder are converted
+// separately so large sample counts do not overflow.
+func durationOf(samples int64, sampleRate int) time.Duration {
+	rate := int64(sampleRate)
+	if rate <= 0 || samples < 0 || samples/rate >= maxSeconds {
+		return 0
+	}
+	return time.Duration(samples/rate)*time.Second + time.Duration(samples%rate*int64(time.Second)/rate)
+}
+
+// bitrateOf is the average bitrate of audioBytes played over d.
+func bitrateOf(audioBytes int64, d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(float64(audioBytes*8) / d.Seconds())
+}
@@ -0,0 +1,178 @@
+package audiometa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func le32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+func le16(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+
+func parse(t *testing.T, data []byte) *Metadata {
+	t.Helper()
+	meta, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return meta
+}
+
+func id3v23(frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	n := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+	return append(header, body...)
+}
+
+func id3Frame(id string, data []byte) []byte {
+	f := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+	return append(append(f, 0, 0), data...)
+}
+
+func utf16Text(s string) []byte {
+	out := []byte{1, 0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, le16(u)...)
+	}
+	return out
+}
+
+// mp3Frames returns count MPEG-1 Layer III frames at 128 kbps, 44.1 kHz
+// stereo; each is 417 bytes.
+func mp3Frames(count int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return bytes.Repeat(frame, count)
+}
+
+func TestParseMP3ConstantBitrate(t *testing.T) {
+	tag := id3v23(
+		id3Frame("TIT2", utf16Text("Đurđevdan")),
+		id3Frame("TRCK", append([]byte{0}, "7/12"...)),
+	)
+	meta := parse(t, append(tag, mp3Frames(100)...))
+
+	if meta.Format != FormatMP3 || meta.SampleRate != 44100 || meta.Channels != 2 || meta.Bitrate != 128000 {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if want := 2606250 * time.Microsecond; meta.Duration != want {
+		t.Fatalf("duration = %v, want %v", meta.Duration, want)
+	}
+	if meta.Title != "Đurđevdan" || meta.TrackNo != 7 {
+		t.Fatalf("tags = %q, %d", meta.Title, meta.TrackNo)
+	}
+}
+
+func TestParseMP3Xing(t *testing.T) {
+	data := mp3Frames(10)
+	copy(data[36:], "Xing")
+	binary.BigEndian.PutUint32(data[40:], 1)
+	binary.BigEndian.PutUint32(data[44:], 1000)
+
+	meta := parse(t, data)
+	if want := durationOf(1000*1152, 44100); meta.Duration != want {
+		t.Fatalf("duration = %v, want %v", meta.Duration, want)
+	}
+}
+
+func TestParseWAV(t *testing.T) {
+	info := []byte("INFO")
+	info = append(append(append(info, "INAM"...), le32(5)...), "Ajde\x00\x00"...)
+	info = append(append(info, "ITRK"...), le32(2)...)
+	info = append(info, "3\x00"...)
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	b.Write(le32(0))
+	b.WriteString("WAVE")
+	b.WriteString("fmt ")
+	b.Write(le32(16))
+	b.Write(le16(1))      // PCM
+	b.Write(le16(2))      // channels
+	b.Write(le32(48000))  // sample rate
+	b.Write(le32(192000)) // byte rate
+	b.Write(le16(4))
+	b.Write(le16(16))
+	b.WriteString("LIST")
+	b.Write(le32(uint32(len(info))))
+	b.Write(info)
+	b.WriteString("data")
+	b.Write(le32(96000))
+	b.Write(make([]byte, 96000))
+
+	meta := parse(t, b.Bytes())
+	if meta.Format != FormatWAV || meta.SampleRate != 48000 || meta.Channels != 2 || meta.Bitrate != 1536000 {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if meta.Duration != 500*time.Millisecond {
+		t.Fatalf("duration = %v, want 500ms", meta.Duration)
+	}
+	if meta.Title != "Ajde" || meta.TrackNo != 3 {
+		t.Fatalf("tags = %q, %d", meta.Title, meta.TrackNo)
+	}
+}
+
+func TestParseFLAC(t *testing.T) {
+	info := make([]byte, 34)
+	// 44100 Hz, 2 channels, 16 bits, 441000 samples (10 s).
+	packed := uint64(44100)<<44 | uint64(1)<<41 | uint64(15)<<36 | 441000
+	binary.BigEndian.PutUint64(info[10:], packed)
+
+	comments := append(le32(3), "ref"...)
+	comments = append(comments, le32(2)...)
+	for _, c := range []string{"TITLE=Kiša", "tracknumber=4/9"} {
+		comments = append(append(comments, le32(uint32(len(c)))...), c...)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("fLaC")
+	b.Write([]byte{flacStreamInfo, 0, 0, 34})
+	b.Write(info)
+	b.Write([]byte{0x80 | flacVorbisComment, 0, 0, byte(len(comments))})
+	b.Write(comments)
+	b.Write(make([]byte, 100000))
+
+	meta := parse(t, b.Bytes())
+	if meta.Format != FormatFLAC || meta.SampleRate != 44100 || meta.Channels != 2 {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if meta.Duration != 10*time.Second || meta.Bitrate != 80000 {
+		t.Fatalf("duration = %v, bitrate = %d", meta.Duration, meta.Bitrate)
+	}
+	if meta.Title != "Kiša" || meta.TrackNo != 4 {
+		t.Fatalf("tags = %q, %d", meta.Title, meta.TrackNo)
+	}
+}
+
+func TestParseFLACRejectsOverlongStream(t *testing.T) {
+	info := make([]byte, 34)
+	// 1 Hz, 1 channel, 16 bits and the largest 36 bit sample count.
+	packed := uint64(1)<<44 | uint64(15)<<36 | 0xFFFFFFFFF
+	binary.BigEndian.PutUint64(info[10:], packed)
+
+	data := append([]byte{'f', 'L', 'a', 'C', 0x80 | flacStreamInfo, 0, 0, 34}, info...)
+	if _, err := Parse(bytes.NewReader(data), int64(len(data))); err != ErrMalformed {
+		t.Fatalf("Parse = %v, want ErrMalformed", err)
+	}
+	if d := durationOf(1<<62, 1); d != 0 {
+		t.Fatalf("durationOf overflowed to %v", d)
+	}
+	if d := durationOf(3*44100+22050, 44100); d != 3500*time.Millisecond {
+		t.Fatalf("durationOf = %v, want 3.5s", d)
+	}
+}
+
+func TestParseRejectsUnknown(t *testing.T) {
+	for _, data := range [][]byte{[]byte("OggS\x00\x02 not audio we read"), []byte("short")} {
+		if _, err := Parse(bytes.NewReader(data), int64(len(data))); err != ErrUnknownFormat {
+			t.Errorf("Parse(%q) = %v, want ErrUnknownFormat", data, err)
+		}
+	}
+	junk := append([]byte{0xFF, 0xFB}, make([]byte, 100)...)
+	if _, err := Parse(bytes.NewReader(junk), int64(len(junk))); err != ErrMalformed {
+		t.Errorf("Parse(junk frame) = %v, want ErrMalformed", err)
+	}
+}
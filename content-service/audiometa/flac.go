@@ -0,0 +1,117 @@
+package audiometa
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+// parseFLAC reads the STREAMINFO block and, when present, the Vorbis
+// comment block for TITLE and TRACKNUMBER. start is where "fLaC" begins,
+// after any ID3v2 tag.
+func parseFLAC(r io.ReaderAt, size int64, start int64) (*Metadata, error) {
+	meta := &Metadata{Format: FormatFLAC}
+	var totalSamples int64
+	haveInfo := false
+
+	pos := start + 4
+	var header [4]byte
+	for {
+		if _, err := r.ReadAt(header[:], pos); err != nil {
+			return nil, ErrMalformed
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		body := pos + 4
+
+		switch blockType {
+		case flacStreamInfo:
+			var info [34]byte
+			if length < 34 {
+				return nil, ErrMalformed
+			}
+			if _, err := r.ReadAt(info[:], body); err != nil {
+				return nil, ErrMalformed
+			}
+			// Bytes 10-17: 20 bits sample rate, 3 bits channels-1,
+			// 5 bits bits-per-sample-1, 36 bits total samples.
+			packed := binary.BigEndian.Uint64(info[10:18])
+			meta.SampleRate = int(packed >> 44)
+			meta.Channels = int((packed>>41)&0x7) + 1
+			totalSamples = int64(packed & 0xFFFFFFFFF)
+			haveInfo = true
+		case flacVorbisComment:
+			if length <= maxTagSize {
+				block := make([]byte, length)
+				if n, _ := r.ReadAt(block, body); int64(n) == length {
+					readVorbisComment(block, meta)
+				}
+			}
+		}
+
+		pos = body + length
+		if last {
+			break
+		}
+	}
+
+	if !haveInfo || meta.SampleRate <= 0 {
+		return nil, ErrMalformed
+	}
+	// 36 bits of samples at a low enough rate last longer than a
+	// time.Duration can hold.
+	if totalSamples/int64(meta.SampleRate) >= maxSeconds {
+		return nil, ErrMalformed
+	}
+	meta.Duration = durationOf(totalSamples, meta.SampleRate)
+	meta.Bitrate = bitrateOf(size-pos, meta.Duration)
+	return meta, nil
+}
+
+// readVorbisComment reads the little-endian vendor string and comment list
+// of a FLAC VORBIS_COMMENT block.
+func readVorbisComment(block []byte, meta *Metadata) {
+	next := func() (string, bool) {
+		if len(block) < 4 {
+			return "", false
+		}
+		n := int(binary.LittleEndian.Uint32(block[0:4]))
+		if n < 0 || 4+n > len(block) {
+			return "", false
+		}
+		s := string(block[4 : 4+n])
+		block = block[4+n:]
+		return s, true
+	}
+
+	if _, ok := next(); !ok {
+		return
+	}
+	if len(block) < 4 {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(block[0:4]))
+	block = block[4:]
+	for i := 0; i < count; i++ {
+		comment, ok := next()
+		if !ok {
+			return
+		}
+		key, value, found := strings.Cut(comment, "=")
+		if !found {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			meta.Title = strings.TrimSpace(value)
+		case "TRACKNUMBER":
+			meta.TrackNo = parseTrackNo(value)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package audiometa
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxTagSize bounds how much of an ID3v2 tag is read; larger tags usually
+// carry embedded pictures, and only the text frames are of interest.
+const maxTagSize = 1 << 20
+
+type id3Tag struct {
+	end     int64 // offset of the first byte after the tag
+	title   string
+	trackNo int
+}
+
+// readID3v2 reads the ID3v2 tag at the start of r.
+func readID3v2(r io.ReaderAt) (*id3Tag, error) {
+	var header [10]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return nil, ErrMalformed
+	}
+	version, flags := header[3], header[5]
+	size := int64(syncsafe(header[6:10]))
+	tag := &id3Tag{end: 10 + size}
+	if flags&0x10 != 0 {
+		tag.end += 10 // footer
+	}
+	if version < 2 || version > 4 {
+		return tag, nil
+	}
+
+	body := make([]byte, min(size, maxTagSize))
+	n, _ := r.ReadAt(body, 10)
+	body = body[:n]
+
+	pos := 0
+	if flags&0x40 != 0 && version >= 3 && len(body) >= 4 {
+		ext := int(binary.BigEndian.Uint32(body[0:4]))
+		if version == 4 {
+			pos = syncsafe(body[0:4])
+		} else {
+			pos = ext + 4
+		}
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+	for pos+headerLen <= len(body) && body[pos] != 0 {
+		id := string(body[pos : pos+idLen])
+		var frameSize int
+		switch version {
+		case 2:
+			frameSize = int(body[pos+3])<<16 | int(body[pos+4])<<8 | int(body[pos+5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(body[pos+4 : pos+8]))
+		default:
+			frameSize = syncsafe(body[pos+4 : pos+8])
+		}
+		pos += headerLen
+		if frameSize < 0 || pos+frameSize > len(body) {
+			break
+		}
+		data := body[pos : pos+frameSize]
+		pos += frameSize
+
+		switch id {
+		case "TIT2", "TT2":
+			tag.title = decodeText(data)
+		case "TRCK", "TRK":
+			tag.trackNo = parseTrackNo(decodeText(data))
+		}
+	}
+	return tag, nil
+}
+
+// readID3v1 reads the 128 byte ID3v1 tag at the end of the file, if any, and
+// returns its size, title and (ID3v1.1) track number.
+func readID3v1(r io.ReaderAt, size int64) (int64, string, int) {
+	if size < 128 {
+		return 0, "", 0
+	}
+	var tag [128]byte
+	if _, err := r.ReadAt(tag[:], size-128); err != nil || string(tag[0:3]) != "TAG" {
+		return 0, "", 0
+	}
+	title := latin1(tag[3:33])
+	track := 0
+	if tag[125] == 0 && tag[126] != 0 {
+		track = int(tag[126])
+	}
+	return 128, title, track
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// decodeText decodes an ID3v2 text frame: an encoding byte followed by the
+// text, possibly NUL terminated.
+func decodeText(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	enc, text := data[0], data[1:]
+	var s string
+	switch enc {
+	case 0:
+		s = latin1(text)
+	case 1, 2:
+		bigEndian := enc == 2
+		if len(text) >= 2 {
+			switch {
+			case text[0] == 0xFE && text[1] == 0xFF:
+				bigEndian, text = true, text[2:]
+			case text[0] == 0xFF && text[1] == 0xFE:
+				bigEndian, text = false, text[2:]
+			}
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			if bigEndian {
+				units = append(units, binary.BigEndian.Uint16(text[i:]))
+			} else {
+				units = append(units, binary.LittleEndian.Uint16(text[i:]))
+			}
+		}
+		s = string(utf16.Decode(units))
+	default:
+		s = string(text)
+	}
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func latin1(b []byte) string {
+	runes := make([]rune, 0, len(b))
+	for _, c := range b {
+		if c == 0 {
+			break
+		}
+		runes = append(runes, rune(c))
+	}
+	return strings.TrimSpace(string(runes))
+}
+
+// parseTrackNo reads "3" or "3/12".
+func parseTrackNo(s string) int {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		s = s[:i]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,180 @@
+package audiometa
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// syncWindow is how far past the tag parseMP3 looks for the first frame.
+const syncWindow = 64 << 10
+
+var (
+	mp3Bitrates = map[[2]int][16]int{
+		{1, 1}: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{1, 2}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{1, 3}: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		{2, 1}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{2, 2}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{2, 3}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	}
+	mp3SampleRates = map[int][3]int{
+		1:  {44100, 48000, 32000},
+		2:  {22050, 24000, 16000},
+		25: {11025, 12000, 8000},
+	}
+)
+
+// mp3Frame is a decoded MPEG audio frame header. version is 1, 2 or 25
+// (MPEG 2.5) and layer is 1 to 3.
+type mp3Frame struct {
+	version    int
+	layer      int
+	bitrate    int // bits per second
+	sampleRate int
+	padding    int
+	channels   int
+}
+
+func parseFrameHeader(b []byte) (mp3Frame, bool) {
+	h := binary.BigEndian.Uint32(b)
+	if h>>21 != 0x7FF {
+		return mp3Frame{}, false
+	}
+
+	var f mp3Frame
+	switch (h >> 19) & 3 {
+	case 0:
+		f.version = 25
+	case 2:
+		f.version = 2
+	case 3:
+		f.version = 1
+	default:
+		return f, false
+	}
+	layerBits := (h >> 17) & 3
+	if layerBits == 0 {
+		return f, false
+	}
+	f.layer = int(4 - layerBits)
+
+	bitrateIndex := (h >> 12) & 0xF
+	rateIndex := (h >> 10) & 3
+	if bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return f, false
+	}
+	table := f.version
+	if table == 25 {
+		table = 2
+	}
+	f.bitrate = mp3Bitrates[[2]int{table, f.layer}][bitrateIndex] * 1000
+	f.sampleRate = mp3SampleRates[f.version][rateIndex]
+	f.padding = int((h >> 9) & 1)
+	f.channels = 2
+	if (h>>6)&3 == 3 {
+		f.channels = 1
+	}
+	return f, true
+}
+
+func (f mp3Frame) samples() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && f.version != 1:
+		return 576
+	}
+	return 1152
+}
+
+func (f mp3Frame) length() int {
+	if f.layer == 1 {
+		return (12*f.bitrate/f.sampleRate + f.padding) * 4
+	}
+	return f.samples()/8*f.bitrate/f.sampleRate + f.padding
+}
+
+// xingOffset is where a Xing/Info header starts inside a Layer III frame:
+// after the 4 byte header and the side information.
+func (f mp3Frame) xingOffset() int {
+	switch {
+	case f.version == 1 && f.channels == 2:
+		return 36
+	case f.version == 1 || f.channels == 2:
+		return 21
+	}
+	return 13
+}
+
+// parseMP3 finds the first frame after the ID3v2 tag (if any). Variable
+// bitrate files are timed from their Xing/Info frame count; everything else
+// is treated as constant bitrate and timed from the audio size.
+func parseMP3(r io.ReaderAt, size int64, tag *id3Tag) (*Metadata, error) {
+	meta := &Metadata{Format: FormatMP3}
+	start := int64(0)
+	if tag != nil {
+		start = tag.end
+		meta.Title, meta.TrackNo = tag.title, tag.trackNo
+	}
+
+	buf := make([]byte, syncWindow)
+	n, _ := r.ReadAt(buf, start)
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xFF {
+			continue
+		}
+		f, ok := parseFrameHeader(buf[i:])
+		if !ok {
+			continue
+		}
+		next := i + f.length()
+		if next+4 <= len(buf) {
+			if _, ok := parseFrameHeader(buf[next:]); !ok {
+				continue
+			}
+		}
+
+		meta.SampleRate = f.sampleRate
+		meta.Channels = f.channels
+
+		tailSize, v1Title, v1Track := readID3v1(r, size)
+		if meta.Title == "" {
+			meta.Title = v1Title
+		}
+		if meta.TrackNo == 0 {
+			meta.TrackNo = v1Track
+		}
+		audioBytes := size - start - int64(i) - tailSize
+
+		if frames, ok := xingFrames(buf[i:], f); ok {
+			meta.Duration = durationOf(int64(frames)*int64(f.samples()), f.sampleRate)
+			meta.Bitrate = bitrateOf(audioBytes, meta.Duration)
+		} else {
+			meta.Bitrate = f.bitrate
+			meta.Duration = time.Duration(float64(audioBytes*8) / float64(f.bitrate) * float64(time.Second))
+		}
+		return meta, nil
+	}
+	return nil, ErrMalformed
+}
+
+func xingFrames(frame []byte, f mp3Frame) (uint32, bool) {
+	if f.layer != 3 {
+		return 0, false
+	}
+	off := f.xingOffset()
+	if off+12 > len(frame) {
+		return 0, false
+	}
+	if id := string(frame[off : off+4]); id != "Xing" && id != "Info" {
+		return 0, false
+	}
+	if binary.BigEndian.Uint32(frame[off+4:off+8])&1 == 0 {
+		return 0, false
+	}
+	frames := binary.BigEndian.Uint32(frame[off+8 : off+12])
+	return frames, frames > 0
+}
@@ -0,0 +1,82 @@
+package audiometa
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// parseWAV walks the RIFF chunks for "fmt " and "data", and picks the title
+// and track number from a LIST/INFO chunk when there is one.
+func parseWAV(r io.ReaderAt, size int64) (*Metadata, error) {
+	meta := &Metadata{Format: FormatWAV}
+	var byteRate, dataSize int64
+	haveFmt := false
+
+	var header [8]byte
+	for pos := int64(12); pos+8 <= size; {
+		if _, err := r.ReadAt(header[:], pos); err != nil {
+			break
+		}
+		id := string(header[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+		body := pos + 8
+
+		switch id {
+		case "fmt ":
+			var fmtChunk [16]byte
+			if chunkSize < 16 {
+				return nil, ErrMalformed
+			}
+			if _, err := r.ReadAt(fmtChunk[:], body); err != nil {
+				return nil, ErrMalformed
+			}
+			meta.Channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			meta.SampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			byteRate = int64(binary.LittleEndian.Uint32(fmtChunk[8:12]))
+			haveFmt = true
+		case "data":
+			dataSize = chunkSize
+			if body+dataSize > size {
+				dataSize = size - body
+			}
+		case "LIST":
+			if chunkSize >= 4 && chunkSize <= maxTagSize {
+				list := make([]byte, chunkSize)
+				if n, _ := r.ReadAt(list, body); int64(n) == chunkSize && string(list[0:4]) == "INFO" {
+					readWAVInfo(list[4:], meta)
+				}
+			}
+		}
+
+		pos = body + chunkSize + chunkSize%2
+	}
+
+	if !haveFmt || byteRate <= 0 || meta.SampleRate <= 0 {
+		return nil, ErrMalformed
+	}
+	meta.Bitrate = int(byteRate * 8)
+	meta.Duration = durationOf(dataSize*int64(meta.SampleRate)/byteRate, meta.SampleRate)
+	return meta, nil
+}
+
+func readWAVInfo(info []byte, meta *Metadata) {
+	for pos := 0; pos+8 <= len(info); {
+		id := string(info[pos : pos+4])
+		n := int(binary.LittleEndian.Uint32(info[pos+4 : pos+8]))
+		start := pos + 8
+		if start+n > len(info) {
+			return
+		}
+		value := strings.TrimRight(string(info[start:start+n]), "\x00 ")
+		switch id {
+		case "INAM":
+			meta.Title = strings.TrimSpace(value)
+		case "ITRK", "IPRT":
+			if meta.TrackNo == 0 {
+				meta.TrackNo = parseTrackNo(value)
+			}
+		}
+		pos = start + n + n%2
+	}
+}
@@ -0,0 +1,207 @@
+// Command verify-audio re-hashes every stored audio file and compares it with
+// the SHA-256 recorded on the songs that use it. The files are read from the
+// storage backend configured by the STORAGE_* and S3_* variables. It
+// prints a JSON report and exits with status 1 when a file is corrupted or
+// missing.
+//
+// Songs uploaded before checksums were recorded are listed as unverified;
+// -backfill stores the current hash of their file instead.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"content-service/config"
+	"content-service/db"
+	"content-service/repository"
+	"content-service/storage"
+	"shared-utils/filevalidation"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const audioPrefix = "audio/"
+
+type fileIssue struct {
+	File     string   `json:"file"`
+	SongIDs  []string `json:"songIds,omitempty"`
+	Expected string   `json:"expected,omitempty"`
+	Actual   string   `json:"actual,omitempty"`
+}
+
+type report struct {
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Dir         string      `json:"dir"`
+	Files       int         `json:"files"`
+	Verified    int         `json:"verified"`
+	Corrupted   []fileIssue `json:"corrupted"`
+	Missing     []fileIssue `json:"missing"`
+	Unverified  []fileIssue `json:"unverified"`
+	Backfilled  int         `json:"backfilled"`
+	Orphaned    []string    `json:"orphaned"`
+}
+
+// expectation collects the songs that point at one stored file.
+type expectation struct {
+	songIDs   []string
+	checksums map[string]bool
+	missing   []primitive.ObjectID
+}
+
+func main() {
+	mongoURI := flag.String("mongo-uri", envOr("MONGO_URI", "mongodb://localhost:27017"), "MongoDB URI")
+	dbName := flag.String("db", envOr("CONTENT_DB_NAME", "content_db"), "content database name")
+	backfill := flag.Bool("backfill", false, "record the checksum of songs that have none")
+	out := flag.String("out", "", "write the report to this file instead of stdout")
+	flag.Parse()
+
+	// The db package logs to stdout; keep it clean for the report.
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+
+	config.MongoURI = *mongoURI
+	config.ContentDBName = *dbName
+	config.LoadStorageConfig()
+	db.ConnectMongo()
+
+	store, err := storage.Open(config.Storage)
+	if err != nil {
+		log.Fatal("Failed to open storage: ", err)
+	}
+	ctx := context.Background()
+
+	songs, err := repository.ListSongAudio()
+	if err != nil {
+		log.Fatal("Failed to load songs: ", err)
+	}
+
+	expected := make(map[string]*expectation)
+	for _, song := range songs {
+		e := expected[song.AudioFile]
+		if e == nil {
+			e = &expectation{checksums: make(map[string]bool)}
+			expected[song.AudioFile] = e
+		}
+		e.songIDs = append(e.songIDs, song.ID.Hex())
+		if song.Checksum == "" {
+			e.missing = append(e.missing, song.ID)
+		} else {
+			e.checksums[song.Checksum] = true
+		}
+	}
+
+	blobs, err := store.List(ctx, audioPrefix)
+	if err != nil {
+		log.Fatal("Failed to list audio files: ", err)
+	}
+
+	rep := report{
+		GeneratedAt: time.Now().UTC(),
+		Dir:         config.Storage.Location(audioPrefix),
+		Corrupted:   []fileIssue{},
+		Missing:     []fileIssue{},
+		Unverified:  []fileIssue{},
+		Orphaned:    []string{},
+	}
+	seen := make(map[string]bool)
+
+	for _, blob := range blobs {
+		name := strings.TrimPrefix(blob.Key, audioPrefix)
+		if strings.Contains(name, "/") {
+			continue
+		}
+		rep.Files++
+		seen[name] = true
+
+		e := expected[name]
+		if e == nil {
+			rep.Orphaned = append(rep.Orphaned, name)
+			continue
+		}
+
+		actual, err := hashBlob(ctx, store, blob.Key)
+		if err != nil {
+			log.Fatalf("Failed to hash %s: %v", name, err)
+		}
+
+		corrupted := false
+		for sum := range e.checksums {
+			if sum != actual {
+				corrupted = true
+				rep.Corrupted = append(rep.Corrupted, fileIssue{File: name, SongIDs: e.songIDs, Expected: sum, Actual: actual})
+			}
+		}
+		if len(e.checksums) > 0 && !corrupted {
+			rep.Verified++
+		}
+
+		if len(e.missing) == 0 {
+			continue
+		}
+		if !*backfill {
+			rep.Unverified = append(rep.Unverified, fileIssue{File: name, SongIDs: hexIDs(e.missing), Actual: actual})
+			continue
+		}
+		for _, id := range e.missing {
+			if err := repository.SetSongChecksum(id, actual); err != nil {
+				log.Fatalf("Failed to record checksum of song %s: %v", id.Hex(), err)
+			}
+			rep.Backfilled++
+		}
+	}
+
+	for name, e := range expected {
+		if !seen[name] {
+			rep.Missing = append(rep.Missing, fileIssue{File: name, SongIDs: e.songIDs})
+		}
+	}
+	sort.Slice(rep.Missing, func(i, j int) bool { return rep.Missing[i].File < rep.Missing[j].File })
+
+	encoded, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		log.Fatal("Failed to encode report: ", err)
+	}
+	if *out != "" {
+		if err := os.WriteFile(*out, append(encoded, '\n'), 0o644); err != nil {
+			log.Fatal("Failed to write report: ", err)
+		}
+	} else {
+		fmt.Fprintln(stdout, string(encoded))
+	}
+
+	if len(rep.Corrupted) > 0 || len(rep.Missing) > 0 {
+		os.Exit(1)
+	}
+}
+
+func hashBlob(ctx context.Context, store storage.BlobStore, key string) (string, error) {
+	body, _, err := store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	return filevalidation.CalculateChecksum(body)
+}
+
+func hexIDs(ids []primitive.ObjectID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.Hex()
+	}
+	return out
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -1,11 +1,17 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"content-service/storage"
+
 	"github.com/joho/godotenv"
 )
 
@@ -21,6 +27,29 @@ var (
 	NotificationsServiceURL  string
 	UsersServiceURL          string
 	RecommendationServiceURL string
+	ChartsRefreshInterval    time.Duration
+	PlayThresholdPercent     int
+	PlayDedupWindow          time.Duration
+	SuggestRebuildInterval   time.Duration
+	TrashRetention           time.Duration
+	TrashPurgeInterval       time.Duration
+	TranscodeWorkers         int
+	TranscodeTimeout         time.Duration
+	FFmpegPath               string
+	HLSBitrates              []int
+	HLSSegmentSeconds        int
+	WaveformPoints           int
+	AnalysisTimeout          time.Duration
+	Storage                  storage.Config
+	StreamURLSecret          []byte
+	StreamURLTTL             time.Duration
+	MaxConcurrentStreams     int
+	StreamWriteTimeout       time.Duration
+	AudioMaxUploadSize       int64
+	UploadTempDir            string
+	UploadExpiry             time.Duration
+	UploadPurgeInterval      time.Duration
+	UploadRequestTimeout     time.Duration
 )
 
 func LoadConfig() {
@@ -59,9 +88,170 @@ func LoadConfig() {
 	UsersServiceURL = getEnv("USERS_SERVICE_URL", "https://users-service:8001")
 	RecommendationServiceURL = getEnv("RECOMMENDATION_SERVICE_URL", "https://recommendation-service:8004")
 
+	ChartsRefreshInterval, err = time.ParseDuration(getEnv("CHARTS_REFRESH_INTERVAL", "10m"))
+	if err != nil {
+		log.Fatal("Invalid CHARTS_REFRESH_INTERVAL format:", err)
+	}
+	if ChartsRefreshInterval <= 0 {
+		log.Fatal("CHARTS_REFRESH_INTERVAL must be positive")
+	}
+
+	PlayThresholdPercent = getEnvAsInt("PLAY_THRESHOLD_PERCENT", 30)
+	if PlayThresholdPercent < 1 || PlayThresholdPercent > 100 {
+		log.Fatal("PLAY_THRESHOLD_PERCENT must be between 1 and 100")
+	}
+	PlayDedupWindow, err = time.ParseDuration(getEnv("PLAY_DEDUP_WINDOW", "2m"))
+	if err != nil {
+		log.Fatal("Invalid PLAY_DEDUP_WINDOW format:", err)
+	}
+
+	SuggestRebuildInterval, err = time.ParseDuration(getEnv("SUGGEST_REBUILD_INTERVAL", "15m"))
+	if err != nil {
+		log.Fatal("Invalid SUGGEST_REBUILD_INTERVAL format:", err)
+	}
+	if SuggestRebuildInterval <= 0 {
+		log.Fatal("SUGGEST_REBUILD_INTERVAL must be positive")
+	}
+
+	TrashRetention, err = time.ParseDuration(getEnv("TRASH_RETENTION", "720h"))
+	if err != nil {
+		log.Fatal("Invalid TRASH_RETENTION format:", err)
+	}
+	if TrashRetention <= 0 {
+		log.Fatal("TRASH_RETENTION must be positive")
+	}
+	TrashPurgeInterval, err = time.ParseDuration(getEnv("TRASH_PURGE_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatal("Invalid TRASH_PURGE_INTERVAL format:", err)
+	}
+	if TrashPurgeInterval <= 0 {
+		log.Fatal("TRASH_PURGE_INTERVAL must be positive")
+	}
+
+	TranscodeWorkers = getEnvAsInt("TRANSCODE_WORKERS", 1)
+	if TranscodeWorkers < 1 {
+		log.Fatal("TRANSCODE_WORKERS must be at least 1")
+	}
+	TranscodeTimeout, err = time.ParseDuration(getEnv("TRANSCODE_TIMEOUT", "10m"))
+	if err != nil {
+		log.Fatal("Invalid TRANSCODE_TIMEOUT format:", err)
+	}
+	if TranscodeTimeout <= 0 {
+		log.Fatal("TRANSCODE_TIMEOUT must be positive")
+	}
+	FFmpegPath = getEnv("FFMPEG_PATH", "ffmpeg")
+	HLSBitrates, err = parseBitrates(getEnv("HLS_BITRATES", "64,128,192"))
+	if err != nil {
+		log.Fatal("Invalid HLS_BITRATES:", err)
+	}
+	HLSSegmentSeconds = getEnvAsInt("HLS_SEGMENT_SECONDS", 6)
+	if HLSSegmentSeconds < 1 {
+		log.Fatal("HLS_SEGMENT_SECONDS must be at least 1")
+	}
+
+	WaveformPoints = getEnvAsInt("WAVEFORM_POINTS", 1000)
+	if WaveformPoints < 10 || WaveformPoints > 10000 {
+		log.Fatal("WAVEFORM_POINTS must be between 10 and 10000")
+	}
+	AnalysisTimeout, err = time.ParseDuration(getEnv("ANALYSIS_TIMEOUT", "5m"))
+	if err != nil {
+		log.Fatal("Invalid ANALYSIS_TIMEOUT format:", err)
+	}
+	if AnalysisTimeout <= 0 {
+		log.Fatal("ANALYSIS_TIMEOUT must be positive")
+	}
+
+	// Without a dedicated secret one is derived from JWT_SECRET, so a signed
+	// stream URL can never pass as a JWT signature or the other way round.
+	StreamURLSecret = []byte(getEnv("STREAM_URL_SECRET", ""))
+	if len(StreamURLSecret) == 0 {
+		mac := hmac.New(sha256.New, JWTSecret)
+		mac.Write([]byte("stream-url"))
+		StreamURLSecret = mac.Sum(nil)
+	} else if len(StreamURLSecret) < 32 {
+		log.Fatal("STREAM_URL_SECRET must be at least 32 characters long")
+	}
+	StreamURLTTL, err = time.ParseDuration(getEnv("STREAM_URL_TTL", "15m"))
+	if err != nil {
+		log.Fatal("Invalid STREAM_URL_TTL format:", err)
+	}
+	if StreamURLTTL <= 0 {
+		log.Fatal("STREAM_URL_TTL must be positive")
+	}
+	MaxConcurrentStreams = getEnvAsInt("MAX_CONCURRENT_STREAMS", 3)
+	if MaxConcurrentStreams < 1 {
+		log.Fatal("MAX_CONCURRENT_STREAMS must be at least 1")
+	}
+	// Sending a whole file to a slow listener outlasts the server's write
+	// timeout, which is meant for short API responses.
+	StreamWriteTimeout, err = time.ParseDuration(getEnv("STREAM_WRITE_TIMEOUT", "30m"))
+	if err != nil {
+		log.Fatal("Invalid STREAM_WRITE_TIMEOUT format:", err)
+	}
+	if StreamWriteTimeout <= 0 {
+		log.Fatal("STREAM_WRITE_TIMEOUT must be positive")
+	}
+
+	// Chunks of resumable uploads are stored in the storage backend;
+	// UPLOAD_TEMP_DIR only holds scratch files for the request in progress.
+	maxUploadMB := getEnvAsInt("AUDIO_MAX_UPLOAD_MB", 500)
+	if maxUploadMB < 1 {
+		log.Fatal("AUDIO_MAX_UPLOAD_MB must be at least 1")
+	}
+	AudioMaxUploadSize = int64(maxUploadMB) * 1024 * 1024
+	UploadTempDir = getEnv("UPLOAD_TEMP_DIR", "./storage/uploads")
+	UploadExpiry, err = time.ParseDuration(getEnv("UPLOAD_EXPIRY", "24h"))
+	if err != nil {
+		log.Fatal("Invalid UPLOAD_EXPIRY format:", err)
+	}
+	if UploadExpiry <= 0 {
+		log.Fatal("UPLOAD_EXPIRY must be positive")
+	}
+	UploadPurgeInterval, err = time.ParseDuration(getEnv("UPLOAD_PURGE_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatal("Invalid UPLOAD_PURGE_INTERVAL format:", err)
+	}
+	if UploadPurgeInterval <= 0 {
+		log.Fatal("UPLOAD_PURGE_INTERVAL must be positive")
+	}
+	UploadRequestTimeout, err = time.ParseDuration(getEnv("UPLOAD_REQUEST_TIMEOUT", "5m"))
+	if err != nil {
+		log.Fatal("Invalid UPLOAD_REQUEST_TIMEOUT format:", err)
+	}
+	if UploadRequestTimeout <= 0 {
+		log.Fatal("UPLOAD_REQUEST_TIMEOUT must be positive")
+	}
+
+	LoadStorageConfig()
+
 	log.Println("Configuration loaded successfully")
 }
 
+// LoadStorageConfig reads where audio, images and streams are stored. It is
+// separate so tools working on the stored files can load just this part.
+func LoadStorageConfig() {
+	Storage = storage.Config{
+		Backend: getEnv("STORAGE_BACKEND", storage.BackendLocal),
+		Dir:     getEnv("STORAGE_DIR", "./storage"),
+		S3: storage.S3Config{
+			Endpoint:  getEnv("S3_ENDPOINT", ""),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			Bucket:    getEnv("S3_BUCKET", ""),
+			AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			SecretKey: getEnv("S3_SECRET_KEY", ""),
+		},
+	}
+	switch Storage.Backend {
+	case storage.BackendLocal:
+	case storage.BackendS3:
+		if Storage.S3.Endpoint == "" || Storage.S3.Bucket == "" || Storage.S3.AccessKey == "" || Storage.S3.SecretKey == "" {
+			log.Fatal("STORAGE_BACKEND=s3 requires S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+		}
+	default:
+		log.Fatal("STORAGE_BACKEND must be local or s3")
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -82,3 +272,23 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return value
 }
+
+// parseBitrates reads a comma separated list of kbit/s values.
+func parseBitrates(value string) ([]int, error) {
+	var bitrates []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kbps, err := strconv.Atoi(strings.TrimSuffix(part, "k"))
+		if err != nil || kbps < 16 || kbps > 320 {
+			return nil, fmt.Errorf("%q is not a bitrate between 16 and 320", part)
+		}
+		bitrates = append(bitrates, kbps)
+	}
+	if len(bitrates) == 0 {
+		return nil, fmt.Errorf("at least one bitrate is required")
+	}
+	return bitrates, nil
+}
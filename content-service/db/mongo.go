@@ -18,6 +18,14 @@ var SongsCollection *mongo.Collection
 var UserRatingsCollection *mongo.Collection
 var ArtistSubscriptionsCollection *mongo.Collection
 var GenreSubscriptionsCollection *mongo.Collection
+var ChartEntriesCollection *mongo.Collection
+var SongPlaysCollection *mongo.Collection
+var LastPlaysCollection *mongo.Collection
+var PlaylistsCollection *mongo.Collection
+var ChartRunsCollection *mongo.Collection
+var RevisionsCollection *mongo.Collection
+var AudioUploadsCollection *mongo.Collection
+var StreamSessionsCollection *mongo.Collection
 
 func ConnectMongo() {
 	const maxAttempts = 20
@@ -46,6 +54,14 @@ func ConnectMongo() {
 				UserRatingsCollection = db.Collection("user_ratings")
 				ArtistSubscriptionsCollection = db.Collection("artist_subscriptions")
 				GenreSubscriptionsCollection = db.Collection("genre_subscriptions")
+				ChartEntriesCollection = db.Collection("chart_entries")
+				SongPlaysCollection = db.Collection("song_plays")
+				LastPlaysCollection = db.Collection("last_plays")
+				PlaylistsCollection = db.Collection("playlists")
+				ChartRunsCollection = db.Collection("chart_runs")
+				RevisionsCollection = db.Collection("revisions")
+				AudioUploadsCollection = db.Collection("audio_uploads")
+				StreamSessionsCollection = db.Collection("stream_sessions")
 
 				fmt.Printf("Connected to MongoDB (content-service) after %d attempt(s)\n", attempt)
 				return
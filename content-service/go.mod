@@ -8,6 +8,7 @@ require (
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/joho/godotenv v1.5.1
 	go.mongodb.org/mongo-driver v1.17.6
+	golang.org/x/image v0.29.0
 	golang.org/x/time v0.14.0
 	shared-utils v0.0.0-00010101000000-000000000000
 )
@@ -23,6 +23,8 @@ type asyncEvent struct {
 }
 
 func emitRecommendationEvent(eventType string, data map[string]interface{}) {
+	updateSuggestIndex(eventType, data)
+
 	if recommendationEventsClient == nil || eventType == "" {
 		return
 	}
@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"content-service/config"
+	"content-service/models"
+	"content-service/repository"
+	"shared-utils/filevalidation"
+	"shared-utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Resumable uploads follow the core of the tus protocol: the client creates
+// an upload with its size and SHA-256, sends the bytes with PATCH requests
+// carrying Upload-Offset, asks for the offset with HEAD after a broken
+// connection, and completes the upload once every byte has arrived.
+//
+// Each chunk is stored in the blob store as a part under uploads/<id>/ and
+// recorded on the upload document, so chunks and the completion may reach
+// any instance.
+const (
+	uploadChunkContentType = "application/offset+octet-stream"
+
+	// statusChecksumMismatch is the tus status for a chunk whose
+	// Upload-Checksum does not match its bytes.
+	statusChecksumMismatch = 460
+)
+
+// uploadLocks serialises requests for the same upload on this instance.
+// Across instances the offset check in AdvanceAudioUpload decides.
+var uploadLocks sync.Map
+
+type createAudioUploadRequest struct {
+	Size     int64  `json:"size" binding:"required,gt=0"`
+	SHA256   string `json:"sha256" binding:"required"`
+	Filename string `json:"filename"`
+}
+
+func uploadPartPrefix(id primitive.ObjectID) string {
+	return "uploads/" + id.Hex() + "/"
+}
+
+// uploadPartKey names the part starting at offset. The random suffix keeps
+// two instances racing for the same offset from overwriting each other.
+func uploadPartKey(id primitive.ObjectID, offset int64) string {
+	return fmt.Sprintf("%s%020d-%s", uploadPartPrefix(id), offset, primitive.NewObjectID().Hex())
+}
+
+// uploadScratch creates a temporary file in UPLOAD_TEMP_DIR for one request.
+func uploadScratch() (*os.File, func(), error) {
+	if err := os.MkdirAll(config.UploadTempDir, 0o755); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.CreateTemp(config.UploadTempDir, "upload-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() {
+		f.Close()
+		_ = os.Remove(f.Name())
+	}, nil
+}
+
+func uploadURL(id primitive.ObjectID) string {
+	return "/api/content/uploads/" + id.Hex()
+}
+
+func setUploadHeaders(c *gin.Context, upload *models.AudioUpload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "no-store")
+}
+
+// CreateAudioUpload starts a resumable upload of the song's audio. The file
+// may be up to AUDIO_MAX_UPLOAD_MB, well above the limit of a single
+// multipart upload.
+func CreateAudioUpload(c *gin.Context) {
+	songID := strings.TrimSpace(c.Param("id"))
+	if err := validation.ValidateObjectIDFormat(songID, "song ID"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req createAudioUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size and sha256 are required"})
+		return
+	}
+	req.SHA256 = strings.ToLower(strings.TrimSpace(req.SHA256))
+	if !checksumPattern.MatchString(req.SHA256) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sha256 must be 64 hex characters"})
+		return
+	}
+	if err := filevalidation.ValidateFileSizeLimit(req.Size, config.AudioMaxUploadSize); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	song, err := repository.GetSongByID(songID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+
+	now := time.Now()
+	upload := &models.AudioUpload{
+		ID:        primitive.NewObjectID(),
+		SongID:    song.ID,
+		CreatedBy: c.GetString("userID"),
+		Filename:  path.Base(strings.ReplaceAll(strings.TrimSpace(req.Filename), "\\", "/")),
+		Size:      req.Size,
+		Checksum:  req.SHA256,
+		CreatedAt: now,
+		ExpiresAt: now.Add(config.UploadExpiry),
+	}
+
+	if err := repository.CreateAudioUpload(upload); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Header("Location", uploadURL(upload.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"upload": upload,
+		"url":    uploadURL(upload.ID),
+	})
+}
+
+// loadUpload finds the upload named in the path, which only the user who
+// created it can see. Uploads past their expiry are gone even before the
+// purger removes them. On failure it writes the error response and returns
+// nil.
+func loadUpload(c *gin.Context) *models.AudioUpload {
+	id, err := primitive.ObjectIDFromHex(c.Param("uploadId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return nil
+	}
+
+	upload, err := repository.GetAudioUpload(id)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load upload"})
+		return nil
+	}
+	if upload.CreatedBy != c.GetString("userID") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return nil
+	}
+	if !time.Now().Before(upload.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "Upload expired"})
+		return nil
+	}
+	return upload
+}
+
+func lockUpload(id primitive.ObjectID) func() {
+	mu, _ := uploadLocks.LoadOrStore(id.Hex(), &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// HeadAudioUpload reports how many bytes of the upload have arrived, so an
+// interrupted client knows where to resume.
+func HeadAudioUpload(c *gin.Context) {
+	upload := loadUpload(c)
+	if upload == nil {
+		return
+	}
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// PatchAudioUpload appends a chunk at Upload-Offset, which must be the
+// current offset. With Upload-Checksum: sha256 <base64> the chunk is only
+// kept when its hash matches; without it, whatever arrived before a broken
+// connection is kept.
+func PatchAudioUpload(c *gin.Context) {
+	if !strings.HasPrefix(c.ContentType(), uploadChunkContentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + uploadChunkContentType})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+		return
+	}
+
+	var chunkHash hash.Hash
+	var wantSum []byte
+	if header := strings.TrimSpace(c.GetHeader("Upload-Checksum")); header != "" {
+		algo, value, _ := strings.Cut(header, " ")
+		sum, err := base64.StdEncoding.DecodeString(value)
+		if algo != "sha256" || err != nil || len(sum) != sha256.Size {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Checksum must be sha256 <base64>"})
+			return
+		}
+		chunkHash, wantSum = sha256.New(), sum
+	}
+
+	upload := loadUpload(c)
+	if upload == nil {
+		return
+	}
+	unlock := lockUpload(upload.ID)
+	defer unlock()
+
+	// Re-read under the lock; a concurrent chunk may have moved the offset.
+	if upload = loadUpload(c); upload == nil {
+		return
+	}
+	if offset != upload.Offset {
+		setUploadHeaders(c, upload)
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the upload", "offset": upload.Offset})
+		return
+	}
+	remaining := upload.Size - upload.Offset
+	if c.Request.ContentLength > remaining {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Chunk goes past the declared upload size"})
+		return
+	}
+
+	// A large chunk may take longer than the server's read and write
+	// timeouts, which would cut the connection before the response is sent.
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.SetReadDeadline(time.Now().Add(config.UploadRequestTimeout))
+	_ = rc.SetWriteDeadline(time.Now().Add(config.UploadRequestTimeout))
+
+	// The chunk is received into a scratch file first, so a broken
+	// connection or a bad checksum leaves nothing in the blob store.
+	f, done, err := uploadScratch()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write upload"})
+		return
+	}
+	defer done()
+
+	var body io.Reader = io.LimitReader(c.Request.Body, remaining)
+	if chunkHash != nil {
+		body = io.TeeReader(body, chunkHash)
+	}
+	n, copyErr := io.Copy(f, body)
+
+	if chunkHash != nil && (copyErr != nil || string(chunkHash.Sum(nil)) != string(wantSum)) {
+		if copyErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read chunk"})
+			return
+		}
+		c.JSON(statusChecksumMismatch, gin.H{"error": "Chunk checksum mismatch"})
+		return
+	}
+
+	if n > 0 {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write upload"})
+			return
+		}
+		part := models.UploadPart{Key: uploadPartKey(upload.ID, upload.Offset), Size: n}
+		if err := Store.Put(c.Request.Context(), part.Key, f, n, "application/octet-stream"); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write upload"})
+			return
+		}
+
+		expiresAt := time.Now().Add(config.UploadExpiry)
+		advanced, err := repository.AdvanceAudioUpload(upload.ID, upload.Offset, part, expiresAt)
+		if err != nil || !advanced {
+			_ = Store.Delete(context.Background(), part.Key)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record upload progress"})
+			return
+		}
+		if !advanced {
+			// Another instance stored a chunk at this offset first.
+			if upload = loadUpload(c); upload == nil {
+				return
+			}
+			setUploadHeaders(c, upload)
+			c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the upload", "offset": upload.Offset})
+			return
+		}
+		upload.Offset += n
+		upload.ExpiresAt = expiresAt
+	}
+
+	setUploadHeaders(c, upload)
+	if copyErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chunk was cut short", "offset": upload.Offset})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CompleteAudioUpload checks the assembled file against the declared SHA-256
+// and then stores it like a regular upload, with the same ?duplicate= and
+// ?applyTags= options. The upload is kept when the audio is rejected, so the
+// request can be repeated with other options.
+func CompleteAudioUpload(c *gin.Context) {
+	duplicate, ok := duplicateMode(c)
+	if !ok {
+		return
+	}
+
+	upload := loadUpload(c)
+	if upload == nil {
+		return
+	}
+	unlock := lockUpload(upload.ID)
+	defer unlock()
+
+	// Re-read under the lock; a chunk may have finished or the upload been
+	// completed since.
+	if upload = loadUpload(c); upload == nil {
+		return
+	}
+	if upload.Offset != upload.Size {
+		setUploadHeaders(c, upload)
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload is not complete", "offset": upload.Offset, "size": upload.Size})
+		return
+	}
+
+	// Hashing and storing a large file may outlast the write timeout.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(config.UploadRequestTimeout))
+
+	f, done, err := uploadScratch()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	defer done()
+
+	checksum, err := assembleUpload(c.Request.Context(), upload, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	if checksum != upload.Checksum {
+		RemoveAudioUpload(upload)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Uploaded file does not match the declared sha256; start a new upload",
+			"expected": upload.Checksum,
+			"actual":   checksum,
+		})
+		return
+	}
+
+	song, err := repository.GetSongByID(upload.SongID.Hex())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+		return
+	}
+
+	storeSongAudio(c, song, f, upload.Size, upload.Filename, duplicate)
+	if c.Writer.Status() == http.StatusOK {
+		RemoveAudioUpload(upload)
+	}
+}
+
+// assembleUpload copies the upload's parts in order into w and returns the
+// SHA-256 of the whole file.
+func assembleUpload(ctx context.Context, upload *models.AudioUpload, w io.Writer) (string, error) {
+	h := sha256.New()
+	out := io.MultiWriter(w, h)
+	var total int64
+	for _, part := range upload.Parts {
+		r, _, err := Store.Get(ctx, part.Key)
+		if err != nil {
+			return "", err
+		}
+		n, err := io.Copy(out, r)
+		r.Close()
+		if err != nil {
+			return "", err
+		}
+		if n != part.Size {
+			return "", fmt.Errorf("upload part %s has %d bytes, want %d", part.Key, n, part.Size)
+		}
+		total += n
+	}
+	if total != upload.Size {
+		return "", fmt.Errorf("upload parts have %d bytes, want %d", total, upload.Size)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DeleteAudioUpload abandons an upload and frees its stored parts.
+func DeleteAudioUpload(c *gin.Context) {
+	upload := loadUpload(c)
+	if upload == nil {
+		return
+	}
+	unlock := lockUpload(upload.ID)
+	defer unlock()
+
+	RemoveAudioUpload(upload)
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveAudioUpload deletes an upload with its stored parts. The upload
+// purger calls it for uploads that expired. Parts are listed from storage
+// rather than taken from the document, so a part stored by a chunk that
+// never got recorded goes as well.
+func RemoveAudioUpload(upload *models.AudioUpload) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	parts, err := Store.List(ctx, uploadPartPrefix(upload.ID))
+	if err != nil && Logger != nil {
+		Logger.Application.Warn().Err(err).Str("upload_id", upload.ID.Hex()).Msg("Failed to list upload parts")
+	}
+	for _, part := range parts {
+		if err := Store.Delete(ctx, part.Key); err != nil && Logger != nil {
+			Logger.Application.Warn().Err(err).Str("key", part.Key).Msg("Failed to remove upload part")
+		}
+	}
+	if err := repository.DeleteAudioUpload(upload.ID); err != nil && Logger != nil {
+		Logger.Application.Warn().Err(err).Str("upload_id", upload.ID.Hex()).Msg("Failed to remove upload")
+	}
+	uploadLocks.Delete(upload.ID.Hex())
+}
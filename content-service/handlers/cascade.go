@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"content-service/config"
+	"content-service/models"
+	"content-service/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func queryFlag(c *gin.Context, name string) bool {
+	v := strings.ToLower(strings.TrimSpace(c.Query(name)))
+	return v == "true" || v == "1"
+}
+
+// runCascade reports the plan when dryRun is set and otherwise moves it to
+// the trash and emits a deletion event for every song, album and artist
+// taken out of the catalog. Ratings, playlist entries and audio files are
+// only removed when the trash is purged. On failure it writes the error
+// response and returns false.
+func runCascade(c *gin.Context, plan *repository.CascadePlan, dryRun bool) (models.CascadeReport, bool) {
+	if dryRun {
+		report, err := repository.CountCascade(plan)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to plan deletion"})
+			return report, false
+		}
+		report.DryRun = true
+		return report, true
+	}
+
+	// Read before trashing so every delete revision records what was removed.
+	docs, err := repository.LoadCascadeDocuments(plan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to plan deletion"})
+		return models.CascadeReport{}, false
+	}
+
+	report, err := repository.TrashCascade(plan, c.GetString("userID"))
+	if err != nil {
+		if Logger != nil {
+			Logger.Application.Error().Err(err).Str("artist_id", report.ArtistID).Strs("album_ids", report.AlbumIDs).Msg("Moving to trash stopped part way")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete; run the request again to finish"})
+		return report, false
+	}
+	purgeAt := report.DeletedAt.Add(config.TrashRetention)
+	report.PurgeAt = &purgeAt
+
+	for _, id := range plan.SongIDs {
+		var before interface{}
+		if song, ok := docs.Songs[id]; ok {
+			before = song
+		}
+		recordRevision(c, models.Revision{EntityType: models.RevisionEntitySong, EntityID: id, Action: models.RevisionActionDelete}, before, nil)
+	}
+	for _, id := range plan.AlbumIDs {
+		var before interface{}
+		if album, ok := docs.Albums[id]; ok {
+			before = album
+		}
+		recordRevision(c, models.Revision{EntityType: models.RevisionEntityAlbum, EntityID: id, Action: models.RevisionActionDelete}, before, nil)
+	}
+	if docs.Artist != nil {
+		recordRevision(c, models.Revision{EntityType: models.RevisionEntityArtist, EntityID: plan.ArtistID, Action: models.RevisionActionDelete}, *docs.Artist, nil)
+	}
+
+	for _, id := range report.SongIDs {
+		emitRecommendationEvent("song.deleted", map[string]interface{}{"songId": id})
+	}
+	for _, id := range report.AlbumIDs {
+		emitRecommendationEvent("album.deleted", map[string]interface{}{"albumId": id})
+	}
+	if report.ArtistID != "" {
+		emitRecommendationEvent("artist.deleted", map[string]interface{}{"artistId": report.ArtistID})
+	}
+
+	return report, true
+}
+
+// RemoveCascadeFiles deletes the audio files and images of a purged plan;
+// the trash purger calls it once the documents themselves are gone.
+func RemoveCascadeFiles(plan *repository.CascadePlan) {
+	for _, key := range plan.ImageKeys {
+		removeImageSet(key)
+	}
+	RemoveAudioFiles(plan.AudioFiles)
+}
+
+// RemoveAudioFiles deletes stored audio files and their HLS streams,
+// ignoring ones already gone. Files another song still points at are kept.
+func RemoveAudioFiles(files []string) {
+	for _, name := range files {
+		inUse, err := repository.AudioFileInUse(name)
+		if err != nil || inUse {
+			continue
+		}
+		if err := Store.Delete(context.Background(), audioKey(name)); err != nil && Logger != nil {
+			Logger.Application.Warn().Err(err).Str("audio_file", name).Msg("Failed to remove audio file")
+		}
+		removeStream(name)
+	}
+}
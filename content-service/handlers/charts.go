@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"content-service/models"
+	"content-service/repository"
+)
+
+const (
+	defaultChartLimit = 10
+	maxChartLimit     = 100
+)
+
+func GetCharts(c *gin.Context) {
+	window := strings.TrimSpace(c.DefaultQuery("window", "7d"))
+	if _, ok := repository.ChartWindows[window]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be one of 24h, 7d, 30d"})
+		return
+	}
+
+	sortBy := strings.TrimSpace(c.DefaultQuery("sort", repository.ChartSortCount))
+	if sortBy != repository.ChartSortCount && sortBy != repository.ChartSortAverage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be either count or average"})
+		return
+	}
+
+	limit := defaultChartLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxChartLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		limit = parsed
+	}
+
+	genre := strings.TrimSpace(c.Query("genre"))
+
+	response := models.ChartsResponse{
+		Window:  window,
+		Genre:   genre,
+		Sort:    sortBy,
+		Songs:   []models.ChartEntry{},
+		Artists: []models.ChartEntry{},
+		Albums:  []models.ChartEntry{},
+	}
+
+	run, err := repository.GetChartRun(window)
+	if err != nil {
+		Logger.Application.Error().Err(err).Str("window", window).Msg("Failed to load chart run")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch charts"})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	response.GeneratedAt = &run.ComputedAt
+
+	for chartType, target := range map[string]*[]models.ChartEntry{
+		models.ChartTypeSong:   &response.Songs,
+		models.ChartTypeArtist: &response.Artists,
+		models.ChartTypeAlbum:  &response.Albums,
+	} {
+		entries, err := repository.GetChartEntries(run.RunID, chartType, genre, sortBy, limit)
+		if err != nil {
+			Logger.Application.Error().Err(err).Str("window", window).Str("type", chartType).Msg("Failed to load chart")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch charts"})
+			return
+		}
+		if entries != nil {
+			*target = entries
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -72,6 +72,12 @@ func CreateAlbum(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, created)
 
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntityAlbum,
+		EntityID:   created.ID,
+		Action:     models.RevisionActionCreate,
+	}, nil, created)
+
 	emitRecommendationEvent("album.created", map[string]interface{}{
 		"albumId":  created.ID.Hex(),
 		"artistId": created.ArtistID.Hex(),
@@ -164,6 +170,12 @@ func CreateAlbumForArtist(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, created)
 
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntityAlbum,
+		EntityID:   created.ID,
+		Action:     models.RevisionActionCreate,
+	}, nil, created)
+
 	emitRecommendationEvent("album.created", map[string]interface{}{
 		"albumId":  created.ID.Hex(),
 		"artistId": created.ArtistID.Hex(),
@@ -39,6 +39,12 @@ func CreateArtist(c *gin.Context) {
 		"message": "Artist created",
 	})
 
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntityArtist,
+		EntityID:   artist.ID,
+		Action:     models.RevisionActionCreate,
+	}, nil, artist)
+
 	emitRecommendationEvent("artist.created", map[string]interface{}{
 		"artistId": artist.ID.Hex(),
 		"name":     artist.Name,
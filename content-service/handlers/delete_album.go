@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func DeleteAlbum(c *gin.Context) {
@@ -18,25 +19,38 @@ func DeleteAlbum(c *gin.Context) {
 		return
 	}
 
-	songCount, err := repository.CountSongsByAlbumID(objID)
+	cascade, dryRun := queryFlag(c, "cascade"), queryFlag(c, "dryRun")
+	if !cascade && !dryRun {
+		songCount, err := repository.CountSongsByAlbumID(objID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check dependencies"})
+			return
+		}
+
+		if songCount > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete album with existing songs; use ?cascade=true to delete them too"})
+			return
+		}
+	}
+
+	plan, err := repository.PlanAlbumCascade(objID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Album not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check dependencies"})
 		return
 	}
 
-	if songCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete album with existing songs"})
+	report, ok := runCascade(c, plan, dryRun)
+	if !ok {
 		return
 	}
-
-	if err := repository.DeleteAlbum(id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	if cascade || dryRun {
+		c.JSON(http.StatusOK, report)
 		return
 	}
 
-	emitRecommendationEvent("album.deleted", map[string]interface{}{
-		"albumId": id,
-	})
-
-	c.JSON(http.StatusOK, gin.H{"message": "Album deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "Album deleted", "deletionId": report.DeletionID})
 }
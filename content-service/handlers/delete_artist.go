@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func DeleteArtist(c *gin.Context) {
@@ -18,25 +19,38 @@ func DeleteArtist(c *gin.Context) {
 		return
 	}
 
-	albumCount, err := repository.CountAlbumsByArtistID(objID)
+	cascade, dryRun := queryFlag(c, "cascade"), queryFlag(c, "dryRun")
+	if !cascade && !dryRun {
+		albumCount, err := repository.CountAlbumsByArtistID(objID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check dependencies"})
+			return
+		}
+
+		if albumCount > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete artist with existing albums; use ?cascade=true to delete them too"})
+			return
+		}
+	}
+
+	plan, err := repository.PlanArtistCascade(objID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Artist not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check dependencies"})
 		return
 	}
 
-	if albumCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete artist with existing albums"})
+	report, ok := runCascade(c, plan, dryRun)
+	if !ok {
 		return
 	}
-
-	if err := repository.DeleteArtist(id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	if cascade || dryRun {
+		c.JSON(http.StatusOK, report)
 		return
 	}
 
-	emitRecommendationEvent("artist.deleted", map[string]interface{}{
-		"artistId": id,
-	})
-
-	c.JSON(http.StatusOK, gin.H{"message": "Artist deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "Artist deleted", "deletionId": report.DeletionID})
 }
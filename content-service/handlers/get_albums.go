@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -12,7 +13,16 @@ func GetAlbumsByArtist(c *gin.Context) {
 	artistID := c.Param("id")
 	searchQuery := strings.TrimSpace(c.Query("search"))
 
-	albums, err := repository.SearchAlbumsByArtistID(artistID, searchQuery)
+	opts, ok := parseListOptions(c, albumListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := repository.ListAlbumsByArtistID(artistID, searchQuery, opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to fetch albums for artist",
@@ -20,5 +30,5 @@ func GetAlbumsByArtist(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, albums)
+	respondWithPage(c, albumListSpec, page)
 }
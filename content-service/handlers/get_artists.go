@@ -1,12 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
-	"content-service/models"
 	"content-service/repository"
 )
 
@@ -22,15 +22,16 @@ func GetArtists(c *gin.Context) {
 		}
 	}
 
-	var artists []models.Artist
-	var err error
-
-	if searchQuery != "" || len(genres) > 0 {
-		artists, err = repository.SearchAndFilterArtists(searchQuery, genres)
-	} else {
-		artists, err = repository.GetAllArtists()
+	opts, ok := parseListOptions(c, artistListSpec)
+	if !ok {
+		return
 	}
 
+	page, err := repository.ListArtists(searchQuery, genres, opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch artists",
@@ -38,11 +39,7 @@ func GetArtists(c *gin.Context) {
 		return
 	}
 
-	if artists == nil {
-		artists = []models.Artist{}
-	}
-
-	c.JSON(http.StatusOK, artists)
+	respondWithPage(c, artistListSpec, page)
 }
 
 func GetArtistByID(c *gin.Context) {
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,7 +10,16 @@ import (
 )
 
 func GetGenres(c *gin.Context) {
-	genres, err := repository.GetAllGenres()
+	opts, ok := parseListOptions(c, genreListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := repository.ListGenres(opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch genres",
@@ -17,9 +27,5 @@ func GetGenres(c *gin.Context) {
 		return
 	}
 
-	if genres == nil {
-		genres = []string{}
-	}
-
-	c.JSON(http.StatusOK, genres)
+	respondWithPage(c, genreListSpec, page)
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -13,7 +14,16 @@ func GetSongsByAlbum(c *gin.Context) {
 	albumID := c.Param("id")
 	searchQuery := strings.TrimSpace(c.Query("search"))
 
-	songs, err := repository.SearchSongsByAlbumID(albumID, searchQuery)
+	opts, ok := parseListOptions(c, albumSongListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := repository.ListSongsByAlbumID(albumID, searchQuery, opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to fetch songs for album",
@@ -21,5 +31,5 @@ func GetSongsByAlbum(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, songs)
+	respondWithPage(c, albumSongListSpec, page)
 }
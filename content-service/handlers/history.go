@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"content-service/history"
+	"content-service/models"
+	"content-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"shared-utils/logging"
+)
+
+var revisionListSpec = listSpec{
+	sorts:       map[string]string{"version": "version"},
+	defaultSort: "version",
+}
+
+// recordRevision stores the next revision of an entity and writes the admin
+// action to the security log. before and after are the entity models around
+// the change; before is nil for a create and both are nil for a delete. The
+// change has already happened, so a failure is logged and not reported.
+func recordRevision(c *gin.Context, rev models.Revision, before, after interface{}) *models.Revision {
+	var beforeState, afterState map[string]interface{}
+	var err error
+	if before != nil {
+		beforeState, err = history.Snapshot(rev.EntityType, before)
+	}
+	if err == nil && after != nil {
+		afterState, err = history.Snapshot(rev.EntityType, after)
+	}
+
+	rev.ChangedBy = c.GetString("userID")
+	rev.ChangedAt = time.Now()
+	rev.Changes = history.Diff(beforeState, afterState)
+	rev.State = afterState
+	if err == nil {
+		err = repository.CreateRevision(&rev)
+	}
+	if err != nil && Logger != nil {
+		Logger.Application.Warn().Err(err).Str("entity_type", rev.EntityType).Str("entity_id", rev.EntityID.Hex()).Str("action", rev.Action).Msg("Failed to record revision")
+	}
+
+	if Logger != nil {
+		state := afterState
+		if state == nil {
+			state = beforeState
+		}
+		name, _ := state["name"].(string)
+		if name == "" {
+			name, _ = state["title"].(string)
+		}
+		Logger.LogAdminAction(logging.NewSecurityEventContext(c), rev.Action, rev.EntityType, rev.EntityID.Hex(), name)
+	}
+
+	if err != nil {
+		return nil
+	}
+	return &rev
+}
+
+func GetArtistHistory(c *gin.Context) {
+	listHistory(c, models.RevisionEntityArtist)
+}
+
+func GetAlbumHistory(c *gin.Context) {
+	listHistory(c, models.RevisionEntityAlbum)
+}
+
+func GetSongHistory(c *gin.Context) {
+	listHistory(c, models.RevisionEntitySong)
+}
+
+// listHistory pages the revisions of one entity. Trashed entities keep
+// their history, so it is served whether or not the entity is live.
+func listHistory(c *gin.Context, entityType string) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + entityType + " ID"})
+		return
+	}
+
+	opts, ok := parseListOptions(c, revisionListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := repository.ListRevisions(entityType, objID, opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+		return
+	}
+
+	respondWithPage(c, revisionListSpec, page)
+}
+
+func RevertArtist(c *gin.Context) {
+	target, ok := loadRevertTarget(c, models.RevisionEntityArtist)
+	if !ok {
+		return
+	}
+	id := target.EntityID.Hex()
+
+	before, err := repository.GetArtistByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Artist not found"})
+		return
+	}
+
+	var artist models.Artist
+	if err := history.Apply(target.State, &artist); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read revision"})
+		return
+	}
+	if err := repository.UpdateArtist(id, artist); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	rev := recordRevision(c, models.Revision{
+		EntityType:   models.RevisionEntityArtist,
+		EntityID:     target.EntityID,
+		Action:       models.RevisionActionRevert,
+		RevertedFrom: target.Version,
+	}, *before, artist)
+
+	emitRecommendationEvent("artist.updated", map[string]interface{}{
+		"artistId": id,
+		"name":     artist.Name,
+		"genres":   artist.Genres,
+	})
+
+	respondReverted(c, "Artist reverted", rev)
+}
+
+func RevertAlbum(c *gin.Context) {
+	target, ok := loadRevertTarget(c, models.RevisionEntityAlbum)
+	if !ok {
+		return
+	}
+	id := target.EntityID.Hex()
+
+	before, err := repository.GetAlbumByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Album not found"})
+		return
+	}
+
+	var album models.Album
+	if err := history.Apply(target.State, &album); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read revision"})
+		return
+	}
+
+	exists, err := repository.ArtistExistsByID(album.ArtistID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate artist"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "The artist of this revision no longer exists"})
+		return
+	}
+
+	if err := repository.UpdateAlbum(id, album); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	rev := recordRevision(c, models.Revision{
+		EntityType:   models.RevisionEntityAlbum,
+		EntityID:     target.EntityID,
+		Action:       models.RevisionActionRevert,
+		RevertedFrom: target.Version,
+	}, *before, album)
+
+	emitRecommendationEvent("album.updated", map[string]interface{}{
+		"albumId":  id,
+		"artistId": album.ArtistID.Hex(),
+		"genres":   album.Genres,
+	})
+
+	respondReverted(c, "Album reverted", rev)
+}
+
+func RevertSong(c *gin.Context) {
+	target, ok := loadRevertTarget(c, models.RevisionEntitySong)
+	if !ok {
+		return
+	}
+	id := target.EntityID.Hex()
+
+	before, err := repository.GetSongByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+
+	var song models.Song
+	if err := history.Apply(target.State, &song); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read revision"})
+		return
+	}
+
+	exists, err := repository.AlbumExistsByID(song.AlbumID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate album"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "The album of this revision no longer exists"})
+		return
+	}
+
+	if err := repository.UpdateSong(id, song); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	rev := recordRevision(c, models.Revision{
+		EntityType:   models.RevisionEntitySong,
+		EntityID:     target.EntityID,
+		Action:       models.RevisionActionRevert,
+		RevertedFrom: target.Version,
+	}, *before, song)
+
+	emitRecommendationEvent("song.updated", map[string]interface{}{
+		"songId":  id,
+		"title":   song.Title,
+		"albumId": song.AlbumID.Hex(),
+	})
+
+	respondReverted(c, "Song reverted", rev)
+}
+
+// loadRevertTarget reads the :id and :version being reverted to. Only
+// revisions that carry a state can be reverted to; deletions are undone
+// through restore instead.
+func loadRevertTarget(c *gin.Context, entityType string) (*models.Revision, bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + entityType + " ID"})
+		return nil, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return nil, false
+	}
+
+	rev, err := repository.GetRevision(entityType, objID, version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revision"})
+		return nil, false
+	}
+	if rev == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return nil, false
+	}
+	if rev.State == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Revision has no state to revert to; use restore for deletions"})
+		return nil, false
+	}
+	return rev, true
+}
+
+func respondReverted(c *gin.Context, message string, rev *models.Revision) {
+	if rev == nil {
+		c.JSON(http.StatusOK, gin.H{"message": message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "revision": rev})
+}
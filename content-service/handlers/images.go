@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"regexp"
+
+	"content-service/imaging"
+	"content-service/models"
+	"content-service/repository"
+	"content-service/storage"
+	"shared-utils/filevalidation"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	maxImageDimension = 4000
+	thumbnailQuality  = 85
+	originalImageName = "original"
+)
+
+// imageKeyPattern matches the keys uploadImage generates: the owner kind, its
+// id and a prefix of the content hash, so a new upload gets new URLs.
+var imageKeyPattern = regexp.MustCompile(`^(artist|album)-[0-9a-f]{24}-[0-9a-f]{12}$`)
+
+func UploadArtistImage(c *gin.Context) {
+	uploadImage(c, "artist", repository.SetArtistImage)
+}
+
+func UploadAlbumCover(c *gin.Context) {
+	uploadImage(c, "album", repository.SetAlbumCover)
+}
+
+// uploadImage validates the multipart field "file", stores the original
+// with its thumbnails and hands the result to store. Files of the image it
+// replaces are removed once the new one is saved.
+func uploadImage(c *gin.Context, kind string, store func(primitive.ObjectID, *models.ImageSet) (string, error)) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + kind + " ID"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing multipart file field: file"})
+		return
+	}
+	if err := filevalidation.ValidateFileSize(fileHeader.Size); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open uploaded file"})
+		return
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, filevalidation.MaxFileSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	if err := filevalidation.ValidateFileSize(int64(len(data))); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	if err := filevalidation.ValidateFileType(fileHeader.Filename, contentType, filevalidation.AllowedImageTypes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := filevalidation.ValidateImageDimensions(bytes.NewReader(data), maxImageDimension, maxImageDimension); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image data"})
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%s-%s-%x", kind, objID.Hex(), sum[:6])
+	set, err := writeImageSet(c.Request.Context(), key, data, img)
+	if err != nil {
+		removeImageSet(key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+		return
+	}
+
+	previous, err := store(objID, set)
+	if err == mongo.ErrNoDocuments {
+		removeImageSet(key)
+		c.JSON(http.StatusNotFound, gin.H{"error": kind + " not found"})
+		return
+	}
+	if err != nil {
+		removeImageSet(key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+		return
+	}
+	if previous != "" && previous != key {
+		removeImageSet(previous)
+	}
+
+	if Logger != nil {
+		Logger.Application.Info().
+			Str(kind+"_id", objID.Hex()).
+			Str("image_key", key).
+			Msg("Image uploaded")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Image uploaded",
+		"image":   set,
+	})
+}
+
+func writeImageSet(ctx context.Context, key string, original []byte, img image.Image) (*models.ImageSet, error) {
+	err := Store.Put(ctx, imageBlobKey(key, originalImageName), bytes.NewReader(original), int64(len(original)), http.DetectContentType(original))
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	set := &models.ImageSet{
+		Key:        key,
+		Width:      b.Dx(),
+		Height:     b.Dy(),
+		Original:   imageURL(key, originalImageName),
+		Thumbnails: make(map[string]string, len(imaging.Sizes)),
+	}
+	for name, size := range imaging.Sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, imaging.Thumbnail(img, size), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+			return nil, err
+		}
+		if err := Store.Put(ctx, imageBlobKey(key, name+".jpg"), &buf, int64(buf.Len()), "image/jpeg"); err != nil {
+			return nil, err
+		}
+		set.Thumbnails[name] = imageURL(key, name)
+	}
+	return set, nil
+}
+
+func imageURL(key, size string) string {
+	return fmt.Sprintf("/api/content/images/%s/%s", key, size)
+}
+
+// imageBlobKey is where one file of an image set is kept in the blob store.
+func imageBlobKey(key, name string) string {
+	return "images/" + key + "/" + name
+}
+
+func removeImageSet(key string) {
+	if !imageKeyPattern.MatchString(key) {
+		return
+	}
+	if err := storage.DeletePrefix(context.Background(), Store, "images/"+key+"/"); err != nil && Logger != nil {
+		Logger.Application.Warn().Err(err).Str("image_key", key).Msg("Failed to remove image files")
+	}
+}
+
+// StreamImage serves an original or thumbnail. A key never changes content,
+// so responses may be cached for a year.
+func StreamImage(c *gin.Context) {
+	key, size := c.Param("key"), c.Param("size")
+	if !imageKeyPattern.MatchString(key) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	name := originalImageName
+	if size != originalImageName {
+		if _, ok := imaging.Sizes[size]; !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown image size"})
+			return
+		}
+		name = size + ".jpg"
+	}
+
+	blobKey := imageBlobKey(key, name)
+	info, err := Store.Stat(c.Request.Context(), blobKey)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read image"})
+		return
+	}
+	content := storage.NewReadSeeker(c.Request.Context(), Store, blobKey, info.Size)
+	defer content.Close()
+
+	c.Writer.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	c.Writer.Header().Set("ETag", fmt.Sprintf(`"%s-%s"`, key, size))
+	if info.ContentType != "" {
+		c.Writer.Header().Set("Content-Type", info.ContentType)
+	}
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime, content)
+}
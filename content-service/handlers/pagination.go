@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"content-service/models"
+	"content-service/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// listSpec describes what a catalog listing accepts: sort keys mapped to
+// their bson fields, the default sort, and the fields ?fields= may select.
+type listSpec struct {
+	sorts       map[string]string
+	defaultSort string
+	fields      []string
+}
+
+var (
+	artistListSpec = listSpec{
+		sorts:       map[string]string{"id": "_id", "name": "name"},
+		defaultSort: "name",
+		fields:      []string{"id", "name", "image", "images", "biography", "genres"},
+	}
+	albumListSpec = listSpec{
+		sorts:       map[string]string{"id": "_id", "title": "title", "releaseDate": "releaseDate"},
+		defaultSort: "releaseDate",
+		fields:      []string{"id", "title", "releaseDate", "genres", "artistId", "cover"},
+	}
+	songListSpec = listSpec{
+		sorts:       map[string]string{"id": "_id", "title": "title", "trackNo": "trackNo"},
+		defaultSort: "title",
+		fields:      []string{"id", "title", "duration", "trackNo", "albumId", "audioFile", "playCount"},
+	}
+	albumSongListSpec = listSpec{
+		sorts:       songListSpec.sorts,
+		defaultSort: "trackNo",
+		fields:      songListSpec.fields,
+	}
+	genreListSpec = listSpec{
+		sorts:       map[string]string{"name": "name"},
+		defaultSort: "name",
+	}
+)
+
+// parseListOptions reads limit, cursor, sort, order and includeTotal. On
+// invalid input it writes a 400 response and returns false.
+func parseListOptions(c *gin.Context, spec listSpec) (repository.ListOptions, bool) {
+	opts := repository.ListOptions{Limit: defaultPageLimit}
+
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return opts, false
+		}
+		opts.Limit = limit
+	}
+
+	sortKey := strings.TrimSpace(c.DefaultQuery("sort", spec.defaultSort))
+	field, ok := spec.sorts[sortKey]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported sort field"})
+		return opts, false
+	}
+	opts.Sort = field
+
+	switch strings.ToLower(strings.TrimSpace(c.DefaultQuery("order", "asc"))) {
+	case "asc":
+	case "desc":
+		opts.Descending = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return opts, false
+	}
+
+	if raw := strings.TrimSpace(c.Query("cursor")); raw != "" {
+		after, err := repository.DecodeCursor(raw)
+		if err != nil || after.Sort != opts.Sort || after.Descending != opts.Descending {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return opts, false
+		}
+		opts.After = after
+	}
+
+	includeTotal := strings.ToLower(strings.TrimSpace(c.Query("includeTotal")))
+	opts.IncludeTotal = includeTotal == "true" || includeTotal == "1"
+
+	return opts, true
+}
+
+// respondWithPage writes the page, trimming each item to ?fields= when given.
+// The id is always kept so clients can still address the items.
+func respondWithPage[T any](c *gin.Context, spec listSpec, page models.Page[T]) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" || len(spec.fields) == 0 {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	selected := map[string]bool{"id": true}
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !containsString(spec.fields, f) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown field: " + f})
+			return
+		}
+		selected[f] = true
+	}
+
+	items := make([]map[string]interface{}, 0, len(page.Items))
+	for _, item := range page.Items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode items"})
+			return
+		}
+		var full map[string]interface{}
+		if err := json.Unmarshal(encoded, &full); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode items"})
+			return
+		}
+		trimmed := make(map[string]interface{}, len(selected))
+		for key := range selected {
+			if v, ok := full[key]; ok {
+				trimmed[key] = v
+			}
+		}
+		items = append(items, trimmed)
+	}
+
+	c.JSON(http.StatusOK, models.Page[map[string]interface{}]{
+		Items:      items,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	})
+}
+
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
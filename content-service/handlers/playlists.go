@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"content-service/models"
+	"content-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxPlaylistNameLength        = 100
+	maxPlaylistDescriptionLength = 1000
+)
+
+type CreatePlaylistRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+type UpdatePlaylistRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Visibility  *string `json:"visibility"`
+}
+
+type AddPlaylistSongRequest struct {
+	SongID   string `json:"songId" binding:"required"`
+	Position *int   `json:"position"`
+}
+
+type ReorderPlaylistRequest struct {
+	SongIDs []string `json:"songIds" binding:"required"`
+}
+
+type AddCollaboratorRequest struct {
+	UserID string `json:"userId" binding:"required"`
+}
+
+func CreatePlaylist(c *gin.Context) {
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreatePlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	name, description, visibility, msg := validatePlaylistFields(req.Name, req.Description, req.Visibility)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	playlist := models.Playlist{
+		OwnerID:     userID,
+		Name:        name,
+		Description: description,
+		Visibility:  visibility,
+	}
+	if err := repository.CreatePlaylist(&playlist); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create playlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, playlist)
+}
+
+func GetMyPlaylists(c *gin.Context) {
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	playlists, err := repository.GetPlaylistsForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch playlists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, playlists)
+}
+
+func GetPlaylistByID(c *gin.Context) {
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	playlist, ok := loadPlaylist(c)
+	if !ok {
+		return
+	}
+
+	// Private playlists are reported as missing so their ids do not leak.
+	if !playlist.CanView(userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playlist not found"})
+		return
+	}
+
+	songs, err := repository.GetSongsByIDs(playlist.SongIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch playlist songs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PlaylistDetail{Playlist: *playlist, Songs: songs})
+}
+
+func UpdatePlaylist(c *gin.Context) {
+	playlist, ok := loadOwnedPlaylist(c)
+	if !ok {
+		return
+	}
+
+	var req UpdatePlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	name, description, visibility := playlist.Name, playlist.Description, playlist.Visibility
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.Description != nil {
+		description = *req.Description
+	}
+	if req.Visibility != nil {
+		visibility = *req.Visibility
+	}
+
+	name, description, visibility, msg := validatePlaylistFields(name, description, visibility)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	err := repository.UpdatePlaylistFields(playlist.ID, bson.M{
+		"name":        name,
+		"description": description,
+		"visibility":  visibility,
+	})
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playlist not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update playlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Playlist updated"})
+}
+
+func DeletePlaylist(c *gin.Context) {
+	playlist, ok := loadOwnedPlaylist(c)
+	if !ok {
+		return
+	}
+
+	err := repository.DeletePlaylist(playlist.ID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playlist not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete playlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Playlist deleted"})
+}
+
+func AddPlaylistSong(c *gin.Context) {
+	playlist, ok := loadEditablePlaylist(c)
+	if !ok {
+		return
+	}
+
+	var req AddPlaylistSongRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	songID, err := primitive.ObjectIDFromHex(req.SongID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return
+	}
+
+	position := -1
+	if req.Position != nil {
+		if *req.Position < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Position must not be negative"})
+			return
+		}
+		position = *req.Position
+	}
+
+	exists, err := repository.SongExistsByID(songID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check song"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+
+	err = repository.AddSongToPlaylist(playlist.ID, songID, position)
+	if errors.Is(err, repository.ErrPlaylistConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Song is already in the playlist"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add song"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Song added to playlist"})
+}
+
+func RemovePlaylistSong(c *gin.Context) {
+	playlist, ok := loadEditablePlaylist(c)
+	if !ok {
+		return
+	}
+
+	songID, err := primitive.ObjectIDFromHex(c.Param("songId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return
+	}
+
+	err = repository.RemoveSongFromPlaylist(playlist.ID, songID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song is not in the playlist"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove song"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Song removed from playlist"})
+}
+
+func ReorderPlaylistSongs(c *gin.Context) {
+	playlist, ok := loadEditablePlaylist(c)
+	if !ok {
+		return
+	}
+
+	var req ReorderPlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	songIDs := make([]primitive.ObjectID, 0, len(req.SongIDs))
+	seen := make(map[primitive.ObjectID]bool, len(req.SongIDs))
+	for _, idStr := range req.SongIDs {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+			return
+		}
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Duplicate song ID"})
+			return
+		}
+		seen[id] = true
+		songIDs = append(songIDs, id)
+	}
+
+	err := repository.ReorderPlaylistSongs(playlist.ID, songIDs)
+	if errors.Is(err, repository.ErrPlaylistConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "songIds must list exactly the songs in the playlist"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder playlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Playlist reordered"})
+}
+
+func AddPlaylistCollaborator(c *gin.Context) {
+	playlist, ok := loadOwnedPlaylist(c)
+	if !ok {
+		return
+	}
+
+	var req AddCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	collaboratorID, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if playlist.IsOwner(collaboratorID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Owner cannot be added as collaborator"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	if err := ensureUserExists(ctx, req.UserID); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Timed out while verifying user"})
+			return
+		}
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to verify user"})
+		return
+	}
+
+	err = repository.AddPlaylistCollaborator(playlist.ID, collaboratorID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playlist not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add collaborator"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collaborator added"})
+}
+
+// RemovePlaylistCollaborator lets the owner remove anyone and a collaborator
+// leave the playlist on their own.
+func RemovePlaylistCollaborator(c *gin.Context) {
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	playlist, ok := loadPlaylist(c)
+	if !ok {
+		return
+	}
+
+	collaboratorID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if !playlist.IsOwner(userID) && collaboratorID != userID {
+		if !playlist.CanView(userID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Playlist not found"})
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can remove collaborators"})
+		return
+	}
+
+	err = repository.RemovePlaylistCollaborator(playlist.ID, collaboratorID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collaborator not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove collaborator"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collaborator removed"})
+}
+
+func loadPlaylist(c *gin.Context) (*models.Playlist, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid playlist ID"})
+		return nil, false
+	}
+
+	playlist, err := repository.GetPlaylistByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch playlist"})
+		return nil, false
+	}
+	if playlist == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playlist not found"})
+		return nil, false
+	}
+	return playlist, true
+}
+
+func loadOwnedPlaylist(c *gin.Context) (*models.Playlist, bool) {
+	return loadPlaylistWithAccess(c, (*models.Playlist).IsOwner, "Only the owner can change this playlist")
+}
+
+func loadEditablePlaylist(c *gin.Context) (*models.Playlist, bool) {
+	return loadPlaylistWithAccess(c, (*models.Playlist).CanEdit, "You cannot edit this playlist")
+}
+
+func loadPlaylistWithAccess(c *gin.Context, allowed func(*models.Playlist, primitive.ObjectID) bool, denied string) (*models.Playlist, bool) {
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	playlist, ok := loadPlaylist(c)
+	if !ok {
+		return nil, false
+	}
+
+	if !allowed(playlist, userID) {
+		if !playlist.CanView(userID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Playlist not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": denied})
+		return nil, false
+	}
+	return playlist, true
+}
+
+func validatePlaylistFields(name, description, visibility string) (string, string, string, string) {
+	name = strings.TrimSpace(name)
+	description = strings.TrimSpace(description)
+	visibility = strings.ToLower(strings.TrimSpace(visibility))
+
+	if name == "" {
+		return "", "", "", "Playlist name is required"
+	}
+	if len([]rune(name)) > maxPlaylistNameLength {
+		return "", "", "", "Playlist name is too long"
+	}
+	if len([]rune(description)) > maxPlaylistDescriptionLength {
+		return "", "", "", "Playlist description is too long"
+	}
+	if visibility == "" {
+		visibility = models.PlaylistVisibilityPrivate
+	}
+	if visibility != models.PlaylistVisibilityPublic && visibility != models.PlaylistVisibilityPrivate {
+		return "", "", "", "Visibility must be public or private"
+	}
+	return name, description, visibility, ""
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"content-service/models"
+	"content-service/repository"
+	"content-service/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	maxSearchQueryLen  = 200
+)
+
+var searchTypes = []string{"artists", "albums", "songs"}
+
+// Search looks q up in artists, albums and songs at once. Results are grouped
+// by type and ordered by text-search relevance within each group.
+func Search(c *gin.Context) {
+	raw := strings.TrimSpace(c.Query("q"))
+	if len([]rune(raw)) > maxSearchQueryLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is too long"})
+		return
+	}
+
+	terms := search.Terms(raw)
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+	query := strings.Join(terms, " ")
+
+	limit := defaultSearchLimit
+	if v := strings.TrimSpace(c.Query("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+			return
+		}
+		limit = n
+	}
+
+	types := map[string]bool{}
+	if v := strings.TrimSpace(c.Query("type")); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !containsString(searchTypes, t) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "type must be artists, albums or songs"})
+				return
+			}
+			types[t] = true
+		}
+	} else {
+		for _, t := range searchTypes {
+			types[t] = true
+		}
+	}
+
+	results := models.SearchResults{
+		Query:   raw,
+		Artists: []models.ArtistSearchHit{},
+		Albums:  []models.AlbumSearchHit{},
+		Songs:   []models.SongSearchHit{},
+	}
+
+	if types["artists"] {
+		hits, err := repository.SearchArtistsText(query, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search artists"})
+			return
+		}
+		for i := range hits {
+			hits[i].Highlights = highlightFields(terms, "name", hits[i].Name, "biography", hits[i].Biography)
+		}
+		if hits != nil {
+			results.Artists = hits
+		}
+	}
+
+	if types["albums"] {
+		hits, err := repository.SearchAlbumsText(query, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search albums"})
+			return
+		}
+		for i := range hits {
+			hits[i].Highlights = highlightFields(terms, "title", hits[i].Title)
+		}
+		if hits != nil {
+			results.Albums = hits
+		}
+	}
+
+	if types["songs"] {
+		hits, err := repository.SearchSongsText(query, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search songs"})
+			return
+		}
+		for i := range hits {
+			hits[i].Highlights = highlightFields(terms, "title", hits[i].Title)
+		}
+		if hits != nil {
+			results.Songs = hits
+		}
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// highlightFields takes field name and value pairs and returns the
+// highlights of those that contain a search term.
+func highlightFields(terms []string, pairs ...string) []models.Highlight {
+	highlights := []models.Highlight{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if h, ok := search.Highlight(pairs[i], pairs[i+1], terms); ok {
+			highlights = append(highlights, h)
+		}
+	}
+	return highlights
+}
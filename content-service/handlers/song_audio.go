@@ -1,21 +1,42 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"content-service/audiometa"
+	"content-service/config"
+	"content-service/jobs"
+	"content-service/models"
 	"content-service/repository"
+	"content-service/storage"
 	"shared-utils/filevalidation"
 	"shared-utils/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
-const songAudioDir = "./storage/audio"
-
+// UploadSongAudio stores the song's audio file and reads its format,
+// duration, bitrate, sample rate and channels from the file. With
+// ?applyTags=true the title and track number are taken from the file's tags
+// as well, when it has them.
+//
+// Files are stored in the blob store under their SHA-256. When another song
+// already has the same audio the upload is rejected with 409, or with
+// ?duplicate=link the song is pointed at the existing file.
+//
+// The file is then queued for transcoding into HLS renditions; the song's
+// stream stays pending until that is done. A linked song reuses the stream
+// of the song it was linked to.
 func UploadSongAudio(c *gin.Context) {
 	songID := strings.TrimSpace(c.Param("id"))
 	if err := validation.ValidateObjectIDFormat(songID, "song ID"); err != nil {
@@ -23,6 +44,11 @@ func UploadSongAudio(c *gin.Context) {
 		return
 	}
 
+	duplicate, ok := duplicateMode(c)
+	if !ok {
+		return
+	}
+
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing multipart file field: file"})
@@ -34,6 +60,12 @@ func UploadSongAudio(c *gin.Context) {
 		return
 	}
 
+	before, err := repository.GetSongByID(songID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+
 	src, err := fileHeader.Open()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open uploaded file"})
@@ -41,16 +73,43 @@ func UploadSongAudio(c *gin.Context) {
 	}
 	defer src.Close()
 
+	storeSongAudio(c, before, src, fileHeader.Size, fileHeader.Filename, duplicate)
+}
+
+// duplicateMode reads ?duplicate=reject|link, writing a 400 when it is
+// neither.
+func duplicateMode(c *gin.Context) (string, bool) {
+	duplicate := strings.ToLower(strings.TrimSpace(c.DefaultQuery("duplicate", "reject")))
+	if duplicate != "reject" && duplicate != "link" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate must be reject or link"})
+		return "", false
+	}
+	return duplicate, true
+}
+
+// audioSource is an uploaded audio file: a multipart file or an assembled
+// resumable upload.
+type audioSource interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+}
+
+// storeSongAudio validates and stores the uploaded audio of before and
+// writes the response.
+func storeSongAudio(c *gin.Context, before *models.Song, src audioSource, size int64, originalName string, duplicate string) {
+	songID := before.ID.Hex()
+
 	sniff := make([]byte, 512)
 	n, _ := src.Read(sniff)
 	contentType := http.DetectContentType(sniff[:n])
 
-	if _, err := src.Seek(0, 0); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process uploaded file"})
-		return
+	// The parser knows WAV and FLAC, which sniffing does not.
+	meta, metaErr := audiometa.Parse(src, size)
+	if metaErr == nil {
+		contentType = audiometa.ContentType(meta.Format)
 	}
 
-	originalName := fileHeader.Filename
 	ext := strings.ToLower(filepath.Ext(originalName))
 	if ext == "" {
 		switch contentType {
@@ -65,42 +124,131 @@ func UploadSongAudio(c *gin.Context) {
 		}
 	}
 
-	filename := songID + ext
-	if err := filevalidation.ValidateFileType(filename, contentType, filevalidation.AllowedAudioTypes); err != nil {
+	if err := filevalidation.ValidateFileType(songID+ext, contentType, filevalidation.AllowedAudioTypes); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if metaErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid audio file: " + metaErr.Error()})
+		return
+	}
 
-	if err := os.MkdirAll(songAudioDir, 0o755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create audio storage directory"})
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	checksum, err := filevalidation.CalculateChecksum(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
 		return
 	}
 
-	dstPath := filepath.Join(songAudioDir, filename)
+	existing, err := repository.FindSongByChecksum(checksum, before.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate audio"})
+		return
+	}
 
-	if err := c.SaveUploadedFile(fileHeader, dstPath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save audio file"})
+	var filename string
+	switch {
+	case existing != nil && duplicate == "reject":
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "The same audio is already uploaded for another song",
+			"songId": existing.ID.Hex(),
+		})
 		return
+	case existing != nil:
+		filename = existing.AudioFile
+	default:
+		filename = checksum + ext
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		if err := Store.Put(c.Request.Context(), audioKey(filename), src, size, contentType); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save audio file"})
+			return
+		}
 	}
 
-	if err := repository.SetSongAudioFile(songID, filename); err != nil {
-		_ = os.Remove(dstPath)
+	song := *before
+	song.AudioFile = filename
+	song.Checksum = checksum
+	song.Audio = &models.AudioInfo{
+		Format:     meta.Format,
+		DurationMs: meta.Duration.Milliseconds(),
+		Bitrate:    meta.Bitrate,
+		SampleRate: meta.SampleRate,
+		Channels:   meta.Channels,
+	}
+	song.Duration = strconv.Itoa(int(math.Round(meta.Duration.Seconds())))
+	song.Stream = &models.StreamInfo{Status: models.StreamStatusPending, UpdatedAt: time.Now()}
+	if existing != nil && existing.Stream != nil && existing.Stream.Status != models.StreamStatusFailed {
+		song.Stream = existing.Stream
+	}
+	song.Analysis = &models.AudioAnalysis{Status: models.AnalysisStatusPending, UpdatedAt: time.Now()}
+	if existing != nil && existing.Analysis != nil && existing.Analysis.Status != models.AnalysisStatusFailed {
+		song.Analysis = existing.Analysis
+	}
+	if queryFlag(c, "applyTags") {
+		if meta.Title != "" {
+			song.Title = meta.Title
+		}
+		if meta.TrackNo > 0 {
+			song.TrackNo = meta.TrackNo
+		}
+	}
+
+	if err := repository.SetSongAudio(songID, song); err != nil {
+		if existing == nil && filename != before.AudioFile {
+			RemoveAudioFiles([]string{filename})
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	if before.AudioFile != "" && before.AudioFile != filename {
+		RemoveAudioFiles([]string{before.AudioFile})
+	}
+	if song.Stream.Status == models.StreamStatusPending || song.Analysis.Status == models.AnalysisStatusPending {
+		jobs.EnqueueTranscode(song.ID)
+	}
+
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntitySong,
+		EntityID:   before.ID,
+		Action:     models.RevisionActionUpdate,
+	}, *before, song)
+
+	emitRecommendationEvent("song.updated", map[string]interface{}{
+		"songId":   songID,
+		"title":    song.Title,
+		"albumId":  song.AlbumID.Hex(),
+		"duration": song.Duration,
+	})
 
 	Logger.Application.Info().
 		Str("song_id", songID).
 		Str("audio_file", filename).
+		Bool("linked", existing != nil).
 		Msg("Song audio uploaded")
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Audio uploaded",
 		"songId":   songID,
-		"audioUrl": fmt.Sprintf("/api/content/songs/%s/audio", songID),
+		"title":    song.Title,
+		"trackNo":  song.TrackNo,
+		"duration": song.Duration,
+		"audio":    song.Audio,
+		"checksum": checksum,
+		"linked":   existing != nil,
+		"stream":   song.Stream,
+		"analysis": song.Analysis,
 	})
 }
 
+// StreamSongAudio serves the original audio file with range support. It is
+// only reached through a signed URL from IssueStreamURL, so every stream
+// counts against the user's session limit.
 func StreamSongAudio(c *gin.Context) {
 	songID := strings.TrimSpace(c.Param("id"))
 	if err := validation.ValidateObjectIDFormat(songID, "song ID"); err != nil {
@@ -118,20 +266,39 @@ func StreamSongAudio(c *gin.Context) {
 		return
 	}
 
-	p := filepath.Join(songAudioDir, song.AudioFile)
-	f, err := os.Open(p)
-	if err != nil {
+	info, err := Store.Stat(c.Request.Context(), audioKey(song.AudioFile))
+	if err == storage.ErrNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Audio file not found on server"})
 		return
 	}
-	defer f.Close()
-
-	st, err := f.Stat()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read audio file"})
 		return
 	}
+	// A slow listener may take longer than the server's write timeout.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(config.StreamWriteTimeout))
+
+	content := storage.NewReadSeeker(c.Request.Context(), Store, audioKey(song.AudioFile), info.Size)
+	defer content.Close()
 
 	c.Writer.Header().Set("Cache-Control", "private, max-age=0, no-store")
-	http.ServeContent(c.Writer, c.Request, song.AudioFile, st.ModTime().UTC(), f)
+	if exp, ok := c.Get("streamExpiresAt"); ok {
+		// The browser may keep the response for as long as the signed URL is
+		// valid. Shared caches may not: the URL grants the user's access.
+		if left := int(time.Until(exp.(time.Time)).Seconds()); left > 0 {
+			c.Writer.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", left))
+		}
+	}
+	if sum, err := hex.DecodeString(song.Checksum); err == nil && len(sum) == sha256.Size {
+		c.Writer.Header().Set("ETag", `"`+song.Checksum+`"`)
+		c.Writer.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum))
+	}
+	if info.ContentType != "" {
+		c.Writer.Header().Set("Content-Type", info.ContentType)
+	}
+	http.ServeContent(c.Writer, c.Request, song.AudioFile, info.ModTime, content)
+
+	if c.Request.Method == http.MethodGet {
+		trackStreamedBytes(c, song.ID, info.Size)
+	}
 }
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"content-service/config"
+	"content-service/models"
+	"content-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+
+	// streamSessionIdle is how long a partially streamed song keeps counting
+	// towards a play before its progress is forgotten.
+	streamSessionIdle = 30 * time.Minute
+)
+
+type streamProgress struct {
+	bytes    int64
+	lastSeen time.Time
+}
+
+// streamTracker adds up the bytes streamed per user and song across range
+// requests, so a play is counted once enough of the file was delivered.
+type streamTracker struct {
+	mu       sync.Mutex
+	sessions map[string]*streamProgress
+}
+
+var playTracker = &streamTracker{sessions: make(map[string]*streamProgress)}
+
+// add reports whether this chunk pushed the stream past the play threshold.
+func (t *streamTracker) add(userID, songID string, written, fileSize int64) bool {
+	if written <= 0 || fileSize <= 0 {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if len(t.sessions) > 1000 {
+		for key, p := range t.sessions {
+			if now.Sub(p.lastSeen) > streamSessionIdle {
+				delete(t.sessions, key)
+			}
+		}
+	}
+
+	key := userID + ":" + songID
+	p, ok := t.sessions[key]
+	if !ok || now.Sub(p.lastSeen) > streamSessionIdle {
+		p = &streamProgress{}
+		t.sessions[key] = p
+	}
+	p.bytes += written
+	p.lastSeen = now
+
+	if p.bytes*100 < fileSize*int64(config.PlayThresholdPercent) {
+		return false
+	}
+	delete(t.sessions, key)
+	return true
+}
+
+func trackStreamedBytes(c *gin.Context, songID primitive.ObjectID, fileSize int64) {
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		return
+	}
+	if playTracker.add(userID.Hex(), songID.Hex(), int64(c.Writer.Size()), fileSize) {
+		recordPlay(userID, songID, models.PlaySourceStream)
+	}
+}
+
+func recordPlay(userID, songID primitive.ObjectID, source string) (*models.SongPlay, bool, error) {
+	play, recorded, err := repository.RecordPlay(userID, songID, source, config.PlayDedupWindow)
+	if err != nil {
+		if Logger != nil {
+			Logger.Application.Error().Err(err).Str("song_id", songID.Hex()).Msg("Failed to record play")
+		}
+		return nil, false, err
+	}
+	if !recorded {
+		return nil, false, nil
+	}
+
+	emitRecommendationEvent("song.played", map[string]interface{}{
+		"userId":   userID.Hex(),
+		"songId":   songID.Hex(),
+		"playedAt": play.PlayedAt.UTC().Format(time.RFC3339),
+	})
+	return play, true, nil
+}
+
+func RecordSongPlay(c *gin.Context) {
+	songID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return
+	}
+
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exists, err := repository.SongExistsByID(songID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate song"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+
+	play, recorded, err := recordPlay(userID, songID, models.PlaySourceClient)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record play"})
+		return
+	}
+	if !recorded {
+		c.JSON(http.StatusOK, gin.H{"recorded": false})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"recorded": true, "play": play})
+}
+
+func GetSongPlayCount(c *gin.Context) {
+	songID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return
+	}
+
+	count, err := repository.GetSongPlayCount(songID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch play count"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"songId": songID.Hex(), "playCount": count})
+}
+
+func GetListeningHistory(c *gin.Context) {
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit := defaultHistoryLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxHistoryLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = parsed
+	}
+
+	var before time.Time
+	if beforeStr := strings.TrimSpace(c.Query("before")); beforeStr != "" {
+		parsed, err := time.Parse(time.RFC3339, beforeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "before must be an RFC3339 timestamp"})
+			return
+		}
+		before = parsed
+	}
+
+	items, err := repository.GetListeningHistory(userID, before, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listening history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func currentUserObjectID(c *gin.Context) (primitive.ObjectID, bool) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		return primitive.NilObjectID, false
+	}
+	userIDStr, ok := userIDVal.(string)
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"content-service/config"
+	"content-service/models"
+	"content-service/repository"
+	"content-service/storage"
+	"content-service/transcode"
+	"shared-utils/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// streamRetryAfter is suggested to players asking for a stream that is still
+// being transcoded.
+const streamRetryAfter = "10"
+
+var checksumPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// streamPrefix is where the HLS renditions of an audio file are kept in the
+// blob store. Like the audio file, it is named after the checksum, so songs
+// linked to the same audio share one stream.
+func streamPrefix(checksum string) string {
+	return "hls/" + checksum + "/"
+}
+
+// TranscodeSongAudio encodes the song's audio into the configured HLS
+// renditions and analyzes it, recording both outcomes on the song. The
+// transcoder workers call it for every queued song; only the parts still
+// pending are done.
+func TranscodeSongAudio(id primitive.ObjectID) {
+	song, err := repository.GetSongByID(id.Hex())
+	if err != nil {
+		return
+	}
+	transcodeStream := song.Stream != nil && song.Stream.Status == models.StreamStatusPending
+	analyze := song.Analysis != nil && song.Analysis.Status == models.AnalysisStatusPending
+	if !transcodeStream && !analyze {
+		return
+	}
+
+	fail := func(cause error) {
+		if transcodeStream {
+			failStream(song, cause)
+		}
+		if analyze {
+			failAnalysis(song, cause)
+		}
+	}
+	if !checksumPattern.MatchString(song.Checksum) || song.AudioFile == "" {
+		fail(fmt.Errorf("song has no stored audio"))
+		return
+	}
+
+	work, err := os.MkdirTemp("", "transcode-*")
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer os.RemoveAll(work)
+
+	fetchCtx, cancelFetch := context.WithTimeout(context.Background(), config.TranscodeTimeout)
+	input, err := fetchAudio(fetchCtx, song, work)
+	cancelFetch()
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// Each step gets its own timeout, so a slow analysis does not eat into
+	// the time the transcode has.
+	if analyze {
+		ctx, cancel := context.WithTimeout(context.Background(), config.AnalysisTimeout)
+		analyzeSongAudio(ctx, song, input)
+		cancel()
+	}
+	if transcodeStream {
+		ctx, cancel := context.WithTimeout(context.Background(), config.TranscodeTimeout)
+		transcodeSongStream(ctx, song, input, work)
+		cancel()
+	}
+}
+
+func transcodeSongStream(ctx context.Context, song *models.Song, input, work string) {
+	sourceKbps := 0
+	if song.Audio != nil {
+		sourceKbps = song.Audio.Bitrate / 1000
+	}
+	bitrates := transcode.Ladder(config.HLSBitrates, sourceKbps)
+	opts := transcode.Options{
+		FFmpeg:         config.FFmpegPath,
+		Bitrates:       bitrates,
+		SegmentSeconds: config.HLSSegmentSeconds,
+	}
+
+	start := time.Now()
+	if err := transcodeToStore(ctx, opts, song, input, work); err != nil {
+		failStream(song, err)
+		return
+	}
+
+	stream := &models.StreamInfo{
+		Status:     models.StreamStatusReady,
+		Renditions: bitrates,
+		UpdatedAt:  time.Now(),
+	}
+	if err := repository.SetSongStream(song.ID, song.Checksum, stream); err != nil {
+		if Logger != nil {
+			Logger.Application.Error().Err(err).Str("song_id", song.ID.Hex()).Msg("Failed to save stream status")
+		}
+		return
+	}
+
+	if Logger != nil {
+		Logger.Application.Info().
+			Str("song_id", song.ID.Hex()).
+			Ints("renditions", bitrates).
+			Dur("took", time.Since(start)).
+			Msg("Song audio transcoded")
+	}
+}
+
+// fetchAudio copies the song's audio from the blob store into the scratch
+// directory work and returns its path.
+func fetchAudio(ctx context.Context, song *models.Song, work string) (string, error) {
+	body, _, err := Store.Get(ctx, audioKey(song.AudioFile))
+	if err != nil {
+		return "", fmt.Errorf("reading audio: %w", err)
+	}
+	defer body.Close()
+
+	input := filepath.Join(work, "source"+filepath.Ext(song.AudioFile))
+	f, err := os.Create(input)
+	if err == nil {
+		_, err = io.Copy(f, body)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading audio: %w", err)
+	}
+	return input, nil
+}
+
+// transcodeToStore encodes input in the scratch directory work and uploads
+// the renditions, playlists last, under the stream prefix.
+func transcodeToStore(ctx context.Context, opts transcode.Options, song *models.Song, input, work string) error {
+	out := filepath.Join(work, "hls")
+	if err := transcode.Run(ctx, opts, input, out, opts.Bitrates); err != nil {
+		return err
+	}
+
+	var segments, playlists []string
+	err := filepath.WalkDir(out, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if d.Name() == transcode.VariantPlaylist {
+			playlists = append(playlists, p)
+		} else {
+			segments = append(segments, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range append(segments, playlists...) {
+		rel, err := filepath.Rel(out, p)
+		if err != nil {
+			return err
+		}
+		if err := putFile(ctx, streamPrefix(song.Checksum)+filepath.ToSlash(rel), p); err != nil {
+			return fmt.Errorf("storing %s: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+func putFile(ctx context.Context, key, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	contentType, _ := transcode.ContentType(filepath.Base(p))
+	return Store.Put(ctx, key, f, st.Size(), contentType)
+}
+
+func failStream(song *models.Song, cause error) {
+	stream := &models.StreamInfo{
+		Status:    models.StreamStatusFailed,
+		Error:     cause.Error(),
+		UpdatedAt: time.Now(),
+	}
+	if err := repository.SetSongStream(song.ID, song.Checksum, stream); err != nil && Logger != nil {
+		Logger.Application.Error().Err(err).Str("song_id", song.ID.Hex()).Msg("Failed to save stream status")
+	}
+	if Logger != nil {
+		Logger.Application.Warn().Err(cause).Str("song_id", song.ID.Hex()).Msg("Song audio transcode failed")
+	}
+}
+
+// removeStream deletes the HLS renditions of an audio file. Names that are
+// not checksums never had a stream.
+func removeStream(audioFile string) {
+	checksum := strings.TrimSuffix(filepath.Base(audioFile), filepath.Ext(audioFile))
+	if !checksumPattern.MatchString(checksum) {
+		return
+	}
+	if err := storage.DeletePrefix(context.Background(), Store, streamPrefix(checksum)); err != nil && Logger != nil {
+		Logger.Application.Warn().Err(err).Str("audio_file", audioFile).Msg("Failed to remove stream files")
+	}
+}
+
+// readyStream loads the song of the request and checks that its stream can
+// be served. On failure it writes the error response and returns nil; a
+// stream still being transcoded gets 503 with Retry-After.
+func readyStream(c *gin.Context) *models.Song {
+	songID := strings.TrimSpace(c.Param("id"))
+	if err := validation.ValidateObjectIDFormat(songID, "song ID"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	song, err := repository.GetSongByID(songID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return nil
+	}
+
+	switch {
+	case song.Stream == nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": "No stream for this song"})
+		return nil
+	case song.Stream.Status == models.StreamStatusPending:
+		c.Header("Retry-After", streamRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Stream is being prepared", "status": song.Stream.Status})
+		return nil
+	case song.Stream.Status != models.StreamStatusReady:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stream is not available", "status": song.Stream.Status})
+		return nil
+	}
+	return song
+}
+
+// GetSongStreamPlaylist serves the HLS master playlist of a song. Rendition
+// paths are relative, so the player requests them next to this URL.
+func GetSongStreamPlaylist(c *gin.Context) {
+	song := readyStream(c)
+	if song == nil {
+		return
+	}
+
+	c.Writer.Header().Set("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, transcode.PlaylistContentType, transcode.Master(song.Stream.Renditions, "stream/"))
+}
+
+// StreamSongSegment serves a rendition playlist or one of its segments.
+func StreamSongSegment(c *gin.Context) {
+	song := readyStream(c)
+	if song == nil {
+		return
+	}
+
+	rendition, name := c.Param("rendition"), c.Param("file")
+	kbps, ok := transcode.ParseRendition(rendition)
+	if !ok || !containsInt(song.Stream.Renditions, kbps) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown rendition"})
+		return
+	}
+	contentType, ok := transcode.ContentType(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stream file not found"})
+		return
+	}
+
+	key := streamPrefix(song.Checksum) + rendition + "/" + name
+	info, err := Store.Stat(c.Request.Context(), key)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stream file not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read stream file"})
+		return
+	}
+	// A slow listener may take longer than the server's write timeout.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(config.StreamWriteTimeout))
+
+	content := storage.NewReadSeeker(c.Request.Context(), Store, key, info.Size)
+	defer content.Close()
+
+	c.Writer.Header().Set("Content-Type", contentType)
+	c.Writer.Header().Set("Cache-Control", "private, no-cache")
+	c.Writer.Header().Set("ETag", fmt.Sprintf(`"%s-%s-%s"`, song.Checksum, rendition, name))
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime, content)
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"content-service/analysis"
+	"content-service/config"
+	"content-service/models"
+	"content-service/repository"
+	"shared-utils/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// analyzeSongAudio measures the loudness and waveform of the song's audio,
+// already fetched to input, and records them on the song.
+func analyzeSongAudio(ctx context.Context, song *models.Song, input string) {
+	channels := 2
+	if song.Audio != nil && song.Audio.Channels == 1 {
+		channels = 1
+	}
+
+	start := time.Now()
+	result, err := analysis.Analyze(ctx, config.FFmpegPath, input, channels, config.WaveformPoints)
+	if err != nil {
+		failAnalysis(song, err)
+		return
+	}
+
+	measured := &models.AudioAnalysis{
+		Status:     models.AnalysisStatusReady,
+		Loudness:   result.Loudness,
+		ReplayGain: result.ReplayGain,
+		Peak:       result.SamplePeak,
+		Waveform:   result.Waveform,
+		UpdatedAt:  time.Now(),
+	}
+	if err := repository.SetSongAnalysis(song.ID, song.Checksum, measured); err != nil {
+		if Logger != nil {
+			Logger.Application.Error().Err(err).Str("song_id", song.ID.Hex()).Msg("Failed to save audio analysis")
+		}
+		return
+	}
+
+	if Logger != nil {
+		Logger.Application.Info().
+			Str("song_id", song.ID.Hex()).
+			Float64("loudness", result.Loudness).
+			Dur("took", time.Since(start)).
+			Msg("Song audio analyzed")
+	}
+}
+
+func failAnalysis(song *models.Song, cause error) {
+	failed := &models.AudioAnalysis{
+		Status:    models.AnalysisStatusFailed,
+		Error:     cause.Error(),
+		UpdatedAt: time.Now(),
+	}
+	if err := repository.SetSongAnalysis(song.ID, song.Checksum, failed); err != nil && Logger != nil {
+		Logger.Application.Error().Err(err).Str("song_id", song.ID.Hex()).Msg("Failed to save audio analysis")
+	}
+	if Logger != nil {
+		Logger.Application.Warn().Err(cause).Str("song_id", song.ID.Hex()).Msg("Song audio analysis failed")
+	}
+}
+
+// GetSongWaveform returns the song's peak waveform, each point between 0 and
+// 1, with the loudness values the player uses to normalize volume.
+// ?points= reduces the waveform to fewer points for narrow scrubbers.
+func GetSongWaveform(c *gin.Context) {
+	songID := strings.TrimSpace(c.Param("id"))
+	if err := validation.ValidateObjectIDFormat(songID, "song ID"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	points := config.WaveformPoints
+	if raw := c.Query("points"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "points must be a positive integer"})
+			return
+		}
+		points = min(n, config.WaveformPoints)
+	}
+
+	song, err := repository.GetSongByID(songID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+
+	switch {
+	case song.Analysis == nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": "No waveform for this song"})
+		return
+	case song.Analysis.Status == models.AnalysisStatusPending:
+		c.Header("Retry-After", streamRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Waveform is being prepared", "status": song.Analysis.Status})
+		return
+	case song.Analysis.Status != models.AnalysisStatusReady:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Waveform is not available", "status": song.Analysis.Status})
+		return
+	}
+
+	// The waveform only changes with the audio.
+	etag := fmt.Sprintf(`"%s-waveform-%d"`, song.Checksum, points)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	peaks := analysis.Levels(analysis.Downsample(song.Analysis.Waveform, points))
+	c.JSON(http.StatusOK, gin.H{
+		"songId":     songID,
+		"points":     len(peaks),
+		"peaks":      peaks,
+		"loudness":   song.Analysis.Loudness,
+		"replayGain": song.Analysis.ReplayGain,
+		"peak":       song.Analysis.Peak,
+	})
+}
@@ -3,12 +3,14 @@ package handlers
 import (
 	"content-service/models"
 	"content-service/repository"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateSongRequest struct {
@@ -31,20 +33,22 @@ type UpdateSongRequest struct {
 func GetSongs(c *gin.Context) {
 	searchQuery := strings.TrimSpace(c.Query("search"))
 
-	var songs []models.Song
-	var err error
-
-	if searchQuery != "" {
-		songs, err = repository.SearchSongs(searchQuery)
-	} else {
-		songs, err = repository.GetAllSongs()
+	opts, ok := parseListOptions(c, songListSpec)
+	if !ok {
+		return
 	}
 
+	page, err := repository.ListSongs(searchQuery, opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch songs"})
 		return
 	}
-	c.JSON(http.StatusOK, songs)
+
+	respondWithPage(c, songListSpec, page)
 }
 
 func GetSongByID(c *gin.Context) {
@@ -104,6 +108,12 @@ func CreateSong(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, created)
 
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntitySong,
+		EntityID:   created.ID,
+		Action:     models.RevisionActionCreate,
+	}, nil, *created)
+
 	emitRecommendationEvent("song.created", map[string]interface{}{
 		"songId":   created.ID.Hex(),
 		"title":    created.Title,
@@ -164,6 +174,12 @@ func CreateSongForAlbum(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, created)
 
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntitySong,
+		EntityID:   created.ID,
+		Action:     models.RevisionActionCreate,
+	}, nil, *created)
+
 	emitRecommendationEvent("song.created", map[string]interface{}{
 		"songId":   created.ID.Hex(),
 		"title":    created.Title,
@@ -198,6 +214,12 @@ func UpdateSong(c *gin.Context) {
 		return
 	}
 
+	before, err := repository.GetSongByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "song not found"})
+		return
+	}
+
 	exists, err := repository.AlbumExistsByID(albumObjID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate album"})
@@ -219,20 +241,51 @@ func UpdateSong(c *gin.Context) {
 		return
 	}
 
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntitySong,
+		EntityID:   before.ID,
+		Action:     models.RevisionActionUpdate,
+	}, *before, song)
+
+	emitRecommendationEvent("song.updated", map[string]interface{}{
+		"songId":  id,
+		"title":   song.Title,
+		"albumId": song.AlbumID.Hex(),
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Song updated"})
 }
 
+// DeleteSong also removes the song's ratings, playlist entries and audio
+// file; ?dryRun=true only reports them.
 func DeleteSong(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := repository.DeleteSong(id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
 		return
 	}
 
-	emitRecommendationEvent("song.deleted", map[string]interface{}{
-		"songId": id,
-	})
+	plan, err := repository.PlanSongCascade(objID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "song not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check dependencies"})
+		return
+	}
+
+	dryRun := queryFlag(c, "dryRun")
+	report, ok := runCascade(c, plan, dryRun)
+	if !ok {
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Song deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "Song deleted", "deletionId": report.DeletionID})
 }
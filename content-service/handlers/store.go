@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"path"
+
+	"content-service/storage"
+)
+
+// Store holds the audio files, images and HLS streams.
+var Store storage.BlobStore
+
+func SetStore(s storage.BlobStore) {
+	Store = s
+}
+
+func audioKey(audioFile string) string {
+	return "audio/" + path.Base(audioFile)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"content-service/config"
+	"content-service/repository"
+	"content-service/streamurl"
+	"shared-utils/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IssueStreamURL returns a signed URL for streaming the song's audio that
+// works without the JWT until it expires. Each URL belongs to a stream
+// session; passing ?session= with the session of an earlier URL keeps the
+// player in that session, otherwise a new one is opened and the user's
+// oldest sessions beyond MAX_CONCURRENT_STREAMS stop working.
+func IssueStreamURL(c *gin.Context) {
+	songID := strings.TrimSpace(c.Param("id"))
+	if err := validation.ValidateObjectIDFormat(songID, "song ID"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := currentUserObjectID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	song, err := repository.GetSongByID(songID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+	if strings.TrimSpace(song.AudioFile) == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audio not uploaded for this song"})
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.StreamURLTTL).Truncate(time.Second)
+	session, ended, err := repository.OpenStreamSession(userID.Hex(), strings.TrimSpace(c.Query("session")), config.MaxConcurrentStreams, expiresAt, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open stream session"})
+		return
+	}
+
+	query := streamurl.Sign(config.StreamURLSecret, streamurl.Grant{
+		SongID:    songID,
+		UserID:    userID.Hex(),
+		Session:   session,
+		ExpiresAt: expiresAt,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":           fmt.Sprintf("/api/content/songs/%s/audio/signed?%s", songID, query.Encode()),
+		"expiresAt":     expiresAt,
+		"session":       session,
+		"endedSessions": ended,
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"content-service/jobs"
+	"content-service/suggest"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSuggestLimit = 5
+	maxSuggestPrefixLen = 100
+)
+
+// Suggest answers typeahead lookups from the in-memory index; it never
+// touches the database, so it is cheap enough to call on every keystroke.
+func Suggest(c *gin.Context) {
+	prefix := c.Query("prefix")
+	if len([]rune(prefix)) > maxSuggestPrefixLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is too long"})
+		return
+	}
+
+	limit := defaultSuggestLimit
+	if v := strings.TrimSpace(c.Query("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > suggest.MaxResults {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", suggest.MaxResults)})
+			return
+		}
+		limit = n
+	}
+
+	found := suggest.Default.Lookup(prefix, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"prefix":  prefix,
+		"artists": suggestionsOf(found, suggest.TypeArtist),
+		"albums":  suggestionsOf(found, suggest.TypeAlbum),
+		"songs":   suggestionsOf(found, suggest.TypeSong),
+	})
+}
+
+func suggestionsOf(found map[string][]suggest.Entry, typ string) []suggest.Entry {
+	if entries := found[typ]; entries != nil {
+		return entries
+	}
+	return []suggest.Entry{}
+}
+
+// updateSuggestIndex keeps the typeahead index in step with catalog events.
+// New titles are added at once; renames and deletions trigger a rebuild.
+// Updates that carry the indexed title unchanged, such as an audio upload,
+// leave the index alone.
+func updateSuggestIndex(eventType string, data map[string]interface{}) {
+	str := func(key string) string {
+		s, _ := data[key].(string)
+		return s
+	}
+
+	switch eventType {
+	case "artist.created":
+		suggest.Default.Add(suggest.Entry{Type: suggest.TypeArtist, ID: str("artistId"), Text: str("name")})
+	case "album.created":
+		suggest.Default.Add(suggest.Entry{Type: suggest.TypeAlbum, ID: str("albumId"), Text: str("title")})
+	case "song.created":
+		suggest.Default.Add(suggest.Entry{Type: suggest.TypeSong, ID: str("songId"), Text: str("title")})
+	case "artist.updated":
+		rebuildUnlessIndexed(suggest.TypeArtist, str("artistId"), str("name"))
+	case "song.updated":
+		rebuildUnlessIndexed(suggest.TypeSong, str("songId"), str("title"))
+	case "artist.deleted", "album.updated", "album.deleted", "song.deleted":
+		jobs.RequestSuggestRebuild()
+	}
+}
+
+func rebuildUnlessIndexed(typ, id, text string) {
+	if indexed, ok := suggest.Default.Text(typ, id); ok && text != "" && indexed == text {
+		return
+	}
+	jobs.RequestSuggestRebuild()
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"content-service/models"
+	"content-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var trashFields = []string{"deletedAt", "deletedBy", "deletionId"}
+
+var (
+	trashedArtistListSpec = trashListSpec(artistListSpec)
+	trashedAlbumListSpec  = trashListSpec(albumListSpec)
+	trashedSongListSpec   = trashListSpec(songListSpec)
+)
+
+// trashListSpec sorts the trash by deletion time and lets ?fields= pick the
+// trash fields next to the entity's own.
+func trashListSpec(base listSpec) listSpec {
+	return listSpec{
+		sorts:       map[string]string{"id": "_id", "deletedAt": "deletedAt"},
+		defaultSort: "deletedAt",
+		fields:      append(append([]string{}, base.fields...), trashFields...),
+	}
+}
+
+// GetTrash lists the trashed artists, albums or songs, chosen by ?type=.
+func GetTrash(c *gin.Context) {
+	switch c.Query("type") {
+	case "artists":
+		listTrash(c, trashedArtistListSpec, repository.ListTrashedArtists)
+	case "albums":
+		listTrash(c, trashedAlbumListSpec, repository.ListTrashedAlbums)
+	case "songs":
+		listTrash(c, trashedSongListSpec, repository.ListTrashedSongs)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of artists, albums, songs"})
+	}
+}
+
+func listTrash[T any](c *gin.Context, spec listSpec, list func(repository.ListOptions) (models.Page[T], error)) {
+	opts, ok := parseListOptions(c, spec)
+	if !ok {
+		return
+	}
+
+	page, err := list(opts)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	respondWithPage(c, spec, page)
+}
+
+func RestoreArtist(c *gin.Context) {
+	restoreFromTrash(c, "Artist", repository.GetTrashedArtist, nil)
+}
+
+func RestoreAlbum(c *gin.Context) {
+	restoreFromTrash(c, "Album", repository.GetTrashedAlbum, func(item *repository.TrashedItem) (bool, error) {
+		return repository.ArtistExistsByID(item.ArtistID)
+	})
+}
+
+func RestoreSong(c *gin.Context) {
+	restoreFromTrash(c, "Song", repository.GetTrashedSong, func(item *repository.TrashedItem) (bool, error) {
+		return repository.AlbumExistsByID(item.AlbumID)
+	})
+}
+
+// restoreFromTrash brings back the whole deletion the item was trashed
+// with. An item whose parent is still in the trash cannot come back on its
+// own; parentLive is nil for artists, which have no parent.
+func restoreFromTrash(
+	c *gin.Context,
+	kind string,
+	get func(primitive.ObjectID) (*repository.TrashedItem, error),
+	parentLive func(*repository.TrashedItem) (bool, error),
+) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + strings.ToLower(kind) + " ID"})
+		return
+	}
+
+	item, err := get(objID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": kind + " not found in trash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch " + strings.ToLower(kind)})
+		return
+	}
+
+	if parentLive != nil {
+		ok, err := parentLive(item)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check dependencies"})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusConflict, gin.H{"error": "The parent of this " + strings.ToLower(kind) + " is in the trash; restore it first"})
+			return
+		}
+	}
+
+	restored, err := repository.RestoreDeletion(item.DeletionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore; run the request again to finish"})
+		return
+	}
+
+	artistIDs := make([]string, 0, len(restored.Artists))
+	for _, a := range restored.Artists {
+		artistIDs = append(artistIDs, a.ID.Hex())
+		recordRevision(c, models.Revision{EntityType: models.RevisionEntityArtist, EntityID: a.ID, Action: models.RevisionActionRestore}, a, a)
+		emitRecommendationEvent("artist.created", map[string]interface{}{
+			"artistId": a.ID.Hex(),
+			"name":     a.Name,
+			"genres":   a.Genres,
+		})
+	}
+	albumIDs := make([]string, 0, len(restored.Albums))
+	for _, a := range restored.Albums {
+		albumIDs = append(albumIDs, a.ID.Hex())
+		recordRevision(c, models.Revision{EntityType: models.RevisionEntityAlbum, EntityID: a.ID, Action: models.RevisionActionRestore}, a, a)
+		emitRecommendationEvent("album.created", map[string]interface{}{
+			"albumId":  a.ID.Hex(),
+			"artistId": a.ArtistID.Hex(),
+			"title":    a.Title,
+			"genres":   a.Genres,
+		})
+	}
+	songIDs := make([]string, 0, len(restored.Songs))
+	for _, s := range restored.Songs {
+		songIDs = append(songIDs, s.ID.Hex())
+		recordRevision(c, models.Revision{EntityType: models.RevisionEntitySong, EntityID: s.ID, Action: models.RevisionActionRestore}, s, s)
+		emitRecommendationEvent("song.created", map[string]interface{}{
+			"songId":   s.ID.Hex(),
+			"title":    s.Title,
+			"albumId":  s.AlbumID.Hex(),
+			"duration": s.Duration,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    kind + " restored",
+		"deletionId": item.DeletionID.Hex(),
+		"artistIds":  artistIDs,
+		"albumIds":   albumIDs,
+		"songIds":    songIDs,
+	})
+}
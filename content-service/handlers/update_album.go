@@ -46,6 +46,12 @@ func UpdateAlbum(c *gin.Context) {
 		return
 	}
 
+	before, err := repository.GetAlbumByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "album not found"})
+		return
+	}
+
 	exists, err := repository.ArtistExistsByID(artistObjID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate artist"})
@@ -68,6 +74,12 @@ func UpdateAlbum(c *gin.Context) {
 		return
 	}
 
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntityAlbum,
+		EntityID:   before.ID,
+		Action:     models.RevisionActionUpdate,
+	}, *before, album)
+
 	emitRecommendationEvent("album.updated", map[string]interface{}{
 		"albumId":  id,
 		"artistId": req.ArtistID,
@@ -37,6 +37,12 @@ func UpdateArtist(c *gin.Context) {
 		req.Genres[i] = strings.TrimSpace(req.Genres[i])
 	}
 
+	before, err := repository.GetArtistByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "artist not found"})
+		return
+	}
+
 	artist := models.Artist{
 		Name:      req.Name,
 		Biography: req.Biography,
@@ -48,6 +54,12 @@ func UpdateArtist(c *gin.Context) {
 		return
 	}
 
+	recordRevision(c, models.Revision{
+		EntityType: models.RevisionEntityArtist,
+		EntityID:   before.ID,
+		Action:     models.RevisionActionUpdate,
+	}, *before, artist)
+
 	emitRecommendationEvent("artist.updated", map[string]interface{}{
 		"artistId": id,
 		"name":     req.Name,
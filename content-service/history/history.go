@@ -0,0 +1,73 @@
+package history
+
+import (
+	"reflect"
+	"sort"
+
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Fields are the bson fields of each entity type that revisions track and a
+// revert writes back. Generated fields such as search and play counts are
+// left out.
+var Fields = map[string][]string{
+	models.RevisionEntityArtist: {"name", "biography", "genres"},
+	models.RevisionEntityAlbum:  {"title", "releaseDate", "genres", "artistId"},
+	models.RevisionEntitySong:   {"title", "duration", "trackNo", "albumId"},
+}
+
+// Snapshot returns the tracked fields of doc as they are stored in MongoDB,
+// so snapshots taken from models and from revisions compare equal.
+func Snapshot(entityType string, doc interface{}) (map[string]interface{}, error) {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]interface{}
+	if err := bson.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]interface{}, len(Fields[entityType]))
+	for _, field := range Fields[entityType] {
+		if v, ok := all[field]; ok {
+			state[field] = v
+		}
+	}
+	return state, nil
+}
+
+// Diff lists the fields whose value differs between before and after, in
+// field name order. A nil before means the entity was just created, a nil
+// after that it was deleted.
+func Diff(before, after map[string]interface{}) []models.FieldChange {
+	names := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		names[k] = true
+	}
+	for k := range after {
+		names[k] = true
+	}
+
+	changes := []models.FieldChange{}
+	for name := range names {
+		b, a := before[name], after[name]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: name, Before: b, After: a})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// Apply decodes a revision state into out, a pointer to the entity model.
+func Apply(state map[string]interface{}, out interface{}) error {
+	raw, err := bson.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, out)
+}
@@ -0,0 +1,67 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSnapshotAndDiff(t *testing.T) {
+	artistID := primitive.NewObjectID()
+	before, err := Snapshot(models.RevisionEntityAlbum, models.Album{
+		ID:          primitive.NewObjectID(),
+		Title:       "Rani radovi",
+		ReleaseDate: "1981",
+		Genres:      []string{"rock"},
+		ArtistID:    artistID,
+		Search:      &models.SearchFields{Primary: "rani radovi"},
+	})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if _, ok := before["search"]; ok {
+		t.Fatalf("snapshot keeps untracked field: %v", before)
+	}
+
+	after, err := Snapshot(models.RevisionEntityAlbum, models.Album{
+		Title:       "Rani radovi",
+		ReleaseDate: "1982",
+		Genres:      []string{"rock"},
+		ArtistID:    artistID,
+	})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	got := Diff(before, after)
+	want := []models.FieldChange{{Field: "releaseDate", Before: "1981", After: "1982"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff = %+v, want %+v", got, want)
+	}
+
+	if created := Diff(nil, after); len(created) != 4 || created[0].Field != "artistId" || created[0].Before != nil {
+		t.Fatalf("Diff(nil, after) = %+v", created)
+	}
+	if deleted := Diff(before, nil); len(deleted) != 4 || deleted[2].Field != "releaseDate" || deleted[2].Before != "1981" || deleted[2].After != nil {
+		t.Fatalf("Diff(before, nil) = %+v", deleted)
+	}
+}
+
+func TestApply(t *testing.T) {
+	albumID := primitive.NewObjectID()
+	state, err := Snapshot(models.RevisionEntitySong, models.Song{Title: "Neko to zove", Duration: "3:12", TrackNo: 2, AlbumID: albumID})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	var song models.Song
+	if err := Apply(state, &song); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if song.Title != "Neko to zove" || song.TrackNo != 2 || song.AlbumID != albumID {
+		t.Fatalf("Apply = %+v", song)
+	}
+}
@@ -0,0 +1,62 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Sizes are the thumbnail names and edge lengths generated for every upload.
+var Sizes = map[string]int{
+	"small":  96,
+	"medium": 300,
+	"large":  640,
+}
+
+// Thumbnail crops the centre square of src and scales it down to size x size
+// by averaging the source pixels under each target pixel. Images smaller
+// than size are cropped but never enlarged. Transparent areas are laid on
+// white, so the result can be stored as JPEG.
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	if size > side {
+		size = side
+	}
+
+	crop := image.Rect(0, 0, side, side)
+	square := image.NewRGBA(crop)
+	offset := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+	draw.Draw(square, crop, image.White, image.Point{}, draw.Src)
+	draw.Draw(square, crop, src, offset, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := square.Pix[sy*square.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			o := dst.PixOffset(x, y)
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(bl / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
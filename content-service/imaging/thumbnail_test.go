@@ -0,0 +1,54 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnailCropsAndScales(t *testing.T) {
+	// 300x100: a red square in the middle between two blue ones.
+	src := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x >= 100 && x < 200 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	thumb := Thumbnail(src, 10)
+	if got := thumb.Bounds(); got != image.Rect(0, 0, 10, 10) {
+		t.Fatalf("bounds = %v, want 10x10", got)
+	}
+	for _, p := range []image.Point{{0, 0}, {9, 9}, {5, 5}} {
+		if c := thumb.RGBAAt(p.X, p.Y); c != (color.RGBA{R: 255, A: 255}) {
+			t.Fatalf("pixel %v = %v, want the red centre", p, c)
+		}
+	}
+}
+
+func TestThumbnailAveragesAndNeverEnlarges(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range src.Pix {
+		if (i/4+i%4)%2 == 0 {
+			src.Pix[i] = 200
+		}
+	}
+
+	if got := Thumbnail(src, 64).Bounds(); got != image.Rect(0, 0, 4, 4) {
+		t.Fatalf("bounds = %v, want 4x4", got)
+	}
+	if c := Thumbnail(src, 1).RGBAAt(0, 0); c.R != 100 || c.A != 255 {
+		t.Fatalf("averaged pixel = %v, want gray 100", c)
+	}
+}
+
+func TestThumbnailFlattensTransparency(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	if c := Thumbnail(src, 2).RGBAAt(1, 1); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Fatalf("transparent pixel = %v, want white", c)
+	}
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"content-service/repository"
+)
+
+// StartChartsRefresher materialises the charts of every window right away and
+// then again on each interval, so reading a chart is a single indexed query.
+func StartChartsRefresher(interval time.Duration) {
+	if err := repository.EnsureChartIndexes(); err != nil {
+		fmt.Printf("Warning: failed to create chart indexes: %v\n", err)
+	}
+
+	go func() {
+		refreshCharts()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			refreshCharts()
+		}
+	}()
+}
+
+func refreshCharts() {
+	for window, span := range repository.ChartWindows {
+		start := time.Now()
+		if err := repository.RefreshChart(window, span); err != nil {
+			fmt.Printf("Failed refreshing %s chart: %v\n", window, err)
+			continue
+		}
+		fmt.Printf("Refreshed %s chart in %v\n", window, time.Since(start))
+	}
+}
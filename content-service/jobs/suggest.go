@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"content-service/repository"
+	"content-service/suggest"
+)
+
+var suggestRebuilds = make(chan struct{}, 1)
+
+// StartSuggestIndexer builds the typeahead index right away, then rebuilds it
+// on each interval, so rating changes reach the weights, and whenever a
+// rebuild is requested.
+func StartSuggestIndexer(interval time.Duration) {
+	go func() {
+		rebuildSuggestIndex()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-suggestRebuilds:
+			}
+			rebuildSuggestIndex()
+		}
+	}()
+}
+
+// RequestSuggestRebuild schedules a rebuild after titles were changed or
+// removed. Requests made while one is pending are merged.
+func RequestSuggestRebuild() {
+	select {
+	case suggestRebuilds <- struct{}{}:
+	default:
+	}
+}
+
+func rebuildSuggestIndex() {
+	start := time.Now()
+	entries, err := repository.LoadSuggestEntries()
+	if err != nil {
+		fmt.Printf("Failed rebuilding suggest index: %v\n", err)
+		return
+	}
+	suggest.Default.Replace(entries, start)
+	fmt.Printf("Rebuilt suggest index with %d entries in %v\n", len(entries), time.Since(start))
+}
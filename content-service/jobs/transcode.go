@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"fmt"
+
+	"content-service/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var transcodeQueue = make(chan primitive.ObjectID, 256)
+
+// StartTranscoder runs workers that turn uploaded audio into HLS streams and
+// analyze it, by handing queued songs to transcodeSong. The pending statuses
+// on the song are the durable part of the queue: songs still pending from a
+// previous run, and songs uploaded before analysis existed, are queued on
+// start.
+func StartTranscoder(workers int, transcodeSong func(primitive.ObjectID)) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for id := range transcodeQueue {
+				transcodeSong(id)
+			}
+		}()
+	}
+
+	go func() {
+		if n, err := repository.QueueMissingAnalyses(); err != nil {
+			fmt.Printf("Failed queueing audio analyses: %v\n", err)
+		} else if n > 0 {
+			fmt.Printf("Queued %d songs for audio analysis\n", n)
+		}
+
+		ids, err := repository.ListPendingAudioSongIDs()
+		if err != nil {
+			fmt.Printf("Failed loading pending audio work: %v\n", err)
+			return
+		}
+		// Waiting here for free slots is fine, nothing else is held up.
+		for _, id := range ids {
+			transcodeQueue <- id
+		}
+		if len(ids) > 0 {
+			fmt.Printf("Queued %d songs with pending audio work\n", len(ids))
+		}
+	}()
+}
+
+// EnqueueTranscode schedules a song for transcoding without blocking the
+// caller. When the queue is full the song is dropped; it keeps its pending
+// status and is queued again on the next start.
+func EnqueueTranscode(id primitive.ObjectID) {
+	select {
+	case transcodeQueue <- id:
+	default:
+		fmt.Printf("Transcode queue full, song %s stays pending until restart\n", id.Hex())
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"content-service/repository"
+)
+
+// StartTrashPurger permanently removes artists, albums and songs that have
+// been in the trash for longer than retention, checking on each interval.
+// removeFiles is handed every purged plan to delete its audio and images.
+func StartTrashPurger(retention, interval time.Duration, removeFiles func(*repository.CascadePlan)) {
+	go func() {
+		purgeTrash(retention, removeFiles)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purgeTrash(retention, removeFiles)
+		}
+	}()
+}
+
+func purgeTrash(retention time.Duration, removeFiles func(*repository.CascadePlan)) {
+	plans, err := repository.PlanTrashPurge(time.Now().Add(-retention))
+	if err != nil {
+		fmt.Printf("Failed planning trash purge: %v\n", err)
+		return
+	}
+
+	for _, plan := range plans {
+		report, err := repository.ExecuteCascade(plan)
+		if err != nil {
+			fmt.Printf("Failed purging trash: %v\n", err)
+			continue
+		}
+		removeFiles(plan)
+		fmt.Printf("Purged %d songs and %d albums from trash\n", len(report.SongIDs), len(report.AlbumIDs))
+	}
+}
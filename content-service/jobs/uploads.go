@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"content-service/models"
+	"content-service/repository"
+)
+
+// StartUploadPurger removes resumable uploads that stopped receiving data
+// before their expiry, checking on each interval. remove deletes an upload
+// together with its temporary file.
+func StartUploadPurger(interval time.Duration, remove func(*models.AudioUpload)) {
+	go func() {
+		purgeUploads(remove)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purgeUploads(remove)
+		}
+	}()
+}
+
+func purgeUploads(remove func(*models.AudioUpload)) {
+	uploads, err := repository.ListExpiredAudioUploads(time.Now())
+	if err != nil {
+		fmt.Printf("Failed listing expired uploads: %v\n", err)
+		return
+	}
+
+	for i := range uploads {
+		remove(&uploads[i])
+	}
+	if len(uploads) > 0 {
+		fmt.Printf("Purged %d expired uploads\n", len(uploads))
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"content-service/config"
 	"content-service/db"
 	"content-service/handlers"
+	"content-service/jobs"
 	"content-service/middleware"
+	"content-service/repository"
+	"content-service/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -44,7 +47,40 @@ func main() {
 	handlers.SetLogger(logger)
 	middleware.SetLogger(logger)
 
+	store, err := storage.Open(config.Storage)
+	if err != nil {
+		log.Fatal("Failed to open storage:", err)
+	}
+	handlers.SetStore(store)
+	logger.Application.Info().Str("backend", config.Storage.Backend).Msg("Storage ready")
+
 	db.ConnectMongo()
+	jobs.StartChartsRefresher(config.ChartsRefreshInterval)
+	jobs.StartSuggestIndexer(config.SuggestRebuildInterval)
+	jobs.StartTrashPurger(config.TrashRetention, config.TrashPurgeInterval, handlers.RemoveCascadeFiles)
+	jobs.StartTranscoder(config.TranscodeWorkers, handlers.TranscodeSongAudio)
+	jobs.StartUploadPurger(config.UploadPurgeInterval, handlers.RemoveAudioUpload)
+	if err := repository.EnsureSongPlayIndexes(); err != nil {
+		log.Printf("Warning: failed to create song play indexes: %v", err)
+	}
+	if err := repository.EnsurePlaylistIndexes(); err != nil {
+		log.Printf("Warning: failed to create playlist indexes: %v", err)
+	}
+	if err := repository.EnsureSearchIndexes(); err != nil {
+		log.Printf("Warning: failed to prepare search indexes: %v", err)
+	}
+	if err := repository.EnsureRevisionIndexes(); err != nil {
+		log.Printf("Warning: failed to create revision indexes: %v", err)
+	}
+	if err := repository.EnsureSongIndexes(); err != nil {
+		log.Printf("Warning: failed to create song indexes: %v", err)
+	}
+	if err := repository.EnsureAudioUploadIndexes(); err != nil {
+		log.Printf("Warning: failed to create audio upload indexes: %v", err)
+	}
+	if err := repository.EnsureStreamSessionIndexes(); err != nil {
+		log.Printf("Warning: failed to create stream session indexes: %v", err)
+	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		if err := validation.RegisterCustomValidators(v); err != nil {
@@ -78,6 +114,10 @@ func main() {
 			artists.POST("", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.CreateArtist)
 			artists.PUT("/:id", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.UpdateArtist)
 			artists.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.DeleteArtist)
+			artists.POST("/:id/image", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.UploadArtistImage)
+			artists.POST("/:id/restore", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.RestoreArtist)
+			artists.GET("/:id/history", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.GetArtistHistory)
+			artists.POST("/:id/history/:version/revert", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.RevertArtist)
 		}
 
 		albums := api.Group("/albums")
@@ -88,13 +128,21 @@ func main() {
 			albums.POST("", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.CreateAlbum)
 			albums.PUT("/:id", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.UpdateAlbum)
 			albums.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.DeleteAlbum)
+			albums.POST("/:id/cover", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.UploadAlbumCover)
+			albums.POST("/:id/restore", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.RestoreAlbum)
+			albums.GET("/:id/history", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.GetAlbumHistory)
+			albums.POST("/:id/history/:version/revert", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.RevertAlbum)
 		}
 
 		songs := api.Group("/songs")
 		{
 			songs.GET("", handlers.GetSongs)
 			songs.GET("/:id", handlers.GetSongByID)
-			songs.GET("/:id/audio", middleware.AuthMiddleware(), handlers.StreamSongAudio)
+			songs.GET("/:id/audio/signed", middleware.SignedStreamMiddleware(), handlers.StreamSongAudio)
+			songs.POST("/:id/stream-url", middleware.AuthMiddleware(), handlers.IssueStreamURL)
+			songs.GET("/:id/stream.m3u8", middleware.AuthMiddleware(), handlers.GetSongStreamPlaylist)
+			songs.GET("/:id/stream/:rendition/:file", middleware.AuthMiddleware(), handlers.StreamSongSegment)
+			songs.GET("/:id/waveform", middleware.AuthMiddleware(), handlers.GetSongWaveform)
 
 			songs.POST(
 				"",
@@ -110,6 +158,13 @@ func main() {
 				handlers.UploadSongAudio,
 			)
 
+			songs.POST(
+				"/:id/uploads",
+				middleware.AuthMiddleware(),
+				middleware.RequireRole("ADMIN"),
+				handlers.CreateAudioUpload,
+			)
+
 			songs.PUT(
 				"/:id",
 				middleware.AuthMiddleware(),
@@ -124,10 +179,63 @@ func main() {
 				handlers.DeleteSong,
 			)
 
+			songs.POST(
+				"/:id/restore",
+				middleware.AuthMiddleware(),
+				middleware.RequireRole("ADMIN"),
+				handlers.RestoreSong,
+			)
+
+			songs.GET(
+				"/:id/history",
+				middleware.AuthMiddleware(),
+				middleware.RequireRole("ADMIN"),
+				handlers.GetSongHistory,
+			)
+
+			songs.POST(
+				"/:id/history/:version/revert",
+				middleware.AuthMiddleware(),
+				middleware.RequireRole("ADMIN"),
+				handlers.RevertSong,
+			)
+
 			songs.GET("/:id/rating", middleware.AuthMiddleware(), handlers.GetUserRating)
 			songs.POST("/:id/rating", middleware.AuthMiddleware(), handlers.SetRating)
 			songs.DELETE("/:id/rating", middleware.AuthMiddleware(), handlers.DeleteRating)
 			songs.GET("/:id/rating/average", handlers.GetAverageRating) // Public endpoint
+
+			songs.POST("/:id/plays", middleware.AuthMiddleware(), handlers.RecordSongPlay)
+			songs.GET("/:id/plays", handlers.GetSongPlayCount)
+		}
+
+		uploads := api.Group("/uploads", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"))
+		{
+			uploads.HEAD("/:uploadId", handlers.HeadAudioUpload)
+			uploads.PATCH("/:uploadId", handlers.PatchAudioUpload)
+			uploads.DELETE("/:uploadId", handlers.DeleteAudioUpload)
+			uploads.POST("/:uploadId/complete", handlers.CompleteAudioUpload)
+		}
+
+		api.GET("/images/:key/:size", handlers.StreamImage)
+		api.GET("/search", handlers.Search)
+		api.GET("/suggest", handlers.Suggest)
+		api.GET("/charts", handlers.GetCharts)
+		api.GET("/me/history", middleware.AuthMiddleware(), handlers.GetListeningHistory)
+		api.GET("/trash", middleware.AuthMiddleware(), middleware.RequireRole("ADMIN"), handlers.GetTrash)
+
+		playlists := api.Group("/playlists", middleware.AuthMiddleware())
+		{
+			playlists.GET("", handlers.GetMyPlaylists)
+			playlists.POST("", handlers.CreatePlaylist)
+			playlists.GET("/:id", handlers.GetPlaylistByID)
+			playlists.PUT("/:id", handlers.UpdatePlaylist)
+			playlists.DELETE("/:id", handlers.DeletePlaylist)
+			playlists.POST("/:id/songs", handlers.AddPlaylistSong)
+			playlists.PUT("/:id/songs/order", handlers.ReorderPlaylistSongs)
+			playlists.DELETE("/:id/songs/:songId", handlers.RemovePlaylistSong)
+			playlists.POST("/:id/collaborators", handlers.AddPlaylistCollaborator)
+			playlists.DELETE("/:id/collaborators/:userId", handlers.RemovePlaylistCollaborator)
 		}
 
 		subscriptions := api.Group("/subscriptions", middleware.AuthMiddleware())
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"content-service/config"
+	"content-service/repository"
+	"content-service/streamurl"
+
+	"github.com/gin-gonic/gin"
+	"shared-utils/logging"
+)
+
+// SignedStreamMiddleware authenticates a request by the signed URL it was
+// made with instead of a JWT. The URL must be signed for the song in the
+// path, not expired, and its stream session must not have been ended by the
+// user opening too many others.
+func SignedStreamMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		grant, err := streamurl.Verify(config.StreamURLSecret, strings.TrimSpace(c.Param("id")), c.Request.URL.Query(), now)
+		if err != nil {
+			ctx := logging.NewSecurityEventContext(c)
+			Logger.LogAuthTokenEvent(ctx, "stream_url_invalid", err.Error())
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired stream URL"})
+			c.Abort()
+			return
+		}
+
+		active, err := repository.StreamSessionActive(grant.UserID, grant.Session, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stream session"})
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusConflict, gin.H{"error": "Stream session ended because too many streams were started"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", grant.UserID)
+		c.Set("streamExpiresAt", grant.ExpiresAt)
+
+		c.Next()
+	}
+}
@@ -8,4 +8,7 @@ type Album struct {
 	ReleaseDate string            `bson:"releaseDate" json:"releaseDate"`
 	Genres     []string           `bson:"genres" json:"genres"`
 	ArtistID   primitive.ObjectID `bson:"artistId" json:"artistId"`
+	Cover      *ImageSet          `bson:"cover,omitempty" json:"cover,omitempty"`
+	Search     *SearchFields      `bson:"search,omitempty" json:"-"`
+	TrashInfo  `bson:",inline"`
 }
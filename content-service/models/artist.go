@@ -6,6 +6,9 @@ type Artist struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name      string             `bson:"name" json:"name"`
 	Image     string             `bson:"image,omitempty" json:"image,omitempty"`
+	Images    *ImageSet          `bson:"images,omitempty" json:"images,omitempty"`
 	Biography string             `bson:"biography" json:"biography"`
 	Genres    []string           `bson:"genres" json:"genres"`
+	Search    *SearchFields      `bson:"search,omitempty" json:"-"`
+	TrashInfo `bson:",inline"`
 }
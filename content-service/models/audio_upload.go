@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AudioUpload is a resumable upload of a song's audio file. Every chunk
+// received so far is a blob in storage, listed in Parts in order; Offset is
+// how many of the Size bytes have arrived. Checksum is the SHA-256 the client declared for
+// the whole file, verified when the upload is completed.
+type AudioUpload struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SongID    primitive.ObjectID `bson:"songId" json:"songId"`
+	CreatedBy string             `bson:"createdBy" json:"createdBy"`
+	Filename  string             `bson:"filename" json:"filename"`
+	Size      int64              `bson:"size" json:"size"`
+	Checksum  string             `bson:"checksum" json:"checksum"`
+	Offset    int64              `bson:"offset" json:"offset"`
+	Parts     []UploadPart       `bson:"parts" json:"-"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
+
+// UploadPart is one stored chunk of an AudioUpload.
+type UploadPart struct {
+	Key  string `bson:"key"`
+	Size int64  `bson:"size"`
+}
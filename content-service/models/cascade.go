@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// CascadeReport lists what a cascading delete moved to the trash, or would
+// move when DryRun is set. Ratings, Subscriptions and Playlists are document
+// counts of what the purge removes once the retention has passed; Playlists
+// counts the playlists the songs will be taken out of.
+type CascadeReport struct {
+	DryRun        bool       `json:"dryRun"`
+	DeletionID    string     `json:"deletionId,omitempty"`
+	DeletedAt     *time.Time `json:"deletedAt,omitempty"`
+	PurgeAt       *time.Time `json:"purgeAt,omitempty"`
+	ArtistID      string     `json:"artistId,omitempty"`
+	AlbumIDs      []string   `json:"albumIds"`
+	SongIDs       []string   `json:"songIds"`
+	AudioFiles    []string   `json:"audioFiles"`
+	Ratings       int64      `json:"ratings"`
+	Subscriptions int64      `json:"subscriptions"`
+	Playlists     int64      `json:"playlists"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ChartTypeSong   = "song"
+	ChartTypeArtist = "artist"
+	ChartTypeAlbum  = "album"
+)
+
+// ChartEntry is one materialised row of a chart. Entries of a single refresh
+// share RunID; ChartRun points at the run that is currently served.
+type ChartEntry struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"-"`
+	RunID         string              `bson:"runId" json:"-"`
+	Window        string              `bson:"window" json:"-"`
+	Type          string              `bson:"type" json:"-"`
+	ItemID        primitive.ObjectID  `bson:"itemId" json:"id"`
+	Name          string              `bson:"name" json:"name"`
+	ArtistID      *primitive.ObjectID `bson:"artistId,omitempty" json:"artistId,omitempty"`
+	AlbumID       *primitive.ObjectID `bson:"albumId,omitempty" json:"albumId,omitempty"`
+	Genres        []string            `bson:"genres" json:"genres"`
+	RatingCount   int                 `bson:"ratingCount" json:"ratingCount"`
+	AverageRating float64             `bson:"averageRating" json:"averageRating"`
+}
+
+type ChartRun struct {
+	Window     string    `bson:"_id"`
+	RunID      string    `bson:"runId"`
+	ComputedAt time.Time `bson:"computedAt"`
+}
+
+type ChartsResponse struct {
+	Window      string       `json:"window"`
+	Genre       string       `json:"genre,omitempty"`
+	Sort        string       `json:"sort"`
+	GeneratedAt *time.Time   `json:"generatedAt"`
+	Songs       []ChartEntry `json:"songs"`
+	Artists     []ChartEntry `json:"artists"`
+	Albums      []ChartEntry `json:"albums"`
+}
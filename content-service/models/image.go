@@ -0,0 +1,12 @@
+package models
+
+// ImageSet is an uploaded artist image or album cover. Key names the stored
+// files; Original and Thumbnails are the URLs they are served from, and
+// change whenever a new image is uploaded.
+type ImageSet struct {
+	Key        string            `bson:"key" json:"-"`
+	Width      int               `bson:"width" json:"width"`
+	Height     int               `bson:"height" json:"height"`
+	Original   string            `bson:"original" json:"original"`
+	Thumbnails map[string]string `bson:"thumbnails" json:"thumbnails"`
+}
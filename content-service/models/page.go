@@ -0,0 +1,9 @@
+package models
+
+// Page is the envelope every catalog listing responds with. NextCursor is
+// empty on the last page and Total is only set when it was requested.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
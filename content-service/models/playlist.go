@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PlaylistVisibilityPublic  = "public"
+	PlaylistVisibilityPrivate = "private"
+)
+
+// Playlist keeps its songs in SongIDs in play order. Collaborators may add,
+// remove and reorder songs; everything else is reserved for the owner.
+type Playlist struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	OwnerID       primitive.ObjectID   `bson:"ownerId" json:"ownerId"`
+	Name          string               `bson:"name" json:"name"`
+	Description   string               `bson:"description" json:"description"`
+	Visibility    string               `bson:"visibility" json:"visibility"`
+	Collaborators []primitive.ObjectID `bson:"collaborators" json:"collaborators"`
+	SongIDs       []primitive.ObjectID `bson:"songIds" json:"songIds"`
+	CreatedAt     time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time            `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (p *Playlist) IsOwner(userID primitive.ObjectID) bool {
+	return p.OwnerID == userID
+}
+
+func (p *Playlist) CanEdit(userID primitive.ObjectID) bool {
+	if p.IsOwner(userID) {
+		return true
+	}
+	for _, id := range p.Collaborators {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Playlist) CanView(userID primitive.ObjectID) bool {
+	return p.Visibility == PlaylistVisibilityPublic || p.CanEdit(userID)
+}
+
+type PlaylistDetail struct {
+	Playlist
+	Songs []Song `json:"songs"`
+}
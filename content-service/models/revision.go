@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RevisionEntityArtist = "artist"
+	RevisionEntityAlbum  = "album"
+	RevisionEntitySong   = "song"
+
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRestore = "restore"
+	RevisionActionRevert  = "revert"
+)
+
+// Revision is one numbered change to an artist, album or song. State holds
+// the editable fields as they were after the change, which is what a revert
+// goes back to; deletions leave the fields alone and carry no State.
+type Revision struct {
+	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	EntityType   string                 `bson:"entityType" json:"entityType"`
+	EntityID     primitive.ObjectID     `bson:"entityId" json:"entityId"`
+	Version      int                    `bson:"version" json:"version"`
+	Action       string                 `bson:"action" json:"action"`
+	ChangedBy    string                 `bson:"changedBy" json:"changedBy"`
+	ChangedAt    time.Time              `bson:"changedAt" json:"changedAt"`
+	Changes      []FieldChange          `bson:"changes" json:"changes"`
+	State        map[string]interface{} `bson:"state,omitempty" json:"state,omitempty"`
+	RevertedFrom int                    `bson:"revertedFrom,omitempty" json:"revertedFrom,omitempty"`
+}
+
+// FieldChange is one field of a revision's before/after diff; Before is nil
+// for a field that did not exist yet.
+type FieldChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// SearchFields holds the folded text the catalog text index is built on.
+// Primary is weighted above Secondary when results are ranked.
+type SearchFields struct {
+	Primary   string `bson:"primary"`
+	Secondary string `bson:"secondary,omitempty"`
+}
+
+// Span is a half-open range of rune offsets into Highlight.Text.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type Highlight struct {
+	Field   string `json:"field"`
+	Text    string `json:"text"`
+	Matches []Span `json:"matches"`
+}
+
+type ArtistSearchHit struct {
+	Artist     `bson:",inline"`
+	Score      float64     `bson:"score" json:"score"`
+	Highlights []Highlight `bson:"-" json:"highlights"`
+}
+
+type AlbumSearchHit struct {
+	Album      `bson:",inline"`
+	Score      float64     `bson:"score" json:"score"`
+	Highlights []Highlight `bson:"-" json:"highlights"`
+}
+
+type SongSearchHit struct {
+	Song       `bson:",inline"`
+	Score      float64     `bson:"score" json:"score"`
+	Highlights []Highlight `bson:"-" json:"highlights"`
+}
+
+type SearchResults struct {
+	Query   string            `json:"query"`
+	Artists []ArtistSearchHit `json:"artists"`
+	Albums  []AlbumSearchHit  `json:"albums"`
+	Songs   []SongSearchHit   `json:"songs"`
+}
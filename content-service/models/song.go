@@ -1,6 +1,10 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Song struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -9,4 +13,56 @@ type Song struct {
 	TrackNo   int                `bson:"trackNo" json:"trackNo"`
 	AlbumID   primitive.ObjectID `bson:"albumId" json:"albumId"`
 	AudioFile string             `bson:"audioFile,omitempty" json:"audioFile,omitempty"`
+	Audio     *AudioInfo         `bson:"audio,omitempty" json:"audio,omitempty"`
+	Checksum  string             `bson:"checksum,omitempty" json:"checksum,omitempty"`
+	Stream    *StreamInfo        `bson:"stream,omitempty" json:"stream,omitempty"`
+	Analysis  *AudioAnalysis     `bson:"analysis,omitempty" json:"analysis,omitempty"`
+	PlayCount int                `bson:"playCount,omitempty" json:"playCount"`
+	Search    *SearchFields      `bson:"search,omitempty" json:"-"`
+	TrashInfo `bson:",inline"`
+}
+
+// AudioInfo is read from the uploaded audio file itself. Bitrate is in bits
+// per second, averaged for variable bitrate files.
+type AudioInfo struct {
+	Format     string `bson:"format" json:"format"`
+	DurationMs int64  `bson:"durationMs" json:"durationMs"`
+	Bitrate    int    `bson:"bitrate" json:"bitrate"`
+	SampleRate int    `bson:"sampleRate" json:"sampleRate"`
+	Channels   int    `bson:"channels" json:"channels"`
+}
+
+const (
+	StreamStatusPending = "pending"
+	StreamStatusReady   = "ready"
+	StreamStatusFailed  = "failed"
+)
+
+// StreamInfo tracks the HLS transcode of the song's audio. Renditions are
+// the encoded bitrates in kbit/s, set once the stream is ready.
+type StreamInfo struct {
+	Status     string    `bson:"status" json:"status"`
+	Renditions []int     `bson:"renditions,omitempty" json:"renditions,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	UpdatedAt  time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+const (
+	AnalysisStatusPending = "pending"
+	AnalysisStatusReady   = "ready"
+	AnalysisStatusFailed  = "failed"
+)
+
+// AudioAnalysis is measured from the decoded audio. Loudness is the
+// integrated loudness in LUFS, ReplayGain the gain in dB that brings it to
+// -18 LUFS and Peak the highest sample, 1.0 being full scale. The waveform
+// holds peaks scaled to 0-255 and is only served by the waveform endpoint.
+type AudioAnalysis struct {
+	Status     string    `bson:"status" json:"status"`
+	Loudness   float64   `bson:"loudness,omitempty" json:"loudness,omitempty"`
+	ReplayGain float64   `bson:"replayGain,omitempty" json:"replayGain,omitempty"`
+	Peak       float64   `bson:"peak,omitempty" json:"peak,omitempty"`
+	Waveform   []byte    `bson:"waveform,omitempty" json:"-"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	UpdatedAt  time.Time `bson:"updatedAt" json:"updatedAt"`
 }
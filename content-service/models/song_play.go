@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PlaySourceStream = "stream"
+	PlaySourceClient = "client"
+)
+
+type SongPlay struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID   primitive.ObjectID `bson:"userId" json:"userId"`
+	SongID   primitive.ObjectID `bson:"songId" json:"songId"`
+	PlayedAt time.Time          `bson:"playedAt" json:"playedAt"`
+	Source   string             `bson:"source" json:"source"`
+}
+
+type ListeningHistoryItem struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	SongID    primitive.ObjectID `bson:"songId" json:"songId"`
+	SongTitle string             `bson:"songTitle" json:"songTitle"`
+	AlbumID   primitive.ObjectID `bson:"albumId" json:"albumId"`
+	PlayedAt  time.Time          `bson:"playedAt" json:"playedAt"`
+	Source    string             `bson:"source" json:"source"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TrashInfo is set on artists, albums and songs that were deleted but not
+// yet purged. Everything deleted in one request shares a DeletionID, so a
+// restore brings back exactly what that request removed.
+type TrashInfo struct {
+	DeletedAt  *time.Time          `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	DeletedBy  string              `bson:"deletedBy,omitempty" json:"deletedBy,omitempty"`
+	DeletionID *primitive.ObjectID `bson:"deletionId,omitempty" json:"deletionId,omitempty"`
+}
@@ -7,48 +7,24 @@ import (
 
 	"content-service/db"
 	"content-service/models"
+	"content-service/search"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func GetAlbumsByArtistID(artistID string) ([]models.Album, error) {
-	return SearchAlbumsByArtistID(artistID, "")
-}
-
-func SearchAlbumsByArtistID(artistID string, searchQuery string) ([]models.Album, error) {
+func ListAlbumsByArtistID(artistID string, searchQuery string, opts ListOptions) (models.Page[models.Album], error) {
 	objID, err := primitive.ObjectIDFromHex(artistID)
 	if err != nil {
-		return nil, err
+		return models.Page[models.Album]{}, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	filter := bson.M{"artistId": objID}
+	filter := live(bson.M{"artistId": objID})
 	if searchQuery != "" {
-		filter["title"] = bson.M{"$regex": searchQuery, "$options": "i"}
-	}
-
-	cursor, err := db.AlbumsCollection.Find(
-		ctx,
-		filter,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var albums []models.Album
-	if err := cursor.All(ctx, &albums); err != nil {
-		return nil, err
+		filter["title"] = containsFilter(searchQuery)
 	}
 
-	if albums == nil {
-		albums = []models.Album{}
-	}
-
-	return albums, nil
+	return findPage[models.Album](db.AlbumsCollection, filter, opts)
 }
 
 func GetAlbumByID(albumID string) (*models.Album, error) {
@@ -60,7 +36,7 @@ func GetAlbumByID(albumID string) (*models.Album, error) {
 	var album models.Album
 	err = db.AlbumsCollection.FindOne(
 		context.Background(),
-		bson.M{"_id": objID},
+		live(bson.M{"_id": objID}),
 	).Decode(&album)
 	if err != nil {
 		return nil, err
@@ -73,7 +49,7 @@ func ArtistExistsByID(id primitive.ObjectID) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	count, err := db.ArtistsCollection.CountDocuments(ctx, bson.M{"_id": id})
+	count, err := db.ArtistsCollection.CountDocuments(ctx, live(bson.M{"_id": id}))
 	if err != nil {
 		return false, err
 	}
@@ -87,6 +63,7 @@ func CreateAlbum(album models.Album) (models.Album, error) {
 	if album.ID.IsZero() {
 		album.ID = primitive.NewObjectID()
 	}
+	album.Search = search.TitleFields(album.Title)
 
 	_, err := db.AlbumsCollection.InsertOne(ctx, album)
 	return album, err
@@ -107,12 +84,13 @@ func UpdateAlbum(id string, album models.Album) error {
 			"releaseDate": album.ReleaseDate,
 			"genres":      album.Genres,
 			"artistId":    album.ArtistID,
+			"search":      search.TitleFields(album.Title),
 		},
 	}
 
 	result, err := db.AlbumsCollection.UpdateOne(
 		ctx,
-		bson.M{"_id": objID},
+		live(bson.M{"_id": objID}),
 		update,
 	)
 	if err != nil {
@@ -126,35 +104,11 @@ func UpdateAlbum(id string, album models.Album) error {
 	return nil
 }
 
-func DeleteAlbum(id string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return err
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	result, err := db.AlbumsCollection.DeleteOne(
-		ctx,
-		bson.M{"_id": objID},
-	)
-	if err != nil {
-		return err
-	}
-
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("album not found")
-	}
-
-	return nil
-}
-
 func CountSongsByAlbumID(albumID primitive.ObjectID) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	count, err := db.SongsCollection.CountDocuments(ctx, bson.M{"albumId": albumID})
+	count, err := db.SongsCollection.CountDocuments(ctx, live(bson.M{"albumId": albumID}))
 	if err != nil {
 		return 0, err
 	}
@@ -8,25 +8,11 @@ import (
 
 	"content-service/db"
 	"content-service/models"
+	"content-service/search"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func GetAllArtists() ([]models.Artist, error) {
-	cursor, err := db.ArtistsCollection.Find(context.Background(), bson.M{})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(context.Background())
-
-	var artists []models.Artist
-	if err := cursor.All(context.Background(), &artists); err != nil {
-		return nil, err
-	}
-
-	return artists, nil
-}
-
 func GetArtistByID(id string) (*models.Artist, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -36,7 +22,7 @@ func GetArtistByID(id string) (*models.Artist, error) {
 	var artist models.Artist
 	err = db.ArtistsCollection.FindOne(
 		context.Background(),
-		bson.M{"_id": objID},
+		live(bson.M{"_id": objID}),
 	).Decode(&artist)
 
 	if err != nil {
@@ -46,6 +32,7 @@ func GetArtistByID(id string) (*models.Artist, error) {
 	return &artist, nil
 }
 func CreateArtist(artist models.Artist) error {
+	artist.Search = search.ArtistFields(artist.Name, artist.Biography)
 	_, err := db.ArtistsCollection.InsertOne(context.Background(), artist)
 	return err
 }
@@ -64,12 +51,13 @@ func UpdateArtist(id string, artist models.Artist) error {
 			"name":      artist.Name,
 			"biography": artist.Biography,
 			"genres":    artist.Genres,
+			"search":    search.ArtistFields(artist.Name, artist.Biography),
 		},
 	}
 
 	result, err := db.ArtistsCollection.UpdateOne(
 		ctx,
-		bson.M{"_id": objID},
+		live(bson.M{"_id": objID}),
 		update,
 	)
 	if err != nil {
@@ -83,67 +71,42 @@ func UpdateArtist(id string, artist models.Artist) error {
 	return nil
 }
 
-func DeleteArtist(id string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return err
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	result, err := db.ArtistsCollection.DeleteOne(
-		ctx,
-		bson.M{"_id": objID},
-	)
-	if err != nil {
-		return err
-	}
-
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("artist not found")
-	}
-
-	return nil
-}
-
 func CountAlbumsByArtistID(artistID primitive.ObjectID) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	count, err := db.AlbumsCollection.CountDocuments(ctx, bson.M{"artistId": artistID})
+	count, err := db.AlbumsCollection.CountDocuments(ctx, live(bson.M{"artistId": artistID}))
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func SearchAndFilterArtists(searchQuery string, genres []string) ([]models.Artist, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	filter := bson.M{}
+func ListArtists(searchQuery string, genres []string, opts ListOptions) (models.Page[models.Artist], error) {
+	filter := live(bson.M{})
 
 	if searchQuery != "" {
-		filter["name"] = bson.M{"$regex": searchQuery, "$options": "i"}
+		filter["name"] = containsFilter(searchQuery)
 	}
 
 	if len(genres) > 0 {
 		filter["genres"] = bson.M{"$in": genres}
 	}
 
-	cursor, err := db.ArtistsCollection.Find(ctx, filter)
+	return findPage[models.Artist](db.ArtistsCollection, filter, opts)
+}
+
+// ListGenres pages the genres used by artists and albums. Genres live only
+// as strings on those documents, so the page is cut in memory.
+func ListGenres(opts ListOptions) (models.Page[string], error) {
+	genres, err := GetAllGenres()
 	if err != nil {
-		return nil, err
+		return models.Page[string]{}, err
 	}
-	defer cursor.Close(ctx)
-
-	var artists []models.Artist
-	if err := cursor.All(ctx, &artists); err != nil {
-		return nil, err
+	if opts.Descending {
+		sort.Sort(sort.Reverse(sort.StringSlice(genres)))
 	}
-
-	return artists, nil
+	return pageStrings(genres, opts)
 }
 
 func GetAllGenres() ([]string, error) {
@@ -153,6 +116,7 @@ func GetAllGenres() ([]string, error) {
 	genreSet := make(map[string]bool)
 
 	pipeline := []bson.M{
+		{"$match": live(bson.M{})},
 		{"$unwind": "$genres"},
 		{"$group": bson.M{"_id": "$genres"}},
 	}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"content-service/db"
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func EnsureAudioUploadIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.AudioUploadsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "expiresAt", Value: 1}},
+	})
+	return err
+}
+
+func CreateAudioUpload(upload *models.AudioUpload) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if upload.ID.IsZero() {
+		upload.ID = primitive.NewObjectID()
+	}
+	_, err := db.AudioUploadsCollection.InsertOne(ctx, upload)
+	return err
+}
+
+// GetAudioUpload returns mongo.ErrNoDocuments for unknown uploads.
+func GetAudioUpload(id primitive.ObjectID) (*models.AudioUpload, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var upload models.AudioUpload
+	if err := db.AudioUploadsCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// AdvanceAudioUpload appends part to the upload, moving its offset from from
+// past the part, and pushes its expiry out. It reports false when the offset
+// was no longer from, which is how two replicas receiving the same chunk
+// agree on one of them.
+func AdvanceAudioUpload(id primitive.ObjectID, from int64, part models.UploadPart, expiresAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.AudioUploadsCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "offset": from},
+		bson.M{
+			"$set":  bson.M{"offset": from + part.Size, "expiresAt": expiresAt},
+			"$push": bson.M{"parts": part},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func DeleteAudioUpload(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := db.AudioUploadsCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// ListExpiredAudioUploads returns uploads that have not received data since
+// before their expiry.
+func ListExpiredAudioUploads(now time.Time) ([]models.AudioUpload, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := db.AudioUploadsCollection.Find(ctx, bson.M{"expiresAt": bson.M{"$lte": now}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var uploads []models.AudioUpload
+	if err := cursor.All(ctx, &uploads); err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
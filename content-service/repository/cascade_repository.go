@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"content-service/db"
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CascadePlan is everything a cascading delete removes. ArtistID is zero
+// when only an album (and its songs) is deleted. ImageKeys are the artist
+// image and album covers whose files go with them.
+type CascadePlan struct {
+	ArtistID   primitive.ObjectID
+	AlbumIDs   []primitive.ObjectID
+	SongIDs    []primitive.ObjectID
+	AudioFiles []string
+	ImageKeys  []string
+}
+
+// PlanArtistCascade collects the artist's live albums and their songs. It
+// returns mongo.ErrNoDocuments when the artist does not exist or is already
+// in the trash.
+func PlanArtistCascade(artistID primitive.ObjectID) (*CascadePlan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return planArtist(ctx, artistID, live(bson.M{}))
+}
+
+// planArtist builds an artist plan from the documents matching state, which
+// is live(...) for a delete and empty for a purge.
+func planArtist(ctx context.Context, artistID primitive.ObjectID, state bson.M) (*CascadePlan, error) {
+	var artist struct {
+		Images *models.ImageSet `bson:"images"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"images.key": 1})
+	if err := db.ArtistsCollection.FindOne(ctx, withState(bson.M{"_id": artistID}, state), opts).Decode(&artist); err != nil {
+		return nil, err
+	}
+
+	plan := &CascadePlan{ArtistID: artistID}
+	if artist.Images != nil {
+		plan.ImageKeys = append(plan.ImageKeys, artist.Images.Key)
+	}
+	if err := plan.addAlbums(ctx, withState(bson.M{"artistId": artistID}, state)); err != nil {
+		return nil, err
+	}
+	if err := plan.addSongs(ctx, withState(bson.M{"albumId": bson.M{"$in": plan.AlbumIDs}}, state)); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PlanAlbumCascade collects the album's live songs. It returns
+// mongo.ErrNoDocuments when the album does not exist or is in the trash.
+func PlanAlbumCascade(albumID primitive.ObjectID) (*CascadePlan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	plan := &CascadePlan{}
+	if err := plan.addAlbums(ctx, live(bson.M{"_id": albumID})); err != nil {
+		return nil, err
+	}
+	if len(plan.AlbumIDs) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	if err := plan.addSongs(ctx, live(bson.M{"albumId": albumID})); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PlanSongCascade covers a single live song, so purging it also cleans up
+// its ratings, playlist entries and audio file.
+func PlanSongCascade(songID primitive.ObjectID) (*CascadePlan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	plan := &CascadePlan{}
+	if err := plan.addSongs(ctx, live(bson.M{"_id": songID})); err != nil {
+		return nil, err
+	}
+	if len(plan.SongIDs) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return plan, nil
+}
+
+func withState(filter, state bson.M) bson.M {
+	for k, v := range state {
+		filter[k] = v
+	}
+	return filter
+}
+
+func (p *CascadePlan) addAlbums(ctx context.Context, filter bson.M) error {
+	opts := options.Find().SetProjection(bson.M{"cover.key": 1})
+	cursor, err := db.AlbumsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var albums []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Cover *models.ImageSet   `bson:"cover"`
+	}
+	if err := cursor.All(ctx, &albums); err != nil {
+		return err
+	}
+	for _, a := range albums {
+		p.AlbumIDs = append(p.AlbumIDs, a.ID)
+		if a.Cover != nil {
+			p.ImageKeys = append(p.ImageKeys, a.Cover.Key)
+		}
+	}
+	return nil
+}
+
+func (p *CascadePlan) addSongs(ctx context.Context, filter bson.M) error {
+	opts := options.Find().SetProjection(bson.M{"audioFile": 1})
+	cursor, err := db.SongsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var songs []struct {
+		ID        primitive.ObjectID `bson:"_id"`
+		AudioFile string             `bson:"audioFile"`
+	}
+	if err := cursor.All(ctx, &songs); err != nil {
+		return err
+	}
+	for _, s := range songs {
+		p.SongIDs = append(p.SongIDs, s.ID)
+		if s.AudioFile != "" {
+			p.AudioFiles = append(p.AudioFiles, s.AudioFile)
+		}
+	}
+	return nil
+}
+
+// CountCascade fills in how many ratings, subscriptions and playlists the
+// plan touches, without changing anything.
+func CountCascade(plan *CascadePlan) (models.CascadeReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	report := plan.report()
+	var err error
+	if report.Ratings, err = db.UserRatingsCollection.CountDocuments(ctx, plan.songFilter("songId")); err != nil {
+		return report, err
+	}
+	if report.Playlists, err = db.PlaylistsCollection.CountDocuments(ctx, plan.songFilter("songIds")); err != nil {
+		return report, err
+	}
+	if !plan.ArtistID.IsZero() {
+		if report.Subscriptions, err = db.ArtistSubscriptionsCollection.CountDocuments(ctx, bson.M{"artistId": plan.ArtistID}); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// ExecuteCascade permanently deletes the plan, leaves first: ratings and
+// playlist entries, songs, albums, subscriptions and finally the artist.
+// MongoDB runs here without transactions, so a failed cascade stops where it
+// is and can simply be run again. Audio files are left to the caller.
+func ExecuteCascade(plan *CascadePlan) (models.CascadeReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	report := plan.report()
+	if len(plan.SongIDs) > 0 {
+		res, err := db.UserRatingsCollection.DeleteMany(ctx, plan.songFilter("songId"))
+		if err != nil {
+			return report, err
+		}
+		report.Ratings = res.DeletedCount
+
+		upd, err := db.PlaylistsCollection.UpdateMany(ctx, plan.songFilter("songIds"), bson.M{
+			"$pull": bson.M{"songIds": bson.M{"$in": plan.SongIDs}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		})
+		if err != nil {
+			return report, err
+		}
+		report.Playlists = upd.ModifiedCount
+
+		if _, err := db.SongsCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": plan.SongIDs}}); err != nil {
+			return report, err
+		}
+	}
+
+	if len(plan.AlbumIDs) > 0 {
+		if _, err := db.AlbumsCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": plan.AlbumIDs}}); err != nil {
+			return report, err
+		}
+	}
+
+	if !plan.ArtistID.IsZero() {
+		res, err := db.ArtistSubscriptionsCollection.DeleteMany(ctx, bson.M{"artistId": plan.ArtistID})
+		if err != nil {
+			return report, err
+		}
+		report.Subscriptions = res.DeletedCount
+
+		if _, err := db.ArtistsCollection.DeleteOne(ctx, bson.M{"_id": plan.ArtistID}); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (p *CascadePlan) songFilter(field string) bson.M {
+	return bson.M{field: bson.M{"$in": p.SongIDs}}
+}
+
+func (p *CascadePlan) report() models.CascadeReport {
+	report := models.CascadeReport{
+		AlbumIDs:   hexIDs(p.AlbumIDs),
+		SongIDs:    hexIDs(p.SongIDs),
+		AudioFiles: append([]string{}, p.AudioFiles...),
+	}
+	if !p.ArtistID.IsZero() {
+		report.ArtistID = p.ArtistID.Hex()
+	}
+	return report
+}
+
+func distinctIDs(ctx context.Context, coll *mongo.Collection, filter bson.M) ([]primitive.ObjectID, error) {
+	cursor, err := coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.ID)
+	}
+	return ids, nil
+}
+
+func hexIDs(ids []primitive.ObjectID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.Hex())
+	}
+	return out
+}
+
+// CascadeDocuments are the documents of a plan as they were before a delete,
+// recorded as the before state of the delete revisions.
+type CascadeDocuments struct {
+	Artist *models.Artist
+	Albums map[primitive.ObjectID]models.Album
+	Songs  map[primitive.ObjectID]models.Song
+}
+
+// LoadCascadeDocuments reads the artist, albums and songs of the plan.
+func LoadCascadeDocuments(plan *CascadePlan) (*CascadeDocuments, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	docs := &CascadeDocuments{
+		Albums: make(map[primitive.ObjectID]models.Album, len(plan.AlbumIDs)),
+		Songs:  make(map[primitive.ObjectID]models.Song, len(plan.SongIDs)),
+	}
+
+	if !plan.ArtistID.IsZero() {
+		var artist models.Artist
+		if err := db.ArtistsCollection.FindOne(ctx, bson.M{"_id": plan.ArtistID}).Decode(&artist); err != nil {
+			return nil, err
+		}
+		docs.Artist = &artist
+	}
+
+	if len(plan.AlbumIDs) > 0 {
+		var albums []models.Album
+		if err := findAll(ctx, db.AlbumsCollection, bson.M{"_id": bson.M{"$in": plan.AlbumIDs}}, &albums); err != nil {
+			return nil, err
+		}
+		for _, album := range albums {
+			docs.Albums[album.ID] = album
+		}
+	}
+
+	if len(plan.SongIDs) > 0 {
+		var songs []models.Song
+		if err := findAll(ctx, db.SongsCollection, bson.M{"_id": bson.M{"$in": plan.SongIDs}}, &songs); err != nil {
+			return nil, err
+		}
+		for _, song := range songs {
+			docs.Songs[song.ID] = song
+		}
+	}
+
+	return docs, nil
+}
+
+func findAll(ctx context.Context, coll *mongo.Collection, filter bson.M, out interface{}) error {
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, out)
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"content-service/db"
+	"content-service/models"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ChartWindows are the rolling windows charts are materialised for.
+var ChartWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+const (
+	ChartSortCount   = "count"
+	ChartSortAverage = "average"
+
+	// minRatingsForAverage keeps items with a single 5 out of the top of
+	// charts sorted by average rating.
+	minRatingsForAverage = 3
+)
+
+type chartSongRow struct {
+	SongID       primitive.ObjectID `bson:"_id"`
+	Count        int                `bson:"count"`
+	Sum          int                `bson:"sum"`
+	Title        string             `bson:"title"`
+	AlbumID      primitive.ObjectID `bson:"albumId"`
+	AlbumTitle   string             `bson:"albumTitle"`
+	AlbumGenres  []string           `bson:"albumGenres"`
+	ArtistID     primitive.ObjectID `bson:"artistId"`
+	ArtistName   string             `bson:"artistName"`
+	ArtistGenres []string           `bson:"artistGenres"`
+}
+
+type chartAccumulator struct {
+	entry  models.ChartEntry
+	sum    int
+	genres map[string]struct{}
+}
+
+func EnsureChartIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ChartEntriesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "runId", Value: 1}, {Key: "type", Value: 1}, {Key: "ratingCount", Value: -1}}},
+		{Keys: bson.D{{Key: "runId", Value: 1}, {Key: "type", Value: 1}, {Key: "averageRating", Value: -1}}},
+		{Keys: bson.D{{Key: "window", Value: 1}, {Key: "runId", Value: 1}}},
+	}); err != nil {
+		return err
+	}
+
+	_, err := db.UserRatingsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "updatedAt", Value: 1}},
+		Options: options.Index().SetName("rating_activity_lookup"),
+	})
+	return err
+}
+
+// RefreshChart recomputes the chart of one window from the ratings created or
+// updated inside it and swaps it in once all entries are written.
+func RefreshChart(window string, span time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	cursor, err := db.UserRatingsCollection.Aggregate(ctx, chartPipeline(now.Add(-span)))
+	if err != nil {
+		return err
+	}
+	var rows []chartSongRow
+	if err := cursor.All(ctx, &rows); err != nil {
+		return err
+	}
+
+	runID := fmt.Sprintf("%s-%d", window, now.UnixNano())
+	var docs []interface{}
+	for _, entry := range chartEntries(rows, runID, window) {
+		docs = append(docs, entry)
+	}
+
+	if len(docs) > 0 {
+		if _, err := db.ChartEntriesCollection.InsertMany(ctx, docs); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.ChartRunsCollection.UpdateOne(ctx,
+		bson.M{"_id": window},
+		bson.M{"$set": bson.M{"runId": runID, "computedAt": now}},
+		options.Update().SetUpsert(true),
+	); err != nil {
+		return err
+	}
+
+	_, err = db.ChartEntriesCollection.DeleteMany(ctx, bson.M{"window": window, "runId": bson.M{"$ne": runID}})
+	return err
+}
+
+// chartPipeline groups the ratings created or updated since the start of the
+// window by song and joins the song's album and artist.
+func chartPipeline(since time.Time) []bson.M {
+	return []bson.M{
+		{"$match": bson.M{"updatedAt": bson.M{"$gte": since}}},
+		{"$group": bson.M{
+			"_id":   "$songId",
+			"count": bson.M{"$sum": 1},
+			"sum":   bson.M{"$sum": "$rating"},
+		}},
+		{"$lookup": bson.M{"from": "songs", "localField": "_id", "foreignField": "_id", "as": "song"}},
+		{"$unwind": "$song"},
+		{"$match": bson.M{"song.deletedAt": bson.M{"$exists": false}}},
+		{"$lookup": bson.M{"from": "albums", "localField": "song.albumId", "foreignField": "_id", "as": "album"}},
+		{"$unwind": bson.M{"path": "$album", "preserveNullAndEmptyArrays": true}},
+		{"$lookup": bson.M{"from": "artists", "localField": "album.artistId", "foreignField": "_id", "as": "artist"}},
+		{"$unwind": bson.M{"path": "$artist", "preserveNullAndEmptyArrays": true}},
+		{"$project": bson.M{
+			"count":        1,
+			"sum":          1,
+			"title":        "$song.title",
+			"albumId":      "$song.albumId",
+			"albumTitle":   "$album.title",
+			"albumGenres":  "$album.genres",
+			"artistId":     "$album.artistId",
+			"artistName":   "$artist.name",
+			"artistGenres": "$artist.genres",
+		}},
+	}
+}
+
+// chartEntries sums the per song rows into song, album and artist entries.
+func chartEntries(rows []chartSongRow, runID, window string) []models.ChartEntry {
+	songs := map[primitive.ObjectID]*chartAccumulator{}
+	albums := map[primitive.ObjectID]*chartAccumulator{}
+	artists := map[primitive.ObjectID]*chartAccumulator{}
+
+	for _, row := range rows {
+		genres := append(append([]string{}, row.AlbumGenres...), row.ArtistGenres...)
+
+		song := models.ChartEntry{Type: models.ChartTypeSong, Name: row.Title}
+		if !row.AlbumID.IsZero() {
+			song.AlbumID = &row.AlbumID
+		}
+		if !row.ArtistID.IsZero() {
+			song.ArtistID = &row.ArtistID
+		}
+		accumulate(songs, row.SongID, song, row, genres)
+		if !row.AlbumID.IsZero() && row.AlbumTitle != "" {
+			accumulate(albums, row.AlbumID, models.ChartEntry{
+				Type:     models.ChartTypeAlbum,
+				Name:     row.AlbumTitle,
+				ArtistID: song.ArtistID,
+			}, row, genres)
+		}
+		if !row.ArtistID.IsZero() && row.ArtistName != "" {
+			accumulate(artists, row.ArtistID, models.ChartEntry{
+				Type: models.ChartTypeArtist,
+				Name: row.ArtistName,
+			}, row, genres)
+		}
+	}
+
+	var entries []models.ChartEntry
+	for _, group := range []map[primitive.ObjectID]*chartAccumulator{songs, albums, artists} {
+		for _, acc := range group {
+			entry := acc.entry
+			entry.RunID = runID
+			entry.Window = window
+			entry.AverageRating = math.Round(float64(acc.sum)/float64(entry.RatingCount)*100) / 100
+			entry.Genres = make([]string, 0, len(acc.genres))
+			for g := range acc.genres {
+				entry.Genres = append(entry.Genres, g)
+			}
+			sort.Strings(entry.Genres)
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func accumulate(group map[primitive.ObjectID]*chartAccumulator, id primitive.ObjectID, base models.ChartEntry, row chartSongRow, genres []string) {
+	acc, ok := group[id]
+	if !ok {
+		base.ItemID = id
+		acc = &chartAccumulator{entry: base, genres: map[string]struct{}{}}
+		group[id] = acc
+	}
+	acc.entry.RatingCount += row.Count
+	acc.sum += row.Sum
+	for _, g := range genres {
+		if g != "" {
+			acc.genres[g] = struct{}{}
+		}
+	}
+}
+
+// GetChartRun returns the run currently served for a window, or nil when the
+// chart has not been computed yet.
+func GetChartRun(window string) (*models.ChartRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var run models.ChartRun
+	err := db.ChartRunsCollection.FindOne(ctx, bson.M{"_id": window}).Decode(&run)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func GetChartEntries(runID, chartType, genre, sortBy string, limit int) ([]models.ChartEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter, sortKeys := chartQuery(runID, chartType, genre, sortBy)
+	opts := options.Find().SetSort(sortKeys).SetLimit(int64(limit))
+	cursor, err := db.ChartEntriesCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.ChartEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// chartQuery returns the filter and ranking of a chart. Sorting by average
+// only ranks items with at least minRatingsForAverage ratings.
+func chartQuery(runID, chartType, genre, sortBy string) (bson.M, bson.D) {
+	filter := bson.M{"runId": runID, "type": chartType}
+	if genre != "" {
+		filter["genres"] = genre
+	}
+
+	sortKeys := bson.D{{Key: "ratingCount", Value: -1}, {Key: "averageRating", Value: -1}, {Key: "name", Value: 1}}
+	if sortBy == ChartSortAverage {
+		filter["ratingCount"] = bson.M{"$gte": minRatingsForAverage}
+		sortKeys = bson.D{{Key: "averageRating", Value: -1}, {Key: "ratingCount", Value: -1}, {Key: "name", Value: 1}}
+	}
+	return filter, sortKeys
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestChartPipelineMatchesWindow(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := chartPipeline(since)[0]
+
+	want := bson.M{"$match": bson.M{"updatedAt": bson.M{"$gte": since}}}
+	if !reflect.DeepEqual(first, want) {
+		t.Fatalf("first stage = %v, want %v", first, want)
+	}
+}
+
+func TestChartQuery(t *testing.T) {
+	filter, sortKeys := chartQuery("run", models.ChartTypeSong, "Jazz", ChartSortCount)
+	if want := (bson.M{"runId": "run", "type": models.ChartTypeSong, "genres": "Jazz"}); !reflect.DeepEqual(filter, want) {
+		t.Errorf("count filter = %v, want %v", filter, want)
+	}
+	if want := (bson.D{{Key: "ratingCount", Value: -1}, {Key: "averageRating", Value: -1}, {Key: "name", Value: 1}}); !reflect.DeepEqual(sortKeys, want) {
+		t.Errorf("count sort = %v, want %v", sortKeys, want)
+	}
+
+	filter, sortKeys = chartQuery("run", models.ChartTypeArtist, "", ChartSortAverage)
+	want := bson.M{"runId": "run", "type": models.ChartTypeArtist, "ratingCount": bson.M{"$gte": minRatingsForAverage}}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("average filter = %v, want %v", filter, want)
+	}
+	if want := (bson.D{{Key: "averageRating", Value: -1}, {Key: "ratingCount", Value: -1}, {Key: "name", Value: 1}}); !reflect.DeepEqual(sortKeys, want) {
+		t.Errorf("average sort = %v, want %v", sortKeys, want)
+	}
+}
+
+func TestChartEntries(t *testing.T) {
+	artistA, artistB := primitive.NewObjectID(), primitive.NewObjectID()
+	albumA, albumB := primitive.NewObjectID(), primitive.NewObjectID()
+	rows := []chartSongRow{
+		{SongID: primitive.NewObjectID(), Count: 6, Sum: 18, Title: "Often", AlbumID: albumA, AlbumTitle: "First", ArtistID: artistA, ArtistName: "Ana"},
+		{SongID: primitive.NewObjectID(), Count: 3, Sum: 15, Title: "Loved", AlbumID: albumB, AlbumTitle: "Second", ArtistID: artistB, ArtistName: "Bojan"},
+		{SongID: primitive.NewObjectID(), Count: 1, Sum: 5, Title: "Once", AlbumID: albumB, AlbumTitle: "Second", ArtistID: artistB, ArtistName: "Bojan"},
+		{SongID: primitive.NewObjectID(), Count: 2, Sum: 6, Title: "Orphan"},
+	}
+
+	byName := map[string]models.ChartEntry{}
+	for _, e := range chartEntries(rows, "run", "7d") {
+		if e.RunID != "run" || e.Window != "7d" {
+			t.Errorf("%s has run %q, window %q", e.Name, e.RunID, e.Window)
+		}
+		byName[e.Type+" "+e.Name] = e
+	}
+
+	if e := byName[models.ChartTypeSong+" Loved"]; e.RatingCount != 3 || e.AverageRating != 5 || e.ArtistID == nil || *e.ArtistID != artistB {
+		t.Errorf("Loved = %+v", e)
+	}
+	if e := byName[models.ChartTypeSong+" Orphan"]; e.ArtistID != nil || e.AlbumID != nil {
+		t.Errorf("song without album has artistId %v, albumId %v", e.ArtistID, e.AlbumID)
+	}
+	if e := byName[models.ChartTypeArtist+" Bojan"]; e.RatingCount != 4 || e.AverageRating != 5 {
+		t.Errorf("Bojan = %d ratings averaging %v, want 4 averaging 5", e.RatingCount, e.AverageRating)
+	}
+	if e := byName[models.ChartTypeAlbum+" Second"]; e.RatingCount != 4 || e.ItemID != albumB || e.ArtistID == nil || *e.ArtistID != artistB {
+		t.Errorf("Second = %+v", e)
+	}
+}
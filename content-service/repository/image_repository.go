@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"content-service/db"
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SetArtistImage stores the artist's new image and returns the key of the
+// one it replaced, if any, so its files can be removed. Image keeps the
+// medium thumbnail for clients that only show one picture.
+func SetArtistImage(artistID primitive.ObjectID, images *models.ImageSet) (string, error) {
+	return replaceImageSet(db.ArtistsCollection, artistID, "images", bson.M{
+		"images": images,
+		"image":  images.Thumbnails["medium"],
+	})
+}
+
+// SetAlbumCover stores the album's new cover and returns the key of the one
+// it replaced, if any.
+func SetAlbumCover(albumID primitive.ObjectID, cover *models.ImageSet) (string, error) {
+	return replaceImageSet(db.AlbumsCollection, albumID, "cover", bson.M{"cover": cover})
+}
+
+// replaceImageSet returns mongo.ErrNoDocuments when the document does not
+// exist or is in the trash.
+func replaceImageSet(coll *mongo.Collection, id primitive.ObjectID, field string, set bson.M) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetProjection(bson.M{field + ".key": 1})
+
+	var previous bson.Raw
+	err := coll.FindOneAndUpdate(ctx, live(bson.M{"_id": id}), bson.M{"$set": set}, opts).Decode(&previous)
+	if err != nil {
+		return "", err
+	}
+	key, _ := previous.Lookup(field, "key").StringValueOK()
+	return key, nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListOptions describes one page of a catalog listing. Sort is the bson
+// field to order by; _id always breaks ties so the order is total.
+type ListOptions struct {
+	Limit        int
+	Sort         string
+	Descending   bool
+	After        *PageCursor
+	IncludeTotal bool
+}
+
+// PageCursor marks the last item of a page. It records the sort it was made
+// for so it cannot be replayed against a different ordering. Kind is set for
+// values JSON cannot carry as-is; dates travel as milliseconds, and a
+// document without the sort field gets the null kind.
+type PageCursor struct {
+	Sort       string      `json:"s"`
+	Descending bool        `json:"d,omitempty"`
+	Value      interface{} `json:"v,omitempty"`
+	Kind       string      `json:"k,omitempty"`
+	ID         string      `json:"id"`
+}
+
+const (
+	cursorKindDate = "date"
+	cursorKindNull = "null"
+)
+
+func EncodeCursor(c PageCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(value string) (*PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c PageCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort == "" || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// keysetFilter narrows filter to the documents after opts.After.
+func keysetFilter(filter bson.M, opts ListOptions) (bson.M, error) {
+	if opts.After == nil {
+		return filter, nil
+	}
+	after := opts.After
+	if after.Sort != opts.Sort || after.Descending != opts.Descending {
+		return nil, ErrInvalidCursor
+	}
+	lastID, err := primitive.ObjectIDFromHex(after.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	op := "$gt"
+	if opts.Descending {
+		op = "$lt"
+	}
+
+	// MongoDB sorts missing and null values before all others, but range
+	// operators never match them, so they are paged with equality on null.
+	var page bson.M
+	switch {
+	case opts.Sort == "_id":
+		page = bson.M{"_id": bson.M{op: lastID}}
+	case after.Kind == cursorKindNull && opts.Descending:
+		page = bson.M{opts.Sort: nil, "_id": bson.M{op: lastID}}
+	case after.Kind == cursorKindNull:
+		page = bson.M{"$or": []bson.M{
+			{opts.Sort: nil, "_id": bson.M{op: lastID}},
+			{opts.Sort: bson.M{"$ne": nil}},
+		}}
+	default:
+		value, err := cursorValue(after)
+		if err != nil {
+			return nil, err
+		}
+		or := []bson.M{
+			{opts.Sort: bson.M{op: value}},
+			{opts.Sort: value, "_id": bson.M{op: lastID}},
+		}
+		if opts.Descending {
+			or = append(or, bson.M{opts.Sort: nil})
+		}
+		page = bson.M{"$or": or}
+	}
+
+	if len(filter) == 0 {
+		return page, nil
+	}
+	return bson.M{"$and": []bson.M{filter, page}}, nil
+}
+
+// findPage runs a keyset-paginated query on coll. It fetches one extra
+// document to find out whether another page follows.
+func findPage[T any](coll *mongo.Collection, filter bson.M, opts ListOptions) (models.Page[T], error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query, err := keysetFilter(filter, opts)
+	if err != nil {
+		return models.Page[T]{}, err
+	}
+
+	dir := 1
+	if opts.Descending {
+		dir = -1
+	}
+	sort := bson.D{{Key: "_id", Value: dir}}
+	if opts.Sort != "_id" {
+		sort = bson.D{{Key: opts.Sort, Value: dir}, {Key: "_id", Value: dir}}
+	}
+
+	findOpts := options.Find().SetSort(sort).SetLimit(int64(opts.Limit) + 1)
+	cursor, err := coll.Find(ctx, query, findOpts)
+	if err != nil {
+		return models.Page[T]{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.Raw
+	for cursor.Next(ctx) {
+		docs = append(docs, append(bson.Raw(nil), cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
+		return models.Page[T]{}, err
+	}
+
+	page := models.Page[T]{Items: make([]T, 0, len(docs))}
+	if len(docs) > opts.Limit {
+		docs = docs[:opts.Limit]
+		page.NextCursor = cursorFor(docs[len(docs)-1], opts)
+	}
+	for _, doc := range docs {
+		var item T
+		if err := bson.Unmarshal(doc, &item); err != nil {
+			return models.Page[T]{}, err
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	if opts.IncludeTotal {
+		total, err := coll.CountDocuments(ctx, filter)
+		if err != nil {
+			return models.Page[T]{}, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+func cursorFor(doc bson.Raw, opts ListOptions) string {
+	c := PageCursor{Sort: opts.Sort, Descending: opts.Descending}
+	if id, ok := doc.Lookup("_id").ObjectIDOK(); ok {
+		c.ID = id.Hex()
+	}
+	if opts.Sort != "_id" {
+		var value interface{}
+		if err := doc.Lookup(opts.Sort).Unmarshal(&value); err == nil {
+			c.Value = value
+		}
+		switch value := c.Value.(type) {
+		case nil, primitive.Null:
+			c.Value = nil
+			c.Kind = cursorKindNull
+		case primitive.DateTime:
+			c.Value = int64(value)
+			c.Kind = cursorKindDate
+		}
+	}
+	return EncodeCursor(c)
+}
+
+func cursorValue(c *PageCursor) (interface{}, error) {
+	if c.Value == nil {
+		return nil, ErrInvalidCursor
+	}
+	switch c.Kind {
+	case "":
+		return c.Value, nil
+	case cursorKindDate:
+		millis, ok := c.Value.(float64)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		return primitive.DateTime(int64(millis)), nil
+	default:
+		return nil, ErrInvalidCursor
+	}
+}
+
+// pageStrings pages a sorted, duplicate-free list of strings in memory. The
+// cursor keeps the last value in ID since the values have no ObjectID.
+func pageStrings(values []string, opts ListOptions) (models.Page[string], error) {
+	start := 0
+	if opts.After != nil {
+		if opts.After.Sort != opts.Sort || opts.After.Descending != opts.Descending {
+			return models.Page[string]{}, ErrInvalidCursor
+		}
+		start = len(values)
+		for i, v := range values {
+			if (!opts.Descending && v > opts.After.ID) || (opts.Descending && v < opts.After.ID) {
+				start = i
+				break
+			}
+		}
+	}
+
+	page := models.Page[string]{Items: values[start:]}
+	if len(page.Items) > opts.Limit {
+		page.Items = page.Items[:opts.Limit]
+		page.NextCursor = EncodeCursor(PageCursor{
+			Sort:       opts.Sort,
+			Descending: opts.Descending,
+			ID:         page.Items[len(page.Items)-1],
+		})
+	}
+	if opts.IncludeTotal {
+		total := int64(len(values))
+		page.Total = &total
+	}
+	return page, nil
+}
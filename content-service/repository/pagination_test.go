@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	in := PageCursor{Sort: "title", Descending: true, Value: "Abbey Road", ID: primitive.NewObjectID().Hex()}
+
+	out, err := DecodeCursor(EncodeCursor(in))
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !reflect.DeepEqual(*out, in) {
+		t.Fatalf("got %+v, want %+v", *out, in)
+	}
+
+	for _, bad := range []string{"", "not base64!", EncodeCursor(PageCursor{Sort: "title"})} {
+		if _, err := DecodeCursor(bad); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidCursor", bad, err)
+		}
+	}
+}
+
+func TestKeysetFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	base := bson.M{"albumId": "a"}
+
+	got, err := keysetFilter(base, ListOptions{
+		Sort:  "trackNo",
+		After: &PageCursor{Sort: "trackNo", Value: 3.0, ID: id.Hex()},
+	})
+	if err != nil {
+		t.Fatalf("keysetFilter: %v", err)
+	}
+	want := bson.M{"$and": []bson.M{base, {"$or": []bson.M{
+		{"trackNo": bson.M{"$gt": 3.0}},
+		{"trackNo": 3.0, "_id": bson.M{"$gt": id}},
+	}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got, err = keysetFilter(bson.M{}, ListOptions{
+		Sort:       "_id",
+		Descending: true,
+		After:      &PageCursor{Sort: "_id", Descending: true, ID: id.Hex()},
+	})
+	if err != nil {
+		t.Fatalf("keysetFilter: %v", err)
+	}
+	if want := (bson.M{"_id": bson.M{"$lt": id}}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	_, err = keysetFilter(base, ListOptions{
+		Sort:  "title",
+		After: &PageCursor{Sort: "trackNo", Value: 3.0, ID: id.Hex()},
+	})
+	if err != ErrInvalidCursor {
+		t.Fatalf("cursor for another sort: got %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestPageStrings(t *testing.T) {
+	values := []string{"Blues", "Jazz", "Pop", "Rock"}
+	opts := ListOptions{Limit: 2, Sort: "name", IncludeTotal: true}
+
+	first, err := pageStrings(values, opts)
+	if err != nil {
+		t.Fatalf("pageStrings: %v", err)
+	}
+	if !reflect.DeepEqual(first.Items, []string{"Blues", "Jazz"}) || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	if first.Total == nil || *first.Total != 4 {
+		t.Fatalf("total = %v, want 4", first.Total)
+	}
+
+	opts.After, err = DecodeCursor(first.NextCursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	second, err := pageStrings(values, opts)
+	if err != nil {
+		t.Fatalf("pageStrings: %v", err)
+	}
+	if !reflect.DeepEqual(second.Items, []string{"Pop", "Rock"}) || second.NextCursor != "" {
+		t.Fatalf("second page = %+v", second)
+	}
+}
+
+func TestDateCursor(t *testing.T) {
+	id := primitive.NewObjectID()
+	deletedAt := primitive.NewDateTimeFromTime(time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC))
+	doc, err := bson.Marshal(bson.M{"_id": id, "deletedAt": deletedAt})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	opts := ListOptions{Sort: "deletedAt", Descending: true}
+	opts.After, err = DecodeCursor(cursorFor(doc, opts))
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+
+	got, err := keysetFilter(bson.M{}, opts)
+	if err != nil {
+		t.Fatalf("keysetFilter: %v", err)
+	}
+	want := bson.M{"$or": []bson.M{
+		{"deletedAt": bson.M{"$lt": deletedAt}},
+		{"deletedAt": deletedAt, "_id": bson.M{"$lt": id}},
+		{"deletedAt": nil},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCursorForMissingValue(t *testing.T) {
+	id := primitive.NewObjectID()
+	for _, fields := range []bson.M{{"_id": id}, {"_id": id, "releaseDate": nil}} {
+		doc, err := bson.Marshal(fields)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+
+		asc := ListOptions{Sort: "releaseDate"}
+		asc.After, err = DecodeCursor(cursorFor(doc, asc))
+		if err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+		got, err := keysetFilter(bson.M{}, asc)
+		if err != nil {
+			t.Fatalf("ascending after %v: %v", fields, err)
+		}
+		want := bson.M{"$or": []bson.M{
+			{"releaseDate": nil, "_id": bson.M{"$gt": id}},
+			{"releaseDate": bson.M{"$ne": nil}},
+		}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ascending: got %v, want %v", got, want)
+		}
+
+		desc := ListOptions{Sort: "releaseDate", Descending: true}
+		desc.After, err = DecodeCursor(cursorFor(doc, desc))
+		if err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+		got, err = keysetFilter(bson.M{}, desc)
+		if err != nil {
+			t.Fatalf("descending after %v: %v", fields, err)
+		}
+		if want := (bson.M{"releaseDate": nil, "_id": bson.M{"$lt": id}}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("descending: got %v, want %v", got, want)
+		}
+	}
+}
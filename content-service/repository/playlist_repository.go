@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"content-service/db"
+	"content-service/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrPlaylistConflict is returned when a song change does not apply to the
+// playlist as it is now: a duplicate add, or a reorder that is not a
+// permutation of the current songs.
+var ErrPlaylistConflict = errors.New("playlist changed or song already present")
+
+func EnsurePlaylistIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.PlaylistsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ownerId", Value: 1}}},
+		{Keys: bson.D{{Key: "collaborators", Value: 1}}},
+		{Keys: bson.D{{Key: "songIds", Value: 1}}},
+	})
+	return err
+}
+
+func CreatePlaylist(playlist *models.Playlist) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	playlist.CreatedAt = now
+	playlist.UpdatedAt = now
+	if playlist.Collaborators == nil {
+		playlist.Collaborators = []primitive.ObjectID{}
+	}
+	if playlist.SongIDs == nil {
+		playlist.SongIDs = []primitive.ObjectID{}
+	}
+
+	result, err := db.PlaylistsCollection.InsertOne(ctx, playlist)
+	if err != nil {
+		return err
+	}
+	playlist.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetPlaylistByID returns nil when the playlist does not exist.
+func GetPlaylistByID(id primitive.ObjectID) (*models.Playlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var playlist models.Playlist
+	err := db.PlaylistsCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&playlist)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// GetPlaylistsForUser returns the playlists the user owns or collaborates on.
+func GetPlaylistsForUser(userID primitive.ObjectID) ([]models.Playlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"$or": []bson.M{{"ownerId": userID}, {"collaborators": userID}}}
+	opts := options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}})
+
+	cursor, err := db.PlaylistsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var playlists []models.Playlist
+	if err := cursor.All(ctx, &playlists); err != nil {
+		return nil, err
+	}
+	if playlists == nil {
+		playlists = []models.Playlist{}
+	}
+	return playlists, nil
+}
+
+func UpdatePlaylistFields(id primitive.ObjectID, fields bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fields["updatedAt"] = time.Now()
+	result, err := db.PlaylistsCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func DeletePlaylist(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.PlaylistsCollection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// AddSongToPlaylist inserts the song at position, or appends it when position
+// is negative or past the end.
+func AddSongToPlaylist(id, songID primitive.ObjectID, position int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	push := bson.M{"$each": []primitive.ObjectID{songID}}
+	if position >= 0 {
+		push["$position"] = position
+	}
+
+	result, err := db.PlaylistsCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "songIds": bson.M{"$ne": songID}},
+		bson.M{
+			"$push": bson.M{"songIds": push},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPlaylistConflict
+	}
+	return nil
+}
+
+func RemoveSongFromPlaylist(id, songID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.PlaylistsCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "songIds": songID},
+		bson.M{
+			"$pull": bson.M{"songIds": songID},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ReorderPlaylistSongs replaces the song order. It only applies when songIDs
+// holds exactly the live songs currently in the playlist; trashed songs are
+// not shown, so they keep their place until they are restored or purged.
+func ReorderPlaylistSongs(id primitive.ObjectID, songIDs []primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var playlist struct {
+		SongIDs []primitive.ObjectID `bson:"songIds"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"songIds": 1})
+	if err := db.PlaylistsCollection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&playlist); err != nil {
+		return err
+	}
+	stored := playlist.SongIDs
+	if stored == nil {
+		stored = []primitive.ObjectID{}
+	}
+
+	hidden := map[primitive.ObjectID]bool{}
+	if len(stored) > 0 {
+		trashedIDs, err := distinctIDs(ctx, db.SongsCollection, trashed(bson.M{"_id": bson.M{"$in": stored}}))
+		if err != nil {
+			return err
+		}
+		for _, songID := range trashedIDs {
+			hidden[songID] = true
+		}
+	}
+
+	reordered, ok := reorderVisible(stored, hidden, songIDs)
+	if !ok {
+		return ErrPlaylistConflict
+	}
+
+	// Matching the whole stored list keeps a concurrent change from being
+	// overwritten.
+	result, err := db.PlaylistsCollection.UpdateOne(ctx, bson.M{"_id": id, "songIds": stored}, bson.M{
+		"$set": bson.M{"songIds": reordered, "updatedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPlaylistConflict
+	}
+	return nil
+}
+
+// reorderVisible puts order into the places of the stored songs that are not
+// hidden, leaving the hidden ones where they are. It reports false when order
+// is not a permutation of the visible songs.
+func reorderVisible(stored []primitive.ObjectID, hidden map[primitive.ObjectID]bool, order []primitive.ObjectID) ([]primitive.ObjectID, bool) {
+	visible := make(map[primitive.ObjectID]bool, len(stored))
+	for _, songID := range stored {
+		if !hidden[songID] {
+			visible[songID] = true
+		}
+	}
+	if len(order) != len(visible) {
+		return nil, false
+	}
+	for _, songID := range order {
+		if !visible[songID] {
+			return nil, false
+		}
+		delete(visible, songID)
+	}
+
+	reordered := make([]primitive.ObjectID, 0, len(stored))
+	next := 0
+	for _, songID := range stored {
+		if hidden[songID] {
+			reordered = append(reordered, songID)
+			continue
+		}
+		reordered = append(reordered, order[next])
+		next++
+	}
+	return reordered, true
+}
+
+func AddPlaylistCollaborator(id, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.PlaylistsCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$addToSet": bson.M{"collaborators": userID},
+		"$set":      bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func RemovePlaylistCollaborator(id, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.PlaylistsCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "collaborators": userID},
+		bson.M{
+			"$pull": bson.M{"collaborators": userID},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// GetSongsByIDs returns the songs in the order of ids, skipping missing ones.
+func GetSongsByIDs(ids []primitive.ObjectID) ([]models.Song, error) {
+	if len(ids) == 0 {
+		return []models.Song{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.SongsCollection.Find(ctx, live(bson.M{"_id": bson.M{"$in": ids}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.Song
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]models.Song, len(found))
+	for _, s := range found {
+		byID[s.ID] = s
+	}
+
+	songs := make([]models.Song, 0, len(ids))
+	for _, id := range ids {
+		if s, ok := byID[id]; ok {
+			songs = append(songs, s)
+		}
+	}
+	return songs, nil
+}
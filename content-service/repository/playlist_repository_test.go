@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReorderVisibleKeepsHiddenSongsInPlace(t *testing.T) {
+	a, b, c, gone := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	stored := []primitive.ObjectID{a, gone, b, c}
+	hidden := map[primitive.ObjectID]bool{gone: true}
+
+	got, ok := reorderVisible(stored, hidden, []primitive.ObjectID{c, a, b})
+	if want := []primitive.ObjectID{c, gone, a, b}; !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("reorderVisible = %v, %v, want %v", got, ok, want)
+	}
+
+	for name, order := range map[string][]primitive.ObjectID{
+		"missing a song":  {c, a},
+		"hidden song":     {c, a, b, gone},
+		"duplicate":       {a, a, b},
+		"not in playlist": {a, b, primitive.NewObjectID()},
+	} {
+		if _, ok := reorderVisible(stored, hidden, order); ok {
+			t.Errorf("%s: reorder accepted", name)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"content-service/db"
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// revisionAttempts bounds how often CreateRevision retries when another
+// change to the same entity took the version number first.
+const revisionAttempts = 5
+
+func EnsureRevisionIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.RevisionsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "entityType", Value: 1}, {Key: "entityId", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("entity_version"),
+	})
+	return err
+}
+
+// CreateRevision stores rev as the next version of its entity. Versions
+// start at 1; the unique index keeps two concurrent changes from sharing
+// a number.
+func CreateRevision(rev *models.Revision) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		var last models.Revision
+		err := db.RevisionsCollection.FindOne(ctx,
+			bson.M{"entityType": rev.EntityType, "entityId": rev.EntityID},
+			options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1}),
+		).Decode(&last)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+
+		rev.ID = primitive.NewObjectID()
+		rev.Version = last.Version + 1
+		_, err = db.RevisionsCollection.InsertOne(ctx, rev)
+		if mongo.IsDuplicateKeyError(err) && attempt < revisionAttempts {
+			continue
+		}
+		return err
+	}
+}
+
+func ListRevisions(entityType string, entityID primitive.ObjectID, opts ListOptions) (models.Page[models.Revision], error) {
+	filter := bson.M{"entityType": entityType, "entityId": entityID}
+	return findPage[models.Revision](db.RevisionsCollection, filter, opts)
+}
+
+// GetRevision returns nil when the entity has no such version.
+func GetRevision(entityType string, entityID primitive.ObjectID, version int) (*models.Revision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var rev models.Revision
+	err := db.RevisionsCollection.FindOne(ctx, bson.M{
+		"entityType": entityType,
+		"entityId":   entityID,
+		"version":    version,
+	}).Decode(&rev)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
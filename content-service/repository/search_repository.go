@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"content-service/db"
+	"content-service/models"
+	"content-service/search"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const searchIndexName = "catalog_search"
+
+// EnsureSearchIndexes creates the text index of every catalog collection and
+// fills in the search fields of documents written before search existed
+// (or inserted directly, such as the seed data).
+func EnsureSearchIndexes() error {
+	collections := []struct {
+		coll   *mongo.Collection
+		fields func(bson.Raw) *models.SearchFields
+	}{
+		{db.ArtistsCollection, func(doc bson.Raw) *models.SearchFields {
+			return search.ArtistFields(rawString(doc, "name"), rawString(doc, "biography"))
+		}},
+		{db.AlbumsCollection, func(doc bson.Raw) *models.SearchFields {
+			return search.TitleFields(rawString(doc, "title"))
+		}},
+		{db.SongsCollection, func(doc bson.Raw) *models.SearchFields {
+			return search.TitleFields(rawString(doc, "title"))
+		}},
+	}
+
+	for _, c := range collections {
+		if err := ensureTextIndex(c.coll); err != nil {
+			return fmt.Errorf("text index on %s: %w", c.coll.Name(), err)
+		}
+		if err := backfillSearchFields(c.coll, c.fields); err != nil {
+			return fmt.Errorf("search fields on %s: %w", c.coll.Name(), err)
+		}
+	}
+	return nil
+}
+
+func ensureTextIndex(coll *mongo.Collection) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// "none" turns off stemming and stop words; the folded names are not
+	// English and should match as typed.
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "search.primary", Value: "text"},
+			{Key: "search.secondary", Value: "text"},
+		},
+		Options: options.Index().
+			SetName(searchIndexName).
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{
+				{Key: "search.primary", Value: 10},
+				{Key: "search.secondary", Value: 1},
+			}),
+	})
+	return err
+}
+
+func backfillSearchFields(coll *mongo.Collection, fields func(bson.Raw) *models.SearchFields) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := coll.Find(ctx, bson.M{"search": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		id := cursor.Current.Lookup("_id")
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"search": fields(cursor.Current)}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func rawString(doc bson.Raw, key string) string {
+	s, _ := doc.Lookup(key).StringValueOK()
+	return s
+}
+
+func SearchArtistsText(query string, limit int) ([]models.ArtistSearchHit, error) {
+	var hits []models.ArtistSearchHit
+	if err := findByText(db.ArtistsCollection, query, limit, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func SearchAlbumsText(query string, limit int) ([]models.AlbumSearchHit, error) {
+	var hits []models.AlbumSearchHit
+	if err := findByText(db.AlbumsCollection, query, limit, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func SearchSongsText(query string, limit int) ([]models.SongSearchHit, error) {
+	var hits []models.SongSearchHit
+	if err := findByText(db.SongsCollection, query, limit, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// findByText runs a $text query, ordered by relevance, and decodes the
+// documents together with their score into results.
+func findByText(coll *mongo.Collection, query string, limit int, results interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := coll.Find(ctx, live(bson.M{"$text": bson.M{"$search": query}}), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+// containsFilter matches value anywhere in the field, case-insensitively.
+// The input is escaped so it is never interpreted as a pattern.
+func containsFilter(value string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(value), "$options": "i"}
+}
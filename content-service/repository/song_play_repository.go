@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"content-service/db"
+	"content-service/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func EnsureSongPlayIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.SongPlaysCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "playedAt", Value: -1}}, Options: options.Index().SetName("user_history_lookup")},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "songId", Value: 1}, {Key: "playedAt", Value: -1}}, Options: options.Index().SetName("user_song_recent_play")},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.LastPlaysCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetName("last_play_expiry").SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// RecordPlay stores a play and bumps the song's play count. A play of the
+// same song by the same user within minInterval of the previous one is
+// treated as the same listen and ignored; the bool reports whether the play
+// was recorded.
+//
+// The previous play is kept in last_plays, one document per user and song.
+// Moving its time forward is a single upsert that only matches when it is
+// older than minInterval, so of two concurrent plays only one gets through:
+// the other finds a recent time or loses the insert on the _id.
+func RecordPlay(userID, songID primitive.ObjectID, source string, minInterval time.Duration) (*models.SongPlay, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	key := bson.D{{Key: "userId", Value: userID}, {Key: "songId", Value: songID}}
+	_, err := db.LastPlaysCollection.UpdateOne(ctx,
+		bson.M{"_id": key, "playedAt": bson.M{"$lt": now.Add(-minInterval)}},
+		bson.M{"$set": bson.M{"playedAt": now, "expiresAt": now.Add(minInterval)}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	play := models.SongPlay{
+		UserID:   userID,
+		SongID:   songID,
+		PlayedAt: now,
+		Source:   source,
+	}
+	result, err := db.SongPlaysCollection.InsertOne(ctx, play)
+	if err != nil {
+		return nil, false, err
+	}
+	play.ID = result.InsertedID.(primitive.ObjectID)
+
+	if _, err := db.SongsCollection.UpdateOne(ctx,
+		bson.M{"_id": songID},
+		bson.M{"$inc": bson.M{"playCount": 1}},
+	); err != nil {
+		return nil, false, err
+	}
+
+	return &play, true, nil
+}
+
+// GetListeningHistory returns the user's plays, newest first, played before
+// the given time (or up to now when before is zero).
+func GetListeningHistory(userID primitive.ObjectID, before time.Time, limit int) ([]models.ListeningHistoryItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	match := bson.M{"userId": userID}
+	if !before.IsZero() {
+		match["playedAt"] = bson.M{"$lt": before}
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sort": bson.D{{Key: "playedAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{"$limit": limit},
+		// Plays of trashed songs stay in the history, like those of deleted
+		// ones, but without the song's title.
+		{"$lookup": bson.M{
+			"from":         "songs",
+			"localField":   "songId",
+			"foreignField": "_id",
+			"pipeline":     []bson.M{{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}}},
+			"as":           "song",
+		}},
+		{"$unwind": bson.M{"path": "$song", "preserveNullAndEmptyArrays": true}},
+		{"$project": bson.M{
+			"songId":    1,
+			"playedAt":  1,
+			"source":    1,
+			"songTitle": "$song.title",
+			"albumId":   "$song.albumId",
+		}},
+	}
+
+	cursor, err := db.SongPlaysCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var items []models.ListeningHistoryItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.ListeningHistoryItem{}
+	}
+	return items, nil
+}
+
+// GetSongPlayCount returns mongo.ErrNoDocuments for unknown songs.
+func GetSongPlayCount(songID primitive.ObjectID) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var song models.Song
+	err := db.SongsCollection.FindOne(ctx, live(bson.M{"_id": songID}),
+		options.FindOne().SetProjection(bson.M{"playCount": 1}),
+	).Decode(&song)
+	if err != nil {
+		return 0, err
+	}
+	return song.PlayCount, nil
+}
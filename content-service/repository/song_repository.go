@@ -7,12 +7,27 @@ import (
 
 	"content-service/db"
 	"content-service/models"
+	"content-service/search"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+func EnsureSongIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.SongsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "checksum", Value: 1}}},
+		{Keys: bson.D{{Key: "audioFile", Value: 1}}},
+		{Keys: bson.D{{Key: "stream.status", Value: 1}}},
+		{Keys: bson.D{{Key: "analysis.status", Value: 1}}},
+	})
+	return err
+}
+
 func CreateSong(song models.Song) (*models.Song, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -20,6 +35,7 @@ func CreateSong(song models.Song) (*models.Song, error) {
 	if song.ID.IsZero() {
 		song.ID = primitive.NewObjectID()
 	}
+	song.Search = search.TitleFields(song.Title)
 
 	_, err := db.SongsCollection.InsertOne(ctx, song)
 	if err != nil {
@@ -29,25 +45,6 @@ func CreateSong(song models.Song) (*models.Song, error) {
 	return &song, nil
 }
 
-func GetAllSongs() ([]models.Song, error) {
-	cursor, err := db.SongsCollection.Find(context.Background(), bson.M{})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(context.Background())
-
-	var songs []models.Song
-	if err := cursor.All(context.Background(), &songs); err != nil {
-		return nil, err
-	}
-
-	if songs == nil {
-		songs = []models.Song{}
-	}
-
-	return songs, nil
-}
-
 func GetSongByID(id string) (*models.Song, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -57,7 +54,7 @@ func GetSongByID(id string) (*models.Song, error) {
 	var song models.Song
 	err = db.SongsCollection.FindOne(
 		context.Background(),
-		bson.M{"_id": objID},
+		live(bson.M{"_id": objID}),
 	).Decode(&song)
 	if err != nil {
 		return nil, err
@@ -66,7 +63,11 @@ func GetSongByID(id string) (*models.Song, error) {
 	return &song, nil
 }
 
-func SetSongAudioFile(id string, audioFile string) error {
+// SetSongAudio stores the uploaded audio file of a song together with what
+// was read from it: the checksum, the audio info, the measured duration and,
+// when tags were applied, the title and track number. The stream and
+// analysis states are reset along with it.
+func SetSongAudio(id string, song models.Song) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -77,8 +78,18 @@ func SetSongAudioFile(id string, audioFile string) error {
 
 	result, err := db.SongsCollection.UpdateOne(
 		ctx,
-		bson.M{"_id": objID},
-		bson.M{"$set": bson.M{"audioFile": audioFile}},
+		live(bson.M{"_id": objID}),
+		bson.M{"$set": bson.M{
+			"audioFile": song.AudioFile,
+			"audio":     song.Audio,
+			"checksum":  song.Checksum,
+			"stream":    song.Stream,
+			"analysis":  song.Analysis,
+			"duration":  song.Duration,
+			"title":     song.Title,
+			"trackNo":   song.TrackNo,
+			"search":    search.TitleFields(song.Title),
+		}},
 	)
 	if err != nil {
 		return err
@@ -104,12 +115,13 @@ func UpdateSong(id string, song models.Song) error {
 			"duration": song.Duration,
 			"albumId":  song.AlbumID,
 			"trackNo":  song.TrackNo,
+			"search":   search.TitleFields(song.Title),
 		},
 	}
 
 	result, err := db.SongsCollection.UpdateOne(
 		ctx,
-		bson.M{"_id": objID},
+		live(bson.M{"_id": objID}),
 		update,
 	)
 	if err != nil {
@@ -123,55 +135,92 @@ func UpdateSong(id string, song models.Song) error {
 	return nil
 }
 
-func DeleteSong(id string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
+func ListSongsByAlbumID(albumID string, searchQuery string, opts ListOptions) (models.Page[models.Song], error) {
+	objID, err := primitive.ObjectIDFromHex(albumID)
 	if err != nil {
-		return err
+		return models.Page[models.Song]{}, err
 	}
 
+	filter := live(bson.M{"albumId": objID})
+	if searchQuery != "" {
+		filter["title"] = containsFilter(searchQuery)
+	}
+
+	return findPage[models.Song](db.SongsCollection, filter, opts)
+}
+
+func AlbumExistsByID(id primitive.ObjectID) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	result, err := db.SongsCollection.DeleteOne(
-		ctx,
-		bson.M{"_id": objID},
-	)
+	count, err := db.AlbumsCollection.CountDocuments(ctx, live(bson.M{"_id": id}))
 	if err != nil {
-		return err
+		return false, err
 	}
+	return count > 0, nil
+}
 
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("song not found")
-	}
+func SongExistsByID(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	return nil
+	count, err := db.SongsCollection.CountDocuments(ctx, live(bson.M{"_id": id}))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
-func GetSongsByAlbumID(albumID string) ([]models.Song, error) {
-	return SearchSongsByAlbumID(albumID, "")
+func ListSongs(searchQuery string, opts ListOptions) (models.Page[models.Song], error) {
+	filter := live(bson.M{})
+	if searchQuery != "" {
+		filter["title"] = containsFilter(searchQuery)
+	}
+
+	return findPage[models.Song](db.SongsCollection, filter, opts)
 }
 
-func SearchSongsByAlbumID(albumID string, searchQuery string) ([]models.Song, error) {
-	objID, err := primitive.ObjectIDFromHex(albumID)
+// FindSongByChecksum returns a live song other than exclude whose audio has
+// the given checksum, or nil when there is none.
+func FindSongByChecksum(checksum string, exclude primitive.ObjectID) (*models.Song, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var song models.Song
+	err := db.SongsCollection.FindOne(ctx, live(bson.M{
+		"checksum": checksum,
+		"_id":      bson.M{"$ne": exclude},
+	})).Decode(&song)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	return &song, nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// AudioFileInUse reports whether any song, trashed ones included, still
+// points at the stored audio file.
+func AudioFileInUse(audioFile string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{"albumId": objID}
-	if searchQuery != "" {
-		filter["title"] = bson.M{"$regex": searchQuery, "$options": "i"}
-	}
+	n, err := db.SongsCollection.CountDocuments(ctx,
+		bson.M{"audioFile": audioFile},
+		options.Count().SetLimit(1),
+	)
+	return n > 0, err
+}
 
-	opts := options.Find().SetSort(bson.M{"trackNo": 1})
+// ListSongAudio returns the id, audio file and checksum of every song with
+// uploaded audio, trashed ones included.
+func ListSongAudio() ([]models.Song, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	cursor, err := db.SongsCollection.Find(
-		ctx,
-		filter,
-		opts,
-	)
+	opts := options.Find().SetProjection(bson.M{"audioFile": 1, "checksum": 1})
+	cursor, err := db.SongsCollection.Find(ctx, bson.M{"audioFile": bson.M{"$nin": []interface{}{nil, ""}}}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -181,36 +230,75 @@ func SearchSongsByAlbumID(albumID string, searchQuery string) ([]models.Song, er
 	if err := cursor.All(ctx, &songs); err != nil {
 		return nil, err
 	}
+	return songs, nil
+}
 
-	if songs == nil {
-		songs = []models.Song{}
-	}
+func SetSongChecksum(id primitive.ObjectID, checksum string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	return songs, nil
+	_, err := db.SongsCollection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"checksum": checksum}},
+	)
+	return err
 }
 
-func AlbumExistsByID(id primitive.ObjectID) (bool, error) {
+// SetSongStream records the outcome of a transcode. It only applies while
+// the song still has the audio with the given checksum, so a job finishing
+// after a newer upload does not overwrite that upload's state.
+func SetSongStream(id primitive.ObjectID, checksum string, stream *models.StreamInfo) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	count, err := db.AlbumsCollection.CountDocuments(ctx, bson.M{"_id": id})
-	if err != nil {
-		return false, err
-	}
-	return count > 0, nil
+	_, err := db.SongsCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "checksum": checksum},
+		bson.M{"$set": bson.M{"stream": stream}},
+	)
+	return err
 }
 
-func SearchSongs(searchQuery string) ([]models.Song, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// SetSongAnalysis records the outcome of analysing the song's audio, under
+// the same checksum condition as SetSongStream.
+func SetSongAnalysis(id primitive.ObjectID, checksum string, analysis *models.AudioAnalysis) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
+	_, err := db.SongsCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "checksum": checksum},
+		bson.M{"$set": bson.M{"analysis": analysis}},
+	)
+	return err
+}
+
+// QueueMissingAnalyses marks songs whose audio was uploaded before analysis
+// existed as pending, so the audio workers pick them up.
+func QueueMissingAnalyses() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	if searchQuery != "" {
-		filter["title"] = bson.M{"$regex": searchQuery, "$options": "i"}
+	result, err := db.SongsCollection.UpdateMany(ctx,
+		live(bson.M{"checksum": bson.M{"$exists": true, "$ne": ""}, "analysis": bson.M{"$exists": false}}),
+		bson.M{"$set": bson.M{"analysis": &models.AudioAnalysis{Status: models.AnalysisStatusPending, UpdatedAt: time.Now()}}},
+	)
+	if err != nil {
+		return 0, err
 	}
+	return result.ModifiedCount, nil
+}
 
-	cursor, err := db.SongsCollection.Find(ctx, filter)
+// ListPendingAudioSongIDs returns the live songs whose transcode or analysis
+// has not finished yet.
+func ListPendingAudioSongIDs() ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	filter := live(bson.M{"$or": bson.A{
+		bson.M{"stream.status": models.StreamStatusPending},
+		bson.M{"analysis.status": models.AnalysisStatusPending},
+	}})
+	cursor, err := db.SongsCollection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -220,10 +308,9 @@ func SearchSongs(searchQuery string) ([]models.Song, error) {
 	if err := cursor.All(ctx, &songs); err != nil {
 		return nil, err
 	}
-
-	if songs == nil {
-		songs = []models.Song{}
+	ids := make([]primitive.ObjectID, len(songs))
+	for i, s := range songs {
+		ids[i] = s.ID
 	}
-
-	return songs, nil
+	return ids, nil
 }
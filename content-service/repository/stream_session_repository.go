@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"content-service/db"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Stream sessions limit how many streams a user has at once. Every signed
+// URL belongs to a session; opening a session beyond the limit ends the
+// user's least recently opened one, whose URLs stop working. A player that
+// keeps its session across songs therefore uses one slot. Sessions live in
+// MongoDB so every replica sees the same ones.
+
+func EnsureStreamSessionIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.StreamSessionsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "openedAt", Value: -1}}, Options: options.Index().SetName("user_sessions")},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetName("stream_session_expiry").SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// OpenStreamSession returns the session a new URL belongs to. When reuse
+// names a live session of the user it is kept and extended to expiresAt;
+// otherwise a new one is started, ending the oldest sessions above limit.
+// It also returns how many sessions were ended.
+func OpenStreamSession(userID, reuse string, limit int, expiresAt, now time.Time) (string, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if reuse != "" {
+		res, err := db.StreamSessionsCollection.UpdateOne(ctx,
+			bson.M{"_id": reuse, "userId": userID, "expiresAt": bson.M{"$gt": now}},
+			bson.M{"$max": bson.M{"expiresAt": expiresAt}},
+		)
+		if err != nil {
+			return "", 0, err
+		}
+		if res.MatchedCount > 0 {
+			return reuse, 0, nil
+		}
+	}
+
+	id := newStreamSessionID()
+	if _, err := db.StreamSessionsCollection.InsertOne(ctx, bson.M{
+		"_id":       id,
+		"userId":    userID,
+		"openedAt":  now,
+		"expiresAt": expiresAt,
+	}); err != nil {
+		return "", 0, err
+	}
+
+	// Everything past the newest limit sessions ends. Two concurrent opens
+	// both trim, and agree on which ones to keep.
+	opts := options.Find().
+		SetSort(bson.D{{Key: "openedAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(limit)).
+		SetProjection(bson.M{"_id": 1})
+	cursor, err := db.StreamSessionsCollection.Find(ctx, bson.M{"userId": userID, "expiresAt": bson.M{"$gt": now}}, opts)
+	if err != nil {
+		return id, 0, err
+	}
+	var over []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &over); err != nil {
+		return id, 0, err
+	}
+	if len(over) == 0 {
+		return id, 0, nil
+	}
+
+	ids := make([]string, 0, len(over))
+	for _, s := range over {
+		ids = append(ids, s.ID)
+	}
+	res, err := db.StreamSessionsCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return id, 0, err
+	}
+	return id, int(res.DeletedCount), nil
+}
+
+// StreamSessionActive reports whether the user's session is still open.
+func StreamSessionActive(userID, sessionID string, now time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	n, err := db.StreamSessionsCollection.CountDocuments(ctx,
+		bson.M{"_id": sessionID, "userId": userID, "expiresAt": bson.M{"$gt": now}},
+		options.Count().SetLimit(1),
+	)
+	return n > 0, err
+}
+
+func newStreamSessionID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -59,6 +59,7 @@ func GetUserArtistSubscriptionsWithDetails(userID primitive.ObjectID) ([]models.
 			"as":           "artist",
 		}}},
 		{{Key: "$unwind", Value: "$artist"}},
+		{{Key: "$match", Value: bson.M{"artist.deletedAt": bson.M{"$exists": false}}}},
 		{{Key: "$project", Value: bson.M{
 			"_id":          1,
 			"userId":       1,
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"content-service/db"
+	"content-service/suggest"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoadSuggestEntries reads every artist, album and song title for the
+// typeahead index. An entry's weight is the sum of the ratings its songs
+// received, so both how often and how well they were rated count.
+func LoadSuggestEntries() ([]suggest.Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	songWeights, err := ratingSumsBySong(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []suggest.Entry
+	albumWeights := map[primitive.ObjectID]float64{}
+	artistWeights := map[primitive.ObjectID]float64{}
+
+	var songs []struct {
+		ID      primitive.ObjectID `bson:"_id"`
+		Title   string             `bson:"title"`
+		AlbumID primitive.ObjectID `bson:"albumId"`
+	}
+	if err := findProjected(ctx, db.SongsCollection, bson.M{"title": 1, "albumId": 1}, &songs); err != nil {
+		return nil, err
+	}
+	for _, s := range songs {
+		w := songWeights[s.ID]
+		albumWeights[s.AlbumID] += w
+		entries = append(entries, suggest.Entry{Type: suggest.TypeSong, ID: s.ID.Hex(), Text: s.Title, Weight: w})
+	}
+
+	var albums []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Title    string             `bson:"title"`
+		ArtistID primitive.ObjectID `bson:"artistId"`
+	}
+	if err := findProjected(ctx, db.AlbumsCollection, bson.M{"title": 1, "artistId": 1}, &albums); err != nil {
+		return nil, err
+	}
+	for _, a := range albums {
+		w := albumWeights[a.ID]
+		artistWeights[a.ArtistID] += w
+		entries = append(entries, suggest.Entry{Type: suggest.TypeAlbum, ID: a.ID.Hex(), Text: a.Title, Weight: w})
+	}
+
+	var artists []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	if err := findProjected(ctx, db.ArtistsCollection, bson.M{"name": 1}, &artists); err != nil {
+		return nil, err
+	}
+	for _, a := range artists {
+		entries = append(entries, suggest.Entry{Type: suggest.TypeArtist, ID: a.ID.Hex(), Text: a.Name, Weight: artistWeights[a.ID]})
+	}
+
+	return entries, nil
+}
+
+func ratingSumsBySong(ctx context.Context) (map[primitive.ObjectID]float64, error) {
+	cursor, err := db.UserRatingsCollection.Aggregate(ctx, []bson.M{
+		{"$group": bson.M{"_id": "$songId", "total": bson.M{"$sum": "$rating"}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Total float64            `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	sums := make(map[primitive.ObjectID]float64, len(rows))
+	for _, r := range rows {
+		sums[r.ID] = r.Total
+	}
+	return sums, nil
+}
+
+func findProjected(ctx context.Context, coll *mongo.Collection, projection bson.M, results interface{}) error {
+	cursor, err := coll.Find(ctx, live(bson.M{}), options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, results)
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"content-service/db"
+	"content-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// live narrows filter to documents that are not in the trash. A trashed
+// parent always has trashed children, so filtering the queried collection
+// is enough to hide a whole deleted subtree.
+func live(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": false}
+	return filter
+}
+
+func trashed(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": true}
+	return filter
+}
+
+// TrashCascade moves everything in the plan to the trash under one deletion
+// id, songs first so a partly applied cascade never leaves a live child
+// under a trashed parent. Ratings, playlist entries and audio files stay
+// until the plan is purged.
+func TrashCascade(plan *CascadePlan, deletedBy string) (models.CascadeReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	report := plan.report()
+	deletionID := primitive.NewObjectID()
+	now := time.Now()
+	set := bson.M{"$set": bson.M{"deletedAt": now, "deletedBy": deletedBy, "deletionId": deletionID}}
+
+	if len(plan.SongIDs) > 0 {
+		if _, err := db.SongsCollection.UpdateMany(ctx, live(bson.M{"_id": bson.M{"$in": plan.SongIDs}}), set); err != nil {
+			return report, err
+		}
+	}
+	if len(plan.AlbumIDs) > 0 {
+		if _, err := db.AlbumsCollection.UpdateMany(ctx, live(bson.M{"_id": bson.M{"$in": plan.AlbumIDs}}), set); err != nil {
+			return report, err
+		}
+	}
+	if !plan.ArtistID.IsZero() {
+		if _, err := db.ArtistsCollection.UpdateOne(ctx, live(bson.M{"_id": plan.ArtistID}), set); err != nil {
+			return report, err
+		}
+	}
+
+	report.DeletionID = deletionID.Hex()
+	report.DeletedAt = &now
+	return report, nil
+}
+
+// TrashedItem is what a restore needs to know about a trashed document: the
+// deletion it belongs to and the parent that must be live to restore it.
+type TrashedItem struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	DeletionID primitive.ObjectID `bson:"deletionId"`
+	ArtistID   primitive.ObjectID `bson:"artistId"`
+	AlbumID    primitive.ObjectID `bson:"albumId"`
+}
+
+func GetTrashedArtist(id primitive.ObjectID) (*TrashedItem, error) {
+	return getTrashed(db.ArtistsCollection, id)
+}
+
+func GetTrashedAlbum(id primitive.ObjectID) (*TrashedItem, error) {
+	return getTrashed(db.AlbumsCollection, id)
+}
+
+func GetTrashedSong(id primitive.ObjectID) (*TrashedItem, error) {
+	return getTrashed(db.SongsCollection, id)
+}
+
+// getTrashed returns mongo.ErrNoDocuments unless the document is in the trash.
+func getTrashed(coll *mongo.Collection, id primitive.ObjectID) (*TrashedItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var item TrashedItem
+	if err := coll.FindOne(ctx, trashed(bson.M{"_id": id})).Decode(&item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Restored lists the documents a restore brought back, in the order they
+// were taken out of the trash.
+type Restored struct {
+	Artists []models.Artist
+	Albums  []models.Album
+	Songs   []models.Song
+}
+
+// RestoreDeletion takes every document of one deletion out of the trash,
+// the artist first and songs last, mirroring TrashCascade.
+func RestoreDeletion(deletionID primitive.ObjectID) (*Restored, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	filter := bson.M{"deletionId": deletionID}
+	unset := bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": "", "deletionId": ""}}
+	restored := &Restored{}
+
+	if err := restoreFrom(ctx, db.ArtistsCollection, filter, unset, &restored.Artists); err != nil {
+		return nil, err
+	}
+	if err := restoreFrom(ctx, db.AlbumsCollection, filter, unset, &restored.Albums); err != nil {
+		return nil, err
+	}
+	if err := restoreFrom(ctx, db.SongsCollection, filter, unset, &restored.Songs); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func restoreFrom(ctx context.Context, coll *mongo.Collection, filter, unset bson.M, results interface{}) error {
+	cursor, err := coll.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(ctx, results); err != nil {
+		return err
+	}
+	_, err = coll.UpdateMany(ctx, filter, unset)
+	return err
+}
+
+func ListTrashedArtists(opts ListOptions) (models.Page[models.Artist], error) {
+	return findPage[models.Artist](db.ArtistsCollection, trashed(bson.M{}), opts)
+}
+
+func ListTrashedAlbums(opts ListOptions) (models.Page[models.Album], error) {
+	return findPage[models.Album](db.AlbumsCollection, trashed(bson.M{}), opts)
+}
+
+func ListTrashedSongs(opts ListOptions) (models.Page[models.Song], error) {
+	return findPage[models.Song](db.SongsCollection, trashed(bson.M{}), opts)
+}
+
+// PlanTrashPurge collects everything that went to the trash before cutoff:
+// one plan per expired artist with all of its albums and songs, and one plan
+// for the expired albums and songs left over.
+func PlanTrashPurge(cutoff time.Time) ([]*CascadePlan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	expired := bson.M{"deletedAt": bson.M{"$lt": cutoff}}
+
+	artistIDs, err := distinctIDs(ctx, db.ArtistsCollection, expired)
+	if err != nil {
+		return nil, err
+	}
+	var plans []*CascadePlan
+	for _, artistID := range artistIDs {
+		plan, err := planArtist(ctx, artistID, bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+
+	rest := &CascadePlan{}
+	if err := rest.addAlbums(ctx, expired); err != nil {
+		return nil, err
+	}
+	if err := rest.addSongs(ctx, bson.M{"$or": []bson.M{
+		{"albumId": bson.M{"$in": rest.AlbumIDs}},
+		expired,
+	}}); err != nil {
+		return nil, err
+	}
+	if len(rest.AlbumIDs) > 0 || len(rest.SongIDs) > 0 {
+		plans = append(plans, rest)
+	}
+	return plans, nil
+}
@@ -0,0 +1,11 @@
+package search
+
+import "content-service/models"
+
+func ArtistFields(name, biography string) *models.SearchFields {
+	return &models.SearchFields{Primary: IndexText(name), Secondary: IndexText(biography)}
+}
+
+func TitleFields(title string) *models.SearchFields {
+	return &models.SearchFields{Primary: IndexText(title)}
+}
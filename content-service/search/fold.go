@@ -0,0 +1,93 @@
+// Package search prepares catalog text for MongoDB text search. Text is
+// folded to lower-case ASCII so "Balašević", "Balasevic" and "Балашевић"
+// all end up as the same terms, and hits are highlighted in the original.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// folds maps letters to their plain-ASCII spelling. Serbian Cyrillic is
+// transliterated to Latin; đ/ђ become "dj", the spelling people type when
+// the letter is missing from their keyboard.
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'ç': "c", 'ć': "c", 'č': "c", 'ĉ': "c", 'ċ': "c",
+	'ď': "d", 'đ': "dj",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i",
+	'ł': "l", 'ľ': "l", 'ĺ': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'ŕ': "r", 'ř': "r",
+	'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss",
+	'ť': "t", 'ţ': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'ђ': "dj", 'е': "e",
+	'ж': "z", 'з': "z", 'и': "i", 'ј': "j", 'к': "k", 'л': "l", 'љ': "lj",
+	'м': "m", 'н': "n", 'њ': "nj", 'о': "o", 'п': "p", 'р': "r", 'с': "s",
+	'т': "t", 'ћ': "c", 'у': "u", 'ф': "f", 'х': "h", 'ц': "c", 'ч': "c",
+	'џ': "dz", 'ш': "s",
+}
+
+// Fold lower-cases s and strips diacritics.
+func Fold(s string) string {
+	folded, _ := foldWithOffsets(s, false)
+	return folded
+}
+
+// foldWithOffsets folds s and returns, for every byte of the result, the
+// index of the rune in s it came from. With shortDj, đ folds to "d" instead
+// of "dj", the other spelling people use for it.
+func foldWithOffsets(s string, shortDj bool) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(s))
+
+	i := 0
+	for _, r := range s {
+		r = unicode.ToLower(r)
+		out, ok := folds[r]
+		switch {
+		case shortDj && (r == 'đ' || r == 'ђ'):
+			out = "d"
+		case !ok:
+			out = string(r)
+		}
+		for j := 0; j < len(out); j++ {
+			offsets = append(offsets, i)
+		}
+		b.WriteString(out)
+		i++
+	}
+	return b.String(), offsets
+}
+
+// Terms splits folded text into words, dropping everything that is not a
+// letter or digit. Text-search operators such as quotes and a leading minus
+// are therefore never passed through.
+func Terms(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Variants returns the folded spellings of s: one, or two when s contains
+// đ, which is written both as "dj" and as "d".
+func Variants(s string) []string {
+	long := Fold(s)
+	short, _ := foldWithOffsets(s, true)
+	if short == long {
+		return []string{long}
+	}
+	return []string{long, short}
+}
+
+// IndexText is the folded form stored for the text index. Text containing
+// đ is indexed under both of its ASCII spellings.
+func IndexText(parts ...string) string {
+	return strings.Join(Variants(strings.Join(parts, " ")), " ")
+}
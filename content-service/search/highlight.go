@@ -0,0 +1,89 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"content-service/models"
+)
+
+// snippetRadius is how many runes of context a long field keeps on either
+// side of its first match.
+const snippetRadius = 60
+
+// Highlight marks where terms occur in value. Long values are cut to a
+// snippet around the first match. It returns false when nothing matches.
+func Highlight(field, value string, terms []string) (models.Highlight, bool) {
+	runes := []rune(value)
+	spans := matchSpans(value, terms)
+	if len(spans) == 0 {
+		return models.Highlight{}, false
+	}
+
+	start, end := 0, len(runes)
+	if end > 2*snippetRadius {
+		start = spans[0].Start - snippetRadius
+		if start < 0 {
+			start = 0
+		}
+		end = start + 2*snippetRadius
+		if end > len(runes) {
+			end = len(runes)
+		}
+	}
+
+	h := models.Highlight{Field: field, Text: string(runes[start:end])}
+	for _, s := range spans {
+		if s.Start < start || s.End > end {
+			continue
+		}
+		h.Matches = append(h.Matches, models.Span{Start: s.Start - start, End: s.End - start})
+	}
+	return h, true
+}
+
+// matchSpans finds every occurrence of terms in value and returns them as
+// rune offsets into value, sorted and without overlaps.
+func matchSpans(value string, terms []string) []models.Span {
+	var spans []models.Span
+	for _, shortDj := range []bool{false, true} {
+		folded, offsets := foldWithOffsets(value, shortDj)
+		for _, term := range terms {
+			if term == "" {
+				continue
+			}
+			from := 0
+			for {
+				i := strings.Index(folded[from:], term)
+				if i < 0 {
+					break
+				}
+				i += from
+				spans = append(spans, models.Span{
+					Start: offsets[i],
+					End:   offsets[i+len(term)-1] + 1,
+				})
+				from = i + len(term)
+			}
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		return spans[i].End > spans[j].End
+	})
+
+	merged := spans[:0]
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.Start < merged[n-1].End {
+			if s.End > merged[n-1].End {
+				merged[n-1].End = s.End
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
@@ -0,0 +1,80 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"content-service/models"
+)
+
+func TestFold(t *testing.T) {
+	cases := map[string]string{
+		"Balašević":    "balasevic",
+		"Балашевић":    "balasevic",
+		"ĐORĐE":        "djordje",
+		"Ђорђе":        "djordje",
+		"Beyoncé":      "beyonce",
+		"Motörhead":    "motorhead",
+		"Љубав и њива": "ljubav i njiva",
+	}
+	for in, want := range cases {
+		if got := Fold(in); got != want {
+			t.Errorf("Fold(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTermsDropOperators(t *testing.T) {
+	got := Terms(`-"Čola" (.*) Zdravko`)
+	want := []string{"cola", "zdravko"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Terms = %q, want %q", got, want)
+	}
+}
+
+func TestIndexTextKeepsBothDjSpellings(t *testing.T) {
+	if got, want := IndexText("Đorđe Balašević"), "djordje balasevic dorde balasevic"; got != want {
+		t.Fatalf("IndexText = %q, want %q", got, want)
+	}
+	if got, want := IndexText("Zdravko Čolić"), "zdravko colic"; got != want {
+		t.Fatalf("IndexText = %q, want %q", got, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	h, ok := Highlight("name", "Đorđe Balašević", []string{"dorde", "balasevic"})
+	if !ok {
+		t.Fatal("expected a highlight")
+	}
+	want := []models.Span{{Start: 0, End: 5}, {Start: 6, End: 15}}
+	if h.Text != "Đorđe Balašević" || !reflect.DeepEqual(h.Matches, want) {
+		t.Fatalf("Highlight = %+v, want matches %v", h, want)
+	}
+
+	if _, ok := Highlight("title", "Lepa Brena", []string{"ceca"}); ok {
+		t.Fatal("expected no highlight")
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	long := make([]rune, 0, 300)
+	for i := 0; i < 200; i++ {
+		long = append(long, 'x')
+	}
+	long = append(long, []rune(" Šabanović ")...)
+	for i := 0; i < 100; i++ {
+		long = append(long, 'y')
+	}
+
+	h, ok := Highlight("biography", string(long), []string{"sabanovic"})
+	if !ok {
+		t.Fatal("expected a highlight")
+	}
+	if n := len([]rune(h.Text)); n != 2*snippetRadius {
+		t.Fatalf("snippet length = %d, want %d", n, 2*snippetRadius)
+	}
+	m := h.Matches[0]
+	if got := string([]rune(h.Text)[m.Start:m.End]); got != "Šabanović" {
+		t.Fatalf("highlighted %q, want %q", got, "Šabanović")
+	}
+}
@@ -0,0 +1,167 @@
+// Package storage keeps the service's files, such as song audio, images and
+// HLS segments, behind BlobStore, so they can live on the local disk or in
+// an S3-compatible bucket.
+//
+// Keys are slash separated paths like "audio/<sha256>.mp3". They never start
+// with a slash and have no empty, "." or ".." segments.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// Info describes a stored blob. ContentType may be empty when the backend
+// does not keep one.
+type Info struct {
+	Key         string
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+}
+
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any blob
+	// already there. Readers never see a partially written blob.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// GetRange reads length bytes starting at offset; a negative length
+	// reads to the end of the blob.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (Info, error)
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns every blob whose key starts with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]Info, error)
+}
+
+// Config selects and configures the backend.
+type Config struct {
+	Backend string
+	Dir     string
+	S3      S3Config
+}
+
+func Open(cfg Config) (BlobStore, error) {
+	switch cfg.Backend {
+	case BackendLocal, "":
+		return NewLocalStore(cfg.Dir), nil
+	case BackendS3:
+		return NewS3Store(cfg.S3, nil)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// Location describes where blobs under prefix are kept, for logs and
+// reports.
+func (cfg Config) Location(prefix string) string {
+	if cfg.Backend == BackendS3 {
+		return "s3://" + cfg.S3.Bucket + "/" + prefix
+	}
+	return strings.TrimSuffix(cfg.Dir, "/") + "/" + prefix
+}
+
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
+
+// DeletePrefix removes every blob whose key starts with prefix.
+func DeletePrefix(ctx context.Context, store BlobStore, prefix string) error {
+	blobs, err := store.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, b := range blobs {
+		if err := store.Delete(ctx, b.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewReadSeeker returns a reader over a blob of the given size that fetches
+// only what is read, starting a new ranged read after each seek. With it
+// http.ServeContent answers range requests without downloading the whole
+// blob.
+func NewReadSeeker(ctx context.Context, store BlobStore, key string, size int64) io.ReadSeekCloser {
+	return &rangeReader{ctx: ctx, store: store, key: key, size: size}
+}
+
+type rangeReader struct {
+	ctx    context.Context
+	store  BlobStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.store.GetRange(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	if err == io.EOF && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
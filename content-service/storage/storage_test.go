@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a small in-memory stand-in for an S3-compatible server, enough
+// for the calls S3Store makes. Listings come in pages of two to exercise
+// continuation tokens.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") ||
+		r.Header.Get("X-Amz-Date") == "" || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == f.bucket && r.Method == http.MethodGet {
+		f.list(w, r)
+		return
+	}
+	key, ok := strings.CutPrefix(path, f.bucket+"/")
+	if !ok {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now()}
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			}
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		http.ServeContent(w, r, "", obj.modTime, bytes.NewReader(obj.data))
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+	end := min(start+2, len(keys))
+
+	var out listBucketResult
+	for _, k := range keys[start:end] {
+		obj := f.objects[k]
+		out.Contents = append(out.Contents, struct {
+			Key          string `xml:"Key"`
+			Size         int64  `xml:"Size"`
+			LastModified string `xml:"LastModified"`
+		}{k, int64(len(obj.data)), obj.modTime.UTC().Format(time.RFC3339)})
+	}
+	if end < len(keys) {
+		out.IsTruncated = true
+		out.NextContinuationToken = strconv.Itoa(end)
+	}
+	xml.NewEncoder(w).Encode(out)
+}
+
+func stores(t *testing.T) map[string]BlobStore {
+	server := httptest.NewServer(&fakeS3{bucket: "media", objects: map[string]fakeObject{}})
+	t.Cleanup(server.Close)
+
+	s3, err := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "media",
+		AccessKey: "test-key",
+		SecretKey: "test-secret",
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]BlobStore{
+		"local": NewLocalStore(t.TempDir()),
+		"s3":    s3,
+	}
+}
+
+func TestBlobStores(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			data := []byte("0123456789abcdef")
+
+			if _, err := store.Stat(ctx, "audio/a.mp3"); err != ErrNotFound {
+				t.Fatalf("Stat of missing blob: %v, want ErrNotFound", err)
+			}
+			for _, key := range []string{"audio/a.mp3", "audio/b.mp3", "hls/x/64k/index.m3u8", "hls/x/64k/seg_000.ts", "hls/y/64k/index.m3u8"} {
+				if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "audio/mpeg"); err != nil {
+					t.Fatalf("Put %s: %v", key, err)
+				}
+			}
+
+			info, err := store.Stat(ctx, "audio/a.mp3")
+			if err != nil || info.Size != int64(len(data)) || info.ModTime.IsZero() {
+				t.Fatalf("Stat = %+v, %v", info, err)
+			}
+
+			body, info, err := store.Get(ctx, "audio/a.mp3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := io.ReadAll(body)
+			body.Close()
+			if !bytes.Equal(got, data) || info.Size != int64(len(data)) {
+				t.Fatalf("Get = %q, %+v", got, info)
+			}
+
+			for _, rc := range []struct {
+				offset, length int64
+				want           string
+			}{{4, 3, "456"}, {10, -1, "abcdef"}, {0, 0, ""}} {
+				body, err := store.GetRange(ctx, "audio/a.mp3", rc.offset, rc.length)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, _ := io.ReadAll(body)
+				body.Close()
+				if string(got) != rc.want {
+					t.Fatalf("GetRange(%d, %d) = %q, want %q", rc.offset, rc.length, got, rc.want)
+				}
+			}
+
+			listed, err := store.List(ctx, "hls/x/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keys := infoKeys(listed); strings.Join(keys, ",") != "hls/x/64k/index.m3u8,hls/x/64k/seg_000.ts" {
+				t.Fatalf("List(hls/x/) = %v", keys)
+			}
+			listed, _ = store.List(ctx, "audio/")
+			if len(listed) != 2 {
+				t.Fatalf("List(audio/) = %v", infoKeys(listed))
+			}
+
+			if err := DeletePrefix(ctx, store, "hls/x/"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Delete(ctx, "audio/b.mp3"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Delete(ctx, "audio/b.mp3"); err != nil {
+				t.Fatalf("deleting a missing blob: %v", err)
+			}
+			listed, _ = store.List(ctx, "")
+			if keys := infoKeys(listed); strings.Join(keys, ",") != "audio/a.mp3,hls/y/64k/index.m3u8" {
+				t.Fatalf("List after deletes = %v", keys)
+			}
+			if _, _, err := store.Get(ctx, "audio/b.mp3"); err != ErrNotFound {
+				t.Fatalf("Get of deleted blob: %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestBlobStoresRejectBadKeys(t *testing.T) {
+	for name, store := range stores(t) {
+		for _, key := range []string{"", "/audio/a.mp3", "audio/../a.mp3", "audio//a.mp3", "audio/."} {
+			if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err == nil {
+				t.Errorf("%s: Put(%q) accepted", name, key)
+			}
+		}
+	}
+}
+
+func TestReadSeekerServesRanges(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			data := bytes.Repeat([]byte("0123456789"), 100)
+			if err := store.Put(ctx, "audio/long.mp3", bytes.NewReader(data), int64(len(data)), ""); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Range", "bytes=995-")
+			w := httptest.NewRecorder()
+			content := NewReadSeeker(ctx, store, "audio/long.mp3", int64(len(data)))
+			defer content.Close()
+			http.ServeContent(w, req, "long.mp3", time.Time{}, content)
+
+			if w.Code != http.StatusPartialContent || w.Body.String() != "56789" {
+				t.Fatalf("ServeContent = %d %q", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func infoKeys(blobs []Info) []string {
+	keys := make([]string, len(blobs))
+	for i, b := range blobs {
+		keys[i] = b.Key
+	}
+	return keys
+}
@@ -0,0 +1,82 @@
+// Package streamurl signs and checks short-lived audio URLs. A signed URL is
+// scoped to one song, one user and one stream session, so it can be handed
+// to an <audio> element without the user's JWT.
+package streamurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrMalformed = errors.New("malformed signed URL")
+	ErrSignature = errors.New("invalid signature")
+	ErrExpired   = errors.New("signed URL expired")
+)
+
+// Query parameters of a signed URL.
+const (
+	paramUser      = "uid"
+	paramSession   = "sid"
+	paramExpires   = "exp"
+	paramSignature = "sig"
+)
+
+// Grant is what a signed URL allows: streaming SongID as UserID within
+// Session until ExpiresAt.
+type Grant struct {
+	SongID    string
+	UserID    string
+	Session   string
+	ExpiresAt time.Time
+}
+
+// Sign returns the query parameters that carry the grant.
+func Sign(secret []byte, g Grant) url.Values {
+	exp := strconv.FormatInt(g.ExpiresAt.Unix(), 10)
+	return url.Values{
+		paramUser:      {g.UserID},
+		paramSession:   {g.Session},
+		paramExpires:   {exp},
+		paramSignature: {signature(secret, g.SongID, g.UserID, g.Session, exp)},
+	}
+}
+
+// Verify checks the signed parameters of a request for songID and returns
+// the grant they carry.
+func Verify(secret []byte, songID string, query url.Values, now time.Time) (Grant, error) {
+	userID, session := query.Get(paramUser), query.Get(paramSession)
+	exp, sig := query.Get(paramExpires), query.Get(paramSignature)
+	if userID == "" || session == "" || exp == "" || sig == "" {
+		return Grant{}, ErrMalformed
+	}
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return Grant{}, ErrMalformed
+	}
+
+	want := signature(secret, songID, userID, session, exp)
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return Grant{}, ErrSignature
+	}
+
+	g := Grant{SongID: songID, UserID: userID, Session: session, ExpiresAt: time.Unix(expUnix, 0)}
+	if !now.Before(g.ExpiresAt) {
+		return g, ErrExpired
+	}
+	return g, nil
+}
+
+func signature(secret []byte, songID, userID, session, exp string) string {
+	mac := hmac.New(sha256.New, secret)
+	for _, part := range []string{songID, userID, session, exp} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package streamurl
+
+import (
+	"testing"
+	"time"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	grant := Grant{SongID: "song", UserID: "user", Session: "sess", ExpiresAt: now.Add(time.Minute)}
+	query := Sign(secret, grant)
+
+	got, err := Verify(secret, "song", query, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UserID != "user" || got.Session != "sess" || !got.ExpiresAt.Equal(grant.ExpiresAt) {
+		t.Fatalf("Verify = %+v", got)
+	}
+
+	if _, err := Verify(secret, "song", query, now.Add(time.Minute)); err != ErrExpired {
+		t.Fatalf("at expiry: %v, want ErrExpired", err)
+	}
+	if _, err := Verify(secret, "other-song", query, now); err != ErrSignature {
+		t.Fatalf("other song: %v, want ErrSignature", err)
+	}
+	if _, err := Verify([]byte("another secret"), "song", query, now); err != ErrSignature {
+		t.Fatalf("other secret: %v, want ErrSignature", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	for param, value := range map[string]string{
+		paramUser:    "someone-else",
+		paramSession: "other",
+		paramExpires: "1900000000",
+	} {
+		query := Sign(secret, Grant{SongID: "song", UserID: "user", Session: "sess", ExpiresAt: now.Add(time.Minute)})
+		query.Set(param, value)
+		if _, err := Verify(secret, "song", query, now); err != ErrSignature {
+			t.Errorf("changed %s: %v, want ErrSignature", param, err)
+		}
+	}
+
+	query := Sign(secret, Grant{SongID: "song", UserID: "user", Session: "sess", ExpiresAt: now.Add(time.Minute)})
+	query.Del(paramSignature)
+	if _, err := Verify(secret, "song", query, now); err != ErrMalformed {
+		t.Fatalf("missing signature: %v, want ErrMalformed", err)
+	}
+}
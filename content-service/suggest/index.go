@@ -0,0 +1,97 @@
+package suggest
+
+import (
+	"sync"
+	"time"
+)
+
+// MaxResults is the most entries per type a lookup can return.
+const MaxResults = 20
+
+type addedEntry struct {
+	entry Entry
+	at    time.Time
+}
+
+// Index guards the current trie. Rebuilds swap in a new trie; entries added
+// while a rebuild was loading are carried over so they do not vanish.
+type Index struct {
+	mu     sync.RWMutex
+	trie   *Trie
+	texts  map[string]string
+	recent []addedEntry
+}
+
+var Default = NewIndex()
+
+func NewIndex() *Index {
+	return &Index{trie: NewTrie(MaxResults), texts: map[string]string{}}
+}
+
+// Replace swaps in a trie built from entries, which were loaded starting at
+// loadedAt.
+func (i *Index) Replace(entries []Entry, loadedAt time.Time) {
+	trie := NewTrie(MaxResults)
+	texts := make(map[string]string, len(entries))
+	for _, e := range entries {
+		trie.Insert(e)
+		texts[entryKey(e.Type, e.ID)] = e.Text
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var recent []addedEntry
+	for _, a := range i.recent {
+		if a.at.Before(loadedAt) {
+			continue
+		}
+		recent = append(recent, a)
+		if key := entryKey(a.entry.Type, a.entry.ID); texts[key] == "" {
+			trie.Insert(a.entry)
+			texts[key] = a.entry.Text
+		}
+	}
+	i.trie = trie
+	i.texts = texts
+	i.recent = recent
+}
+
+// Add indexes a newly created entry without waiting for the next rebuild.
+func (i *Index) Add(e Entry) {
+	if e.ID == "" || e.Text == "" {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.trie.Insert(e)
+	i.texts[entryKey(e.Type, e.ID)] = e.Text
+	i.recent = append(i.recent, addedEntry{entry: e, at: time.Now()})
+}
+
+func (i *Index) Lookup(prefix string, limit int) map[string][]Entry {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.trie.Lookup(prefix, limit)
+}
+
+func (i *Index) Size() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.texts)
+}
+
+// Text returns the indexed text of an entry, so updates that leave it
+// unchanged need no rebuild.
+func (i *Index) Text(typ, id string) (string, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	text, ok := i.texts[entryKey(typ, id)]
+	return text, ok
+}
+
+func entryKey(typ, id string) string {
+	return typ + ":" + id
+}
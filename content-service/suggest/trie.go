@@ -0,0 +1,159 @@
+// Package suggest keeps an in-memory prefix index of artist, album and song
+// titles for typeahead. Every node of the trie stores the best entries below
+// it, so a lookup only walks the prefix and never scans a subtree.
+package suggest
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"content-service/search"
+)
+
+const (
+	TypeArtist = "artist"
+	TypeAlbum  = "album"
+	TypeSong   = "song"
+
+	// maxKeyLength caps how many bytes of a title are indexed from each word
+	// on; longer prefixes are checked against the entries directly, so the
+	// nodes at that depth keep all of their entries rather than the top k.
+	maxKeyLength = 24
+)
+
+type Entry struct {
+	Type   string  `json:"type"`
+	ID     string  `json:"id"`
+	Text   string  `json:"text"`
+	Weight float64 `json:"-"`
+}
+
+type node struct {
+	children map[byte]*node
+	top      map[string][]*Entry
+}
+
+// Trie indexes entries under every word of their folded text, so "bal"
+// finds "Đorđe Balašević" as well as "Balkan". Each node keeps at most k
+// entries per type, best weight first.
+type Trie struct {
+	root *node
+	k    int
+}
+
+func NewTrie(k int) *Trie {
+	return &Trie{root: newNode(), k: k}
+}
+
+func newNode() *node {
+	return &node{children: map[byte]*node{}, top: map[string][]*Entry{}}
+}
+
+func (t *Trie) Insert(e Entry) {
+	entry := &e
+	seen := map[*node]bool{}
+	for _, key := range entryKeys(e.Text) {
+		n := t.root
+		for i := 0; i < len(key) && i < maxKeyLength; i++ {
+			child, ok := n.children[key[i]]
+			if !ok {
+				child = newNode()
+				n.children[key[i]] = child
+			}
+			n = child
+			if !seen[n] {
+				seen[n] = true
+				k := t.k
+				if i == maxKeyLength-1 {
+					k = math.MaxInt
+				}
+				n.offer(entry, k)
+			}
+		}
+	}
+}
+
+// Lookup returns up to limit entries per type whose text has a word
+// starting with prefix.
+func (t *Trie) Lookup(prefix string, limit int) map[string][]Entry {
+	results := map[string][]Entry{}
+	key := normalize(prefix)
+	if key == "" {
+		return results
+	}
+
+	n := t.root
+	for i := 0; i < len(key) && i < maxKeyLength; i++ {
+		n = n.children[key[i]]
+		if n == nil {
+			return results
+		}
+	}
+
+	for typ, entries := range n.top {
+		for _, e := range entries {
+			if len(results[typ]) == limit {
+				break
+			}
+			if len(key) > maxKeyLength && !matchesPrefix(e.Text, key) {
+				continue
+			}
+			results[typ] = append(results[typ], *e)
+		}
+	}
+	return results
+}
+
+func (n *node) offer(e *Entry, k int) {
+	list := n.top[e.Type]
+	i := sort.Search(len(list), func(i int) bool { return better(e, list[i]) })
+	if i >= k {
+		return
+	}
+	list = append(list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = e
+	if len(list) > k {
+		list = list[:k]
+	}
+	n.top[e.Type] = list
+}
+
+func better(a, b *Entry) bool {
+	if a.Weight != b.Weight {
+		return a.Weight > b.Weight
+	}
+	if a.Text != b.Text {
+		return a.Text < b.Text
+	}
+	return a.ID < b.ID
+}
+
+// entryKeys returns the folded text from the start of every word on, for
+// each spelling of the text.
+func entryKeys(text string) []string {
+	var keys []string
+	for _, variant := range search.Variants(text) {
+		words := normalize(variant)
+		for i := 0; i < len(words); i++ {
+			if i == 0 || words[i-1] == ' ' {
+				keys = append(keys, words[i:])
+			}
+		}
+	}
+	return keys
+}
+
+func matchesPrefix(text, key string) bool {
+	for _, k := range entryKeys(text) {
+		if strings.HasPrefix(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(s string) string {
+	return strings.Join(search.Terms(s), " ")
+}
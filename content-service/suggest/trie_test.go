@@ -0,0 +1,110 @@
+package suggest
+
+import (
+	"testing"
+	"time"
+)
+
+func ids(entries []Entry) []string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestTrieLookup(t *testing.T) {
+	trie := NewTrie(3)
+	trie.Insert(Entry{Type: TypeArtist, ID: "a1", Text: "Đorđe Balašević", Weight: 10})
+	trie.Insert(Entry{Type: TypeArtist, ID: "a2", Text: "Balkan Brass", Weight: 20})
+	trie.Insert(Entry{Type: TypeSong, ID: "s1", Text: "Ballada", Weight: 1})
+	trie.Insert(Entry{Type: TypeSong, ID: "s2", Text: "Bal bal bal", Weight: 2})
+
+	got := trie.Lookup("bal", 10)
+	if want := []string{"a2", "a1"}; !equal(ids(got[TypeArtist]), want) {
+		t.Errorf("artists = %v, want %v", ids(got[TypeArtist]), want)
+	}
+	if want := []string{"s2", "s1"}; !equal(ids(got[TypeSong]), want) {
+		t.Errorf("songs = %v, want %v (each entry once)", ids(got[TypeSong]), want)
+	}
+
+	for _, prefix := range []string{"djor", "Đor", "dor", "ĐORĐE BAL"} {
+		if got := ids(trie.Lookup(prefix, 10)[TypeArtist]); !equal(got, []string{"a1"}) {
+			t.Errorf("Lookup(%q) artists = %v, want [a1]", prefix, got)
+		}
+	}
+
+	if got := trie.Lookup("bal", 1); len(got[TypeArtist]) != 1 || got[TypeArtist][0].ID != "a2" {
+		t.Errorf("limit 1 = %v, want [a2]", ids(got[TypeArtist]))
+	}
+	if got := trie.Lookup("xyz", 10); len(got) != 0 {
+		t.Errorf("unknown prefix = %v, want none", got)
+	}
+}
+
+func TestTrieKeepsTopK(t *testing.T) {
+	trie := NewTrie(2)
+	trie.Insert(Entry{Type: TypeSong, ID: "low", Text: "Song", Weight: 1})
+	trie.Insert(Entry{Type: TypeSong, ID: "high", Text: "Song", Weight: 9})
+	trie.Insert(Entry{Type: TypeSong, ID: "mid", Text: "Song", Weight: 5})
+
+	if got := ids(trie.Lookup("so", 10)[TypeSong]); !equal(got, []string{"high", "mid"}) {
+		t.Fatalf("top = %v, want [high mid]", got)
+	}
+}
+
+func TestTrieLongPrefix(t *testing.T) {
+	trie := NewTrie(5)
+	trie.Insert(Entry{Type: TypeSong, ID: "s1", Text: "Once upon a time in the west theme"})
+	trie.Insert(Entry{Type: TypeSong, ID: "s2", Text: "Once upon a time in the westerns"})
+	trie.Insert(Entry{Type: TypeSong, ID: "s3", Text: "Once upon a time in the wild"})
+
+	if got := ids(trie.Lookup("once upon a time in the west t", 5)[TypeSong]); !equal(got, []string{"s1"}) {
+		t.Fatalf("long prefix = %v, want [s1]", got)
+	}
+}
+
+func TestTrieLongPrefixBeyondTopK(t *testing.T) {
+	trie := NewTrie(2)
+	for _, id := range []string{"a", "b", "c"} {
+		trie.Insert(Entry{Type: TypeSong, ID: id, Text: "Once upon a time in the east " + id, Weight: 9})
+	}
+	trie.Insert(Entry{Type: TypeSong, ID: "west", Text: "Once upon a time in the west", Weight: 1})
+
+	if got := ids(trie.Lookup("once upon a time in the west", 5)[TypeSong]); !equal(got, []string{"west"}) {
+		t.Fatalf("long prefix = %v, want [west]", got)
+	}
+}
+
+func TestIndexReplaceKeepsRecentAdds(t *testing.T) {
+	idx := NewIndex()
+	loadStarted := time.Now().Add(-time.Second)
+	idx.Add(Entry{Type: TypeArtist, ID: "new", Text: "Nova"})
+
+	idx.Replace([]Entry{{Type: TypeArtist, ID: "old", Text: "Novi Fosili"}}, loadStarted)
+
+	if got := ids(idx.Lookup("nov", 10)[TypeArtist]); !equal(got, []string{"new", "old"}) && !equal(got, []string{"old", "new"}) {
+		t.Fatalf("after replace = %v, want old and new", got)
+	}
+	if idx.Size() != 2 {
+		t.Fatalf("Size = %d, want 2", idx.Size())
+	}
+	if text, ok := idx.Text(TypeArtist, "new"); !ok || text != "Nova" {
+		t.Fatalf("Text = %q, %v, want Nova", text, ok)
+	}
+	if _, ok := idx.Text(TypeSong, "new"); ok {
+		t.Fatal("Text found an artist as a song")
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
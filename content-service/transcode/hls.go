@@ -0,0 +1,164 @@
+// Package transcode turns uploaded audio into an HLS stream: one AAC
+// rendition per bitrate, each cut into segments with its own playlist, and a
+// master playlist from which the player picks a rendition.
+package transcode
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// VariantPlaylist is the playlist of one rendition, next to its segments.
+	VariantPlaylist = "index.m3u8"
+
+	PlaylistContentType = "application/vnd.apple.mpegurl"
+	SegmentContentType  = "video/mp2t"
+
+	segmentPattern = "seg_%03d.ts"
+	// containerOverhead is added to the audio bitrate for the MPEG-TS
+	// packaging when advertising a rendition's bandwidth.
+	containerOverhead = 1.1
+)
+
+var (
+	renditionPattern = regexp.MustCompile(`^([1-9][0-9]{1,3})k$`)
+	segmentName      = regexp.MustCompile(`^seg_[0-9]{3,}\.ts$`)
+)
+
+// Options configures the encoder. Bitrates are in kbit/s.
+type Options struct {
+	FFmpeg         string
+	Bitrates       []int
+	SegmentSeconds int
+}
+
+// Ladder returns the configured bitrates that do not exceed the source,
+// lowest first, so a 96 kbit/s MP3 is not re-encoded at 192. The lowest
+// bitrate is always kept. A sourceKbps of zero means unknown and keeps all.
+func Ladder(bitrates []int, sourceKbps int) []int {
+	sorted := append([]int(nil), bitrates...)
+	sort.Ints(sorted)
+	if len(sorted) == 0 || sourceKbps <= 0 {
+		return sorted
+	}
+
+	ladder := sorted[:1]
+	for _, b := range sorted[1:] {
+		if b <= sourceKbps {
+			ladder = append(ladder, b)
+		}
+	}
+	return ladder
+}
+
+// RenditionDir is the directory, relative to the stream, holding the
+// segments and playlist of the given bitrate.
+func RenditionDir(kbps int) string {
+	return strconv.Itoa(kbps) + "k"
+}
+
+// ParseRendition is the inverse of RenditionDir.
+func ParseRendition(dir string) (int, bool) {
+	m := renditionPattern.FindStringSubmatch(dir)
+	if m == nil {
+		return 0, false
+	}
+	kbps, err := strconv.Atoi(m[1])
+	return kbps, err == nil
+}
+
+// ContentType returns the content type of a file inside a rendition
+// directory, or false for names Run never writes.
+func ContentType(name string) (string, bool) {
+	switch {
+	case name == VariantPlaylist:
+		return PlaylistContentType, true
+	case segmentName.MatchString(name):
+		return SegmentContentType, true
+	default:
+		return "", false
+	}
+}
+
+// Master builds the master playlist for the given renditions. prefix is put
+// in front of every rendition directory and depends on where the master is
+// served from.
+func Master(bitrates []int, prefix string) []byte {
+	var b bytes.Buffer
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, kbps := range bitrates {
+		bandwidth := int(float64(kbps*1000) * containerOverhead)
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"mp4a.40.2\"\n", bandwidth)
+		fmt.Fprintf(&b, "%s%s/%s\n", prefix, RenditionDir(kbps), VariantPlaylist)
+	}
+	return b.Bytes()
+}
+
+// Run encodes input into one rendition per bitrate under outDir. Everything
+// is written to a temporary directory first, which replaces outDir only when
+// every rendition succeeded, so a stream being served is never half written.
+func Run(ctx context.Context, opts Options, input, outDir string, bitrates []int) error {
+	if len(bitrates) == 0 {
+		return fmt.Errorf("no bitrates to encode")
+	}
+	if err := os.MkdirAll(filepath.Dir(outDir), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(outDir), ".transcode-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, kbps := range bitrates {
+		dir := filepath.Join(tmp, RenditionDir(kbps))
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			return err
+		}
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, opts.FFmpeg, ffmpegArgs(opts, input, dir, kbps)...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("encoding %dk: %v: %s", kbps, err, lastLine(stderr.String()))
+		}
+	}
+
+	if err := os.RemoveAll(outDir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, outDir)
+}
+
+func ffmpegArgs(opts Options, input, dir string, kbps int) []string {
+	return []string{
+		"-hide_banner", "-loglevel", "error", "-nostdin", "-y",
+		"-i", input,
+		"-map", "0:a:0", "-vn",
+		"-c:a", "aac", "-b:a", strconv.Itoa(kbps) + "k", "-ac", "2", "-ar", "44100",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(opts.SegmentSeconds),
+		"-hls_playlist_type", "vod",
+		"-hls_list_size", "0",
+		"-hls_segment_filename", filepath.Join(dir, segmentPattern),
+		filepath.Join(dir, VariantPlaylist),
+	}
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
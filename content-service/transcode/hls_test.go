@@ -0,0 +1,84 @@
+package transcode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLadderSkipsBitratesAboveSource(t *testing.T) {
+	cases := []struct {
+		source int
+		want   []int
+	}{
+		{source: 0, want: []int{64, 128, 192}},
+		{source: 1411, want: []int{64, 128, 192}},
+		{source: 160, want: []int{64, 128}},
+		{source: 32, want: []int{64}},
+	}
+	for _, tc := range cases {
+		if got := Ladder([]int{192, 64, 128}, tc.source); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Ladder(source %d) = %v, want %v", tc.source, got, tc.want)
+		}
+	}
+}
+
+func TestMasterListsRenditions(t *testing.T) {
+	got := string(Master([]int{64, 128}, "stream/"))
+	want := "#EXTM3U\n#EXT-X-VERSION:3\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=70400,CODECS=\"mp4a.40.2\"\nstream/64k/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=140800,CODECS=\"mp4a.40.2\"\nstream/128k/index.m3u8\n"
+	if got != want {
+		t.Fatalf("Master =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStreamFileNames(t *testing.T) {
+	if kbps, ok := ParseRendition(RenditionDir(128)); !ok || kbps != 128 {
+		t.Fatalf("ParseRendition(128k) = %d, %v", kbps, ok)
+	}
+	for _, dir := range []string{"", "k", "0k", "128", "../128k", "12345k"} {
+		if _, ok := ParseRendition(dir); ok {
+			t.Errorf("ParseRendition(%q) accepted", dir)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"index.m3u8":  PlaylistContentType,
+		"seg_000.ts":  SegmentContentType,
+		"seg_1234.ts": SegmentContentType,
+	} {
+		if got, ok := ContentType(name); !ok || got != want {
+			t.Errorf("ContentType(%q) = %q, %v", name, got, ok)
+		}
+	}
+	for _, name := range []string{"master.m3u8", "seg_1.ts", "../seg_000.ts", "seg_000.ts.tmp"} {
+		if _, ok := ContentType(name); ok {
+			t.Errorf("ContentType(%q) accepted", name)
+		}
+	}
+}
+
+func TestRunKeepsPreviousOutputOnFailure(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "stream")
+	if err := os.MkdirAll(filepath.Join(out, "64k"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{FFmpeg: filepath.Join(t.TempDir(), "missing-ffmpeg"), SegmentSeconds: 6}
+	err := Run(context.Background(), opts, "in.mp3", out, []int{64})
+	if err == nil || !strings.Contains(err.Error(), "encoding 64k") {
+		t.Fatalf("Run error = %v, want an encoding error", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "64k")); err != nil {
+		t.Fatalf("previous output removed: %v", err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(out))
+	if len(entries) != 1 {
+		t.Fatalf("temporary directory left behind: %v", entries)
+	}
+}
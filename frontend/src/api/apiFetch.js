@@ -65,3 +65,18 @@ export async function apiFetch(path, options = {}) {
 
   return data;
 }
+
+// apiFetchAll follows nextCursor through every page of a catalog listing and
+// returns a page holding all items, so lists are not cut off at the page size.
+export async function apiFetchAll(path, options = {}) {
+  const separator = path.includes("?") ? "&" : "?";
+  let page = await apiFetch(path, options);
+  const items = [...(page?.items || [])];
+
+  while (page?.nextCursor) {
+    page = await apiFetch(`${path}${separator}cursor=${encodeURIComponent(page.nextCursor)}`, options);
+    items.push(...(page?.items || []));
+  }
+
+  return { ...page, items, nextCursor: undefined };
+}
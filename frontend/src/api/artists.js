@@ -1,7 +1,8 @@
+import { apiFetchAll } from "./apiFetch";
+
 export async function getArtists() {
-  const response = await fetch("/api/content/artists");
-  if (!response.ok) throw new Error("Failed to fetch artists");
-  return response.json();
+  const data = await apiFetchAll("/api/content/artists?limit=100");
+  return data.items;
 }
 
 export async function getArtistById(id) {
@@ -11,9 +12,8 @@ export async function getArtistById(id) {
 }
 
 export async function getAlbumsByArtist(id) {
-  const response = await fetch(`/api/content/artists/${id}/albums`);
-  if (!response.ok) throw new Error("Failed to fetch albums");
-  return response.json();
+  const data = await apiFetchAll(`/api/content/artists/${id}/albums?limit=100`);
+  return data.items;
 }
 
 export async function createArtist(payload) {
@@ -70,6 +70,13 @@ export const contentApi = {
     });
   },
 
+  // Returns a signed URL for the song's audio. Passing the session from the
+  // previous one keeps the player within a single stream slot.
+  getStreamUrl(songId, session) {
+    const query = session ? `?session=${encodeURIComponent(session)}` : "";
+    return apiFetch(`/api/content/songs/${songId}/stream-url${query}`, { method: "POST" });
+  },
+
   getUserRating(songId) {
     return apiFetch(`/api/content/songs/${songId}/rating`, { method: "GET" });
   },
@@ -1,15 +1,15 @@
 import { useState, useEffect } from 'react';
 import { Autocomplete, TextField, Chip } from '@mui/material';
-import { apiFetch } from '../api/apiFetch';
+import { apiFetchAll } from '../api/apiFetch';
 
 export default function GenreChipInput({ value = [], onChange, label = 'Å½anrovi', placeholder = 'Izaberite ili unesite Å¾anr...' }) {
   const [options, setOptions] = useState([]);
 
   useEffect(() => {
-    apiFetch('/api/content/artists/genres')
-      .then(genres => {
-        if (Array.isArray(genres)) {
-          setOptions(genres);
+    apiFetchAll('/api/content/artists/genres?limit=100')
+      .then(data => {
+        if (Array.isArray(data?.items)) {
+          setOptions(data.items);
         }
       })
       .catch(err => console.warn('Failed to load genres:', err));
@@ -1,6 +1,6 @@
 import React, { useEffect, useMemo, useRef, useState } from "react";
 import { Link, useLocation, useNavigate, useParams } from "react-router-dom";
-import { apiFetch } from "../api/apiFetch";
+import { apiFetch, apiFetchAll } from "../api/apiFetch";
 import { contentApi } from "../api/content";
 import { useAuth } from "../auth/AuthContext";
 import SearchBar from "../components/SearchBar";
@@ -52,6 +52,7 @@ export default function AlbumDetails() {
   const [nowPlaying, setNowPlaying] = useState(null); // { id, title }
   const [audioSrc, setAudioSrc] = useState("");
   const audioRef = useRef(null);
+  const streamSessionRef = useRef("");
 
   const [userRatings, setUserRatings] = useState({});
   const [hoverRatings, setHoverRatings] = useState({});
@@ -75,7 +76,7 @@ export default function AlbumDetails() {
       }
 
       try {
-        const songsUrl = `/api/content/albums/${id}/songs${songSearch ? `?search=${encodeURIComponent(songSearch)}` : ""}`;
+        const songsUrl = `/api/content/albums/${id}/songs?limit=100${songSearch ? `&search=${encodeURIComponent(songSearch)}` : ""}`;
 
         let a = album;
         if (shouldFetchAlbum) {
@@ -84,11 +85,11 @@ export default function AlbumDetails() {
           setAlbum(a);
         }
 
-        const s = await apiFetch(songsUrl);
+        const s = await apiFetchAll(songsUrl);
 
         if (!alive) return;
 
-        const songsArray = Array.isArray(s) ? s : [];
+        const songsArray = s?.items || [];
         setSongs(songsArray);
 
         if (isAuthenticated && songsArray.length > 0) {
@@ -159,9 +160,9 @@ export default function AlbumDetails() {
 
     try {
       await contentApi.deleteSong(songId);
-      const songsUrl = `/api/content/albums/${id}/songs${songSearch ? `?search=${encodeURIComponent(songSearch)}` : ""}`;
-      const updatedSongs = await apiFetch(songsUrl);
-      setSongs(Array.isArray(updatedSongs) ? updatedSongs : []);
+      const songsUrl = `/api/content/albums/${id}/songs?limit=100${songSearch ? `&search=${encodeURIComponent(songSearch)}` : ""}`;
+      const updatedSongs = await apiFetchAll(songsUrl);
+      setSongs(updatedSongs?.items || []);
     } catch (err) {
       alert(err.message || "Failed to delete song");
     }
@@ -244,8 +245,14 @@ export default function AlbumDetails() {
     const isSame = nowPlaying?.id === songId;
 
     if (!isSame) {
-      setNowPlaying({ id: songId, title: song.title || song.name || `Track ${songId}` });
-      setAudioSrc(`/api/content/songs/${songId}/audio`);
+      try {
+        const stream = await contentApi.getStreamUrl(songId, streamSessionRef.current);
+        streamSessionRef.current = stream.session;
+        setNowPlaying({ id: songId, title: song.title || song.name || `Track ${songId}` });
+        setAudioSrc(stream.url);
+      } catch (e) {
+        setAudioErr(e.message || "Failed to start playback.");
+      }
       return;
     }
 
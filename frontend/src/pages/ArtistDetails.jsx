@@ -1,6 +1,6 @@
 import React, { useEffect, useState } from "react";
 import { Link, useNavigate, useParams } from "react-router-dom";
-import { apiFetch } from "../api/apiFetch";
+import { apiFetch, apiFetchAll } from "../api/apiFetch";
 import { contentApi } from "../api/content";
 import { useAuth } from "../auth/AuthContext";
 import SearchBar from "../components/SearchBar";
@@ -46,7 +46,7 @@ export default function ArtistDetails() {
       }
 
       try {
-        const albumsUrl = `/api/content/artists/${id}/albums${albumSearch ? `?search=${encodeURIComponent(albumSearch)}` : ""}`;
+        const albumsUrl = `/api/content/artists/${id}/albums?limit=100${albumSearch ? `&search=${encodeURIComponent(albumSearch)}` : ""}`;
         let a = artist;
         if (shouldFetchArtist) {
           a = await apiFetch(`/api/content/artists/${id}`);
@@ -54,7 +54,7 @@ export default function ArtistDetails() {
           setArtist(a);
         }
 
-        const al = await apiFetch(albumsUrl);
+        const al = await apiFetchAll(albumsUrl);
 
         if (!alive) return;
         setAlbums(Array.isArray(al) ? al : al?.items || []);
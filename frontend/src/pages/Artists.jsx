@@ -1,6 +1,6 @@
 import React, { useEffect, useState } from "react";
 import { Link } from "react-router-dom";
-import { apiFetch } from "../api/apiFetch";
+import { apiFetch, apiFetchAll } from "../api/apiFetch";
 import { useAuth } from "../auth/AuthContext";
 import Navbar from "../components/Navbar";
 import SearchBar from "../components/SearchBar";
@@ -23,9 +23,9 @@ export default function Artists() {
 
     async function loadGenres() {
       try {
-        const data = await apiFetch("/api/content/artists/genres");
+        const data = await apiFetchAll("/api/content/artists/genres?limit=100");
         if (!alive) return;
-        setAvailableGenres(Array.isArray(data) ? data : []);
+        setAvailableGenres(data?.items || []);
       } catch (e) {
         console.error("Failed to load genres:", e);
       }
@@ -44,11 +44,11 @@ export default function Artists() {
       setErr("");
       setLoading(true);
       try {
-        const params = new URLSearchParams();
+        const params = new URLSearchParams({ limit: "100" });
         if (searchQuery) params.append("search", searchQuery);
         if (selectedGenres.length) params.append("genres", selectedGenres.join(","));
 
-        const url = `/api/content/artists${params.toString() ? `?${params}` : ""}`;
+        const url = `/api/content/artists?${params}`;
         const data = await apiFetch(url);
         if (!alive) return;
 
@@ -1,6 +1,6 @@
 import { useEffect, useState } from 'react';
 import { Link as RouterLink } from 'react-router-dom';
-import { apiFetch } from '../api/apiFetch';
+import { apiFetchAll } from '../api/apiFetch';
 import { contentApi } from '../api/content';
 import { useAuth } from '../auth/AuthContext';
 import { getGenreGradient, getGenreIcon } from '../utils/genreHelpers';
@@ -32,11 +32,11 @@ export default function Genres() {
     async function load() {
       try {
         const [genresData, artistsData] = await Promise.all([
-          apiFetch('/api/content/artists/genres'),
-          apiFetch('/api/content/artists'),
+          apiFetchAll('/api/content/artists/genres?limit=100'),
+          apiFetchAll('/api/content/artists?limit=100'),
         ]);
-        setGenres(Array.isArray(genresData) ? genresData : []);
-        setArtists(Array.isArray(artistsData) ? artistsData : []);
+        setGenres(genresData?.items || []);
+        setArtists(artistsData?.items || []);
 
         if (isAuthenticated) {
           const subs = await contentApi.getUserGenreSubscriptions();
@@ -1,7 +1,7 @@
 import { useState, useEffect, useRef, useCallback } from 'react';
 import { Link as RouterLink } from 'react-router-dom';
 import { useAuth } from '../auth/AuthContext';
-import { apiFetch } from '../api/apiFetch';
+import { apiFetch, apiFetchAll } from '../api/apiFetch';
 import { contentApi } from '../api/content';
 import { theme } from '../theme';
 import { getGenreGradient, getGenreIcon } from '../utils/genreHelpers';
@@ -276,10 +276,12 @@ export default function Home() {
 
   async function loadHomeData() {
     try {
-      const [artists, genres] = await Promise.all([
-        apiFetch('/api/content/artists'),
-        apiFetch('/api/content/artists/genres'),
+      const [artistsPage, genresPage] = await Promise.all([
+        apiFetchAll('/api/content/artists?limit=100'),
+        apiFetchAll('/api/content/artists/genres?limit=100'),
       ]);
+      const artists = artistsPage?.items || [];
+      const genres = genresPage?.items || [];
 
       setFeaturedArtists(artists.slice(0, 6));
 
@@ -309,17 +311,16 @@ export default function Home() {
         try {
           const artistId = artist.id || artist._id;
           if (artistId) {
-            const albums = await apiFetch(`/api/content/artists/${artistId}/albums`);
+            const albumsPage = await apiFetchAll(`/api/content/artists/${artistId}/albums?limit=100`);
+            const albums = albumsPage?.items;
             if (Array.isArray(albums)) {
               totalAlbums += albums.length;
               for (const album of albums) {
                 try {
                   const albumId = album.id || album._id;
                   if (albumId) {
-                    const songs = await apiFetch(`/api/content/albums/${albumId}/songs`);
-                    if (Array.isArray(songs)) {
-                      totalSongs += songs.length;
-                    }
+                    const songsPage = await apiFetch(`/api/content/albums/${albumId}/songs?limit=1&fields=id&includeTotal=true`);
+                    totalSongs += songsPage?.total || 0;
                   }
                 } catch (err) {
                   console.warn(`Failed to fetch songs for album ${album.title}:`, err);
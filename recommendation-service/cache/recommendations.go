@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"recommendation-service/models"
+)
+
+// Recommendations caches the candidates of each user's recommendation sections.
+// Entries are dropped when an event concerns the user or one of the songs,
+// albums or artists in their cached results. Until Init is called every
+// lookup is a miss.
+var Recommendations = New(noopStore{}, 0)
+
+func Init(size int, ttl time.Duration) {
+	if size <= 0 || ttl <= 0 {
+		Recommendations = New(noopStore{}, 0)
+		return
+	}
+	Recommendations = New(NewLRU(size), ttl)
+}
+
+type Stats struct {
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hitRatio"`
+	Invalidations uint64  `json:"invalidations"`
+	Entries       int     `json:"entries"`
+}
+
+type RecommendationCache struct {
+	store Store
+	ttl   time.Duration
+
+	mu sync.Mutex
+	// userKeys and userItems remember what was cached for a user, itemUsers
+	// which users have an item in their cached results.
+	userKeys  map[string]map[string]struct{}
+	userItems map[string]map[string]struct{}
+	itemUsers map[string]map[string]struct{}
+	// userSeen is the last time something was cached for a user. Once it is
+	// older than the TTL all of the user's entries have expired and the user
+	// can be dropped from the index.
+	userSeen map[string]time.Time
+
+	// version changes on every invalidation request, even when nothing was
+	// cached for it, so an in-flight query never stores a stale result.
+	version atomic.Uint64
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+func New(store Store, ttl time.Duration) *RecommendationCache {
+	return &RecommendationCache{
+		store:     store,
+		ttl:       ttl,
+		userKeys:  make(map[string]map[string]struct{}),
+		userItems: make(map[string]map[string]struct{}),
+		itemUsers: make(map[string]map[string]struct{}),
+		userSeen:  make(map[string]time.Time),
+	}
+}
+
+func (c *RecommendationCache) Get(userID, section string) ([]models.SongCandidate, bool) {
+	raw, ok := c.store.Get(entryKey(userID, section))
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	var candidates []models.SongCandidate
+	if err := json.Unmarshal(raw, &candidates); err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	return candidates, true
+}
+
+// Version must be read before the candidates are queried and passed to Set,
+// so results computed while an invalidation happened are not stored.
+func (c *RecommendationCache) Version() uint64 {
+	return c.version.Load()
+}
+
+func (c *RecommendationCache) Set(userID, section string, version uint64, candidates []models.SongCandidate) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	raw, err := json.Marshal(candidates)
+	if err != nil {
+		return
+	}
+
+	key := entryKey(userID, section)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.version.Load() != version {
+		return
+	}
+
+	now := time.Now()
+	if len(c.userSeen) > 2*c.store.Len()+64 {
+		c.sweepLocked(now)
+	}
+	c.userSeen[userID] = now
+
+	addToIndex(c.userKeys, userID, key)
+	for _, cand := range candidates {
+		for _, item := range candidateItems(cand) {
+			addToIndex(c.userItems, userID, item)
+			addToIndex(c.itemUsers, item, userID)
+		}
+	}
+	c.store.Set(key, raw, c.ttl)
+}
+
+func (c *RecommendationCache) InvalidateUser(userID string) {
+	if userID == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version.Add(1)
+	c.invalidateUserLocked(userID)
+}
+
+func (c *RecommendationCache) InvalidateSong(songID string) {
+	c.invalidateItem("song:" + songID)
+}
+
+func (c *RecommendationCache) InvalidateAlbum(albumID string) {
+	c.invalidateItem("album:" + albumID)
+}
+
+func (c *RecommendationCache) InvalidateArtist(artistID string) {
+	c.invalidateItem("artist:" + artistID)
+}
+
+func (c *RecommendationCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version.Add(1)
+
+	c.store.Purge()
+	c.userKeys = make(map[string]map[string]struct{})
+	c.userItems = make(map[string]map[string]struct{})
+	c.itemUsers = make(map[string]map[string]struct{})
+	c.userSeen = make(map[string]time.Time)
+	c.invalidations.Add(1)
+}
+
+func (c *RecommendationCache) Stats() Stats {
+	hits := c.hits.Load()
+	misses := c.misses.Load()
+
+	ratio := 0.0
+	if hits+misses > 0 {
+		ratio = float64(hits) / float64(hits+misses)
+	}
+
+	return Stats{
+		Hits:          hits,
+		Misses:        misses,
+		HitRatio:      ratio,
+		Invalidations: c.invalidations.Load(),
+		Entries:       c.store.Len(),
+	}
+}
+
+func (c *RecommendationCache) invalidateItem(item string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version.Add(1)
+
+	for userID := range c.itemUsers[item] {
+		c.invalidateUserLocked(userID)
+	}
+}
+
+func (c *RecommendationCache) invalidateUserLocked(userID string) {
+	keys, ok := c.userKeys[userID]
+	if !ok {
+		return
+	}
+
+	for key := range keys {
+		c.store.Delete(key)
+	}
+	c.forgetUserLocked(userID)
+	c.invalidations.Add(1)
+}
+
+func (c *RecommendationCache) sweepLocked(now time.Time) {
+	for userID, seen := range c.userSeen {
+		if now.Sub(seen) > c.ttl {
+			c.forgetUserLocked(userID)
+		}
+	}
+}
+
+func (c *RecommendationCache) forgetUserLocked(userID string) {
+	for item := range c.userItems[userID] {
+		users := c.itemUsers[item]
+		delete(users, userID)
+		if len(users) == 0 {
+			delete(c.itemUsers, item)
+		}
+	}
+	delete(c.userKeys, userID)
+	delete(c.userItems, userID)
+	delete(c.userSeen, userID)
+}
+
+func entryKey(userID, section string) string {
+	return "recommendations:" + userID + ":" + section
+}
+
+func candidateItems(c models.SongCandidate) []string {
+	items := []string{"song:" + c.ID}
+	if c.AlbumID != "" {
+		items = append(items, "album:"+c.AlbumID)
+	}
+	if c.ArtistID != "" {
+		items = append(items, "artist:"+c.ArtistID)
+	}
+	return items
+}
+
+func addToIndex(index map[string]map[string]struct{}, key, value string) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[string]struct{})
+		index[key] = set
+	}
+	set[value] = struct{}{}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"recommendation-service/models"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	l := NewLRU(2)
+	l.Set("a", []byte("1"), time.Minute)
+	l.Set("b", []byte("2"), time.Minute)
+	l.Get("a")
+	l.Set("c", []byte("3"), time.Minute)
+
+	if _, ok := l.Get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	if _, ok := l.Get("a"); !ok {
+		t.Fatal("expected a to be kept")
+	}
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	now := time.Now()
+	l := NewLRU(2)
+	l.now = func() time.Time { return now }
+	l.Set("a", []byte("1"), time.Second)
+
+	now = now.Add(2 * time.Second)
+	if _, ok := l.Get("a"); ok {
+		t.Fatal("expected a to be expired")
+	}
+}
+
+func TestInvalidateSongDropsAffectedUsers(t *testing.T) {
+	c := New(NewLRU(10), time.Minute)
+	c.Set("u1", "discoverNewSongs", c.Version(), []models.SongCandidate{{ID: "s1"}})
+	c.Set("u2", "discoverNewSongs", c.Version(), []models.SongCandidate{{ID: "s2"}})
+
+	c.InvalidateSong("s1")
+
+	if _, ok := c.Get("u1", "discoverNewSongs"); ok {
+		t.Fatal("expected u1 to be invalidated")
+	}
+	if _, ok := c.Get("u2", "discoverNewSongs"); !ok {
+		t.Fatal("expected u2 to stay cached")
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Invalidations != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSetSkipsResultsComputedDuringInvalidation(t *testing.T) {
+	c := New(NewLRU(10), time.Minute)
+	version := c.Version()
+	c.InvalidateUser("u1")
+	c.Set("u1", "discoverNewSongs", version, []models.SongCandidate{{ID: "s1"}})
+
+	if _, ok := c.Get("u1", "discoverNewSongs"); ok {
+		t.Fatal("expected stale result not to be cached")
+	}
+}
@@ -0,0 +1,21 @@
+package cache
+
+import "time"
+
+// Store holds serialized cache entries. The in-memory LRU is used by default;
+// an external store only has to implement these methods to replace it.
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(key string)
+	Purge()
+	Len() int
+}
+
+type noopStore struct{}
+
+func (noopStore) Get(string) ([]byte, bool)         { return nil, false }
+func (noopStore) Set(string, []byte, time.Duration) {}
+func (noopStore) Delete(string)                     {}
+func (noopStore) Purge()                            {}
+func (noopStore) Len() int                          { return 0 }
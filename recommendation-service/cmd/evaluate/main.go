@@ -0,0 +1,274 @@
+// Command evaluate measures the recommendation sections offline. It loads the
+// current content_db snapshot into the given Neo4j instance, removes a
+// held-out share of every user's ratings from the graph, asks each section for
+// recommendations and prints precision@k, recall@k, coverage and novelty as
+// JSON. The graph is synced again afterwards so the held-out ratings return.
+//
+// Point -neo4j-uri at a dedicated test instance: while the command runs the
+// graph is missing the held-out ratings.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"recommendation-service/config"
+	"recommendation-service/db"
+	"recommendation-service/evaluation"
+	"recommendation-service/ranking"
+	"recommendation-service/repository"
+	"recommendation-service/sync"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type strategyReport struct {
+	Strategy string `json:"strategy"`
+	evaluation.Metrics
+}
+
+// options are the parsed command line flags.
+type options struct {
+	k, minRatings, candidates int
+	holdout                   float64
+	seed                      int64
+	cf                        repository.CollaborativeOptions
+	sections                  []string
+	out                       string
+}
+
+type report struct {
+	GeneratedAt  time.Time        `json:"generatedAt"`
+	K            int              `json:"k"`
+	Holdout      float64          `json:"holdout"`
+	MinRatings   int              `json:"minRatings"`
+	Seed         int64            `json:"seed"`
+	Ratings      int              `json:"ratings"`
+	HeldOut      int              `json:"heldOut"`
+	CatalogSize  int              `json:"catalogSize"`
+	TrainUsers   int              `json:"trainUsers"`
+	EvaluatedFor int              `json:"evaluatedUsers"`
+	Strategies   []strategyReport `json:"strategies"`
+}
+
+func main() {
+	mongoURI := flag.String("mongo-uri", envOr("MONGO_URI", "mongodb://localhost:27017"), "MongoDB URI of the snapshot")
+	dbName := flag.String("db", envOr("CONTENT_DB_NAME", "content_db"), "content database name")
+	neo4jURI := flag.String("neo4j-uri", "", "Neo4j URI of the test graph (required)")
+	neo4jUser := flag.String("neo4j-user", envOr("NEO4J_USER", "neo4j"), "Neo4j user")
+	neo4jPassword := flag.String("neo4j-password", os.Getenv("NEO4J_PASSWORD"), "Neo4j password")
+	k := flag.Int("k", 10, "length of the evaluated recommendation list")
+	holdout := flag.Float64("holdout", 0.2, "fraction of each user's ratings held out")
+	minRatings := flag.Int("min-ratings", 5, "minimum ratings for a user to be evaluated")
+	seed := flag.Int64("seed", 42, "seed of the holdout split")
+	candidates := flag.Int("candidates", 500, "candidates fetched per section")
+	neighbours := flag.Int("neighbours", 20, "collaborative filtering neighbourhood size")
+	minShared := flag.Int("min-shared", 2, "collaborative filtering minimum shared ratings")
+	similarity := flag.String("similarity", "pearson", "collaborative filtering similarity: pearson or cosine")
+	strategies := flag.String("strategies", strings.Join(ranking.Sections, ","), "comma separated sections to evaluate")
+	out := flag.String("out", "", "write the report to this file instead of stdout")
+	flag.Parse()
+
+	if *neo4jURI == "" {
+		log.Fatal("-neo4j-uri is required")
+	}
+	if *k < 1 || *holdout <= 0 || *holdout >= 1 || *minRatings < 2 {
+		log.Fatal("-k must be positive, -holdout in (0, 1) and -min-ratings at least 2")
+	}
+	sections := strings.Split(*strategies, ",")
+	for i, s := range sections {
+		sections[i] = strings.TrimSpace(s)
+		if !ranking.IsSection(sections[i]) {
+			log.Fatalf("unknown strategy %q", sections[i])
+		}
+	}
+
+	config.MongoURI = *mongoURI
+	config.ContentDBName = *dbName
+	config.Neo4jURI = *neo4jURI
+	config.Neo4jUser = *neo4jUser
+	config.Neo4jPassword = *neo4jPassword
+
+	opts := options{
+		k:          *k,
+		minRatings: *minRatings,
+		candidates: *candidates,
+		holdout:    *holdout,
+		seed:       *seed,
+		cf: repository.CollaborativeOptions{
+			Neighbours:       *neighbours,
+			MinSharedRatings: *minShared,
+			Centered:         *similarity == "pearson",
+		},
+		sections: sections,
+		out:      *out,
+	}
+	if err := run(opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run evaluates the sections and writes the report. Once the held-out
+// ratings are removed every failure is returned rather than exiting, so the
+// deferred sync puts them back.
+func run(opts options) error {
+	// The sync and db packages log to stdout; keep it clean for the report.
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+
+	db.ConnectMongo()
+	db.ConnectNeo4j()
+	defer db.CloseNeo4j()
+
+	ctx := context.Background()
+
+	sync.SyncAll()
+	defer func() {
+		fmt.Fprintln(os.Stderr, "Restoring held-out ratings")
+		sync.SyncAll()
+	}()
+
+	ratings, err := loadRatings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load ratings: %w", err)
+	}
+	// Trashed songs are not in the graph and can never be recommended.
+	catalogSize, err := db.SongsCollection.CountDocuments(ctx, bson.M{"deletedAt": bson.M{"$exists": false}})
+	if err != nil {
+		return fmt.Errorf("failed to count songs: %w", err)
+	}
+
+	split := evaluation.SplitRatings(ratings, opts.holdout, opts.minRatings, opts.seed)
+	if err := removeHeldOut(ctx, split.HeldOut); err != nil {
+		return fmt.Errorf("failed to remove held-out ratings: %w", err)
+	}
+
+	popularity := map[string]int{}
+	trainUsers := map[string]struct{}{}
+	for _, r := range split.Train {
+		popularity[r.SongID]++
+		trainUsers[r.UserID] = struct{}{}
+	}
+
+	users := make([]string, 0, len(split.Relevant))
+	for u := range split.Relevant {
+		users = append(users, u)
+	}
+	sort.Strings(users)
+
+	now := time.Now()
+
+	rep := report{
+		GeneratedAt:  now,
+		K:            opts.k,
+		Holdout:      opts.holdout,
+		MinRatings:   opts.minRatings,
+		Seed:         opts.seed,
+		Ratings:      len(ratings),
+		HeldOut:      len(split.HeldOut),
+		CatalogSize:  int(catalogSize),
+		TrainUsers:   len(trainUsers),
+		EvaluatedFor: len(users),
+	}
+
+	for _, section := range opts.sections {
+		recommended := map[string][]string{}
+		for _, u := range users {
+			cands, err := repository.GetSectionCandidates(ctx, section, u, opts.candidates, opts.cf)
+			if err != nil {
+				return fmt.Errorf("failed to get %s for user %s: %w", section, u, err)
+			}
+			for i, song := range ranking.Rank(section, cands, now) {
+				if i == opts.k {
+					break
+				}
+				recommended[u] = append(recommended[u], song.ID)
+			}
+		}
+
+		rep.Strategies = append(rep.Strategies, strategyReport{
+			Strategy: section,
+			Metrics:  evaluation.Evaluate(recommended, split.Relevant, opts.k, int(catalogSize), popularity, len(trainUsers)),
+		})
+	}
+
+	target := stdout
+	if opts.out != "" {
+		f, err := os.Create(opts.out)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer f.Close()
+		target = f
+	}
+
+	enc := json.NewEncoder(target)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rep); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+func loadRatings(ctx context.Context) ([]evaluation.Rating, error) {
+	cursor, err := db.UserRatingsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ratings []evaluation.Rating
+	for cursor.Next(ctx) {
+		var doc struct {
+			UserID primitive.ObjectID `bson:"userId"`
+			SongID primitive.ObjectID `bson:"songId"`
+			Rating int                `bson:"rating"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, evaluation.Rating{
+			UserID: doc.UserID.Hex(),
+			SongID: doc.SongID.Hex(),
+			Rating: doc.Rating,
+		})
+	}
+	return ratings, cursor.Err()
+}
+
+func removeHeldOut(ctx context.Context, heldOut []evaluation.Rating) error {
+	pairs := make([]map[string]interface{}, 0, len(heldOut))
+	for _, r := range heldOut {
+		pairs = append(pairs, map[string]interface{}{"userId": r.UserID, "songId": r.SongID})
+	}
+
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, `
+		UNWIND $pairs AS p
+		MATCH (:User {mongoId: p.userId})-[r:RATED]->(:Song {mongoId: p.songId})
+		DELETE r
+	`, map[string]interface{}{"pairs": pairs})
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume(ctx)
+	return err
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
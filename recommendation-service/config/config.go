@@ -20,6 +20,17 @@ var (
 	Neo4jURI           string
 	Neo4jUser          string
 	Neo4jPassword      string
+
+	SyncReconcileInterval time.Duration
+
+	CFNeighbourhoodSize int
+	CFMinSharedRatings  int
+	CFSimilarity        string
+
+	RecommendationCandidateLimit int
+
+	RecommendationCacheSize int
+	RecommendationCacheTTL  time.Duration
 )
 
 func LoadConfig() {
@@ -58,6 +69,35 @@ func LoadConfig() {
 		log.Fatal("NEO4J_PASSWORD environment variable is required")
 	}
 
+	SyncReconcileInterval, err = time.ParseDuration(getEnv("SYNC_RECONCILE_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatal("Invalid SYNC_RECONCILE_INTERVAL format:", err)
+	}
+
+	CFNeighbourhoodSize = getEnvAsInt("CF_NEIGHBOURHOOD_SIZE", 20)
+	if CFNeighbourhoodSize < 1 {
+		log.Fatal("CF_NEIGHBOURHOOD_SIZE must be at least 1")
+	}
+	CFMinSharedRatings = getEnvAsInt("CF_MIN_SHARED_RATINGS", 2)
+	if CFMinSharedRatings < 1 {
+		log.Fatal("CF_MIN_SHARED_RATINGS must be at least 1")
+	}
+	CFSimilarity = getEnv("CF_SIMILARITY", "pearson")
+	if CFSimilarity != "pearson" && CFSimilarity != "cosine" {
+		log.Fatal("CF_SIMILARITY must be either pearson or cosine")
+	}
+
+	RecommendationCandidateLimit = getEnvAsInt("RECOMMENDATION_CANDIDATE_LIMIT", 500)
+	if RecommendationCandidateLimit < 1 {
+		log.Fatal("RECOMMENDATION_CANDIDATE_LIMIT must be at least 1")
+	}
+
+	RecommendationCacheSize = getEnvAsInt("RECOMMENDATION_CACHE_SIZE", 1000)
+	RecommendationCacheTTL, err = time.ParseDuration(getEnv("RECOMMENDATION_CACHE_TTL", "5m"))
+	if err != nil {
+		log.Fatal("Invalid RECOMMENDATION_CACHE_TTL format:", err)
+	}
+
 	Port = getEnv("PORT", "8004")
 
 	log.Println("Configuration loaded successfully")
@@ -17,6 +17,8 @@ var AlbumsCollection *mongo.Collection
 var ArtistsCollection *mongo.Collection
 var UserRatingsCollection *mongo.Collection
 var GenreSubscriptionsCollection *mongo.Collection
+var ArtistSubscriptionsCollection *mongo.Collection
+var SongPlaysCollection *mongo.Collection
 
 func ConnectMongo() {
 	const maxAttempts = 20
@@ -44,6 +46,8 @@ func ConnectMongo() {
 				ArtistsCollection = db.Collection("artists")
 				UserRatingsCollection = db.Collection("user_ratings")
 				GenreSubscriptionsCollection = db.Collection("genre_subscriptions")
+				ArtistSubscriptionsCollection = db.Collection("artist_subscriptions")
+				SongPlaysCollection = db.Collection("song_plays")
 
 				fmt.Printf("Connected to MongoDB (recommendation-service) after %d attempt(s)\n", attempt)
 				return
@@ -0,0 +1,152 @@
+// Package evaluation splits user ratings into training and held-out sets and
+// scores recommendation lists against the held-out ratings.
+package evaluation
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// RelevantRating is the lowest held-out rating that counts as a hit.
+const RelevantRating = 4
+
+type Rating struct {
+	UserID string `json:"userId"`
+	SongID string `json:"songId"`
+	Rating int    `json:"rating"`
+}
+
+type Split struct {
+	Train   []Rating
+	HeldOut []Rating
+	// Relevant maps each evaluated user to the held-out songs they rated at
+	// least RelevantRating.
+	Relevant map[string]map[string]struct{}
+}
+
+// SplitRatings holds out fraction of the ratings of every user who has at
+// least minRatings of them. The same seed always produces the same split.
+func SplitRatings(ratings []Rating, fraction float64, minRatings int, seed int64) Split {
+	byUser := map[string][]Rating{}
+	for _, r := range ratings {
+		byUser[r.UserID] = append(byUser[r.UserID], r)
+	}
+
+	users := make([]string, 0, len(byUser))
+	for u := range byUser {
+		users = append(users, u)
+	}
+	sort.Strings(users)
+
+	rng := rand.New(rand.NewSource(seed))
+	split := Split{Relevant: map[string]map[string]struct{}{}}
+
+	for _, u := range users {
+		userRatings := byUser[u]
+		sort.Slice(userRatings, func(i, j int) bool { return userRatings[i].SongID < userRatings[j].SongID })
+
+		if len(userRatings) < minRatings {
+			split.Train = append(split.Train, userRatings...)
+			continue
+		}
+
+		rng.Shuffle(len(userRatings), func(i, j int) {
+			userRatings[i], userRatings[j] = userRatings[j], userRatings[i]
+		})
+
+		holdout := int(math.Round(fraction * float64(len(userRatings))))
+		if holdout < 1 {
+			holdout = 1
+		}
+		if holdout >= len(userRatings) {
+			holdout = len(userRatings) - 1
+		}
+
+		split.HeldOut = append(split.HeldOut, userRatings[:holdout]...)
+		split.Train = append(split.Train, userRatings[holdout:]...)
+
+		for _, r := range userRatings[:holdout] {
+			if r.Rating < RelevantRating {
+				continue
+			}
+			if split.Relevant[u] == nil {
+				split.Relevant[u] = map[string]struct{}{}
+			}
+			split.Relevant[u][r.SongID] = struct{}{}
+		}
+	}
+
+	return split
+}
+
+type Metrics struct {
+	PrecisionAtK float64 `json:"precisionAtK"`
+	RecallAtK    float64 `json:"recallAtK"`
+	// Coverage is the share of the catalogue recommended to at least one user.
+	Coverage float64 `json:"coverage"`
+	// Novelty is the mean self-information, -log2 of the share of training
+	// users who rated the item, of the recommended songs.
+	Novelty float64 `json:"novelty"`
+	// UsersEvaluated is the number of users with held-out relevant songs,
+	// UsersWithResults those of them who got a non-empty list.
+	UsersEvaluated   int `json:"usersEvaluated"`
+	UsersWithResults int `json:"usersWithResults"`
+}
+
+// Evaluate scores the top k songs recommended to each user. popularity is the
+// number of training ratings per song and trainUsers the number of users in
+// the training set.
+func Evaluate(recommended map[string][]string, relevant map[string]map[string]struct{}, k int, catalogSize int, popularity map[string]int, trainUsers int) Metrics {
+	var m Metrics
+	distinct := map[string]struct{}{}
+	noveltySum := 0.0
+	noveltyItems := 0
+
+	for user, rel := range relevant {
+		if len(rel) == 0 {
+			continue
+		}
+		m.UsersEvaluated++
+
+		list := recommended[user]
+		if len(list) > k {
+			list = list[:k]
+		}
+		if len(list) > 0 {
+			m.UsersWithResults++
+		}
+
+		hits := 0
+		for _, songID := range list {
+			if _, ok := rel[songID]; ok {
+				hits++
+			}
+			distinct[songID] = struct{}{}
+
+			p := float64(popularity[songID]+1) / float64(trainUsers+1)
+			noveltySum += -math.Log2(p)
+			noveltyItems++
+		}
+
+		m.PrecisionAtK += float64(hits) / float64(k)
+		m.RecallAtK += float64(hits) / float64(len(rel))
+	}
+
+	if m.UsersEvaluated > 0 {
+		m.PrecisionAtK = round(m.PrecisionAtK / float64(m.UsersEvaluated))
+		m.RecallAtK = round(m.RecallAtK / float64(m.UsersEvaluated))
+	}
+	if catalogSize > 0 {
+		m.Coverage = round(float64(len(distinct)) / float64(catalogSize))
+	}
+	if noveltyItems > 0 {
+		m.Novelty = round(noveltySum / float64(noveltyItems))
+	}
+
+	return m
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
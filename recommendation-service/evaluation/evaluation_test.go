@@ -0,0 +1,44 @@
+package evaluation
+
+import "testing"
+
+func TestSplitRatingsIsDeterministic(t *testing.T) {
+	var ratings []Rating
+	for _, song := range []string{"s1", "s2", "s3", "s4", "s5"} {
+		ratings = append(ratings, Rating{UserID: "u1", SongID: song, Rating: 5})
+	}
+	ratings = append(ratings, Rating{UserID: "u2", SongID: "s1", Rating: 5})
+
+	a := SplitRatings(ratings, 0.4, 3, 7)
+	b := SplitRatings(ratings, 0.4, 3, 7)
+
+	if len(a.HeldOut) != 2 || len(a.Train) != 4 {
+		t.Fatalf("unexpected split sizes: %d held out, %d train", len(a.HeldOut), len(a.Train))
+	}
+	for i := range a.HeldOut {
+		if a.HeldOut[i] != b.HeldOut[i] {
+			t.Fatalf("split differs for the same seed: %v vs %v", a.HeldOut, b.HeldOut)
+		}
+	}
+	if _, ok := a.Relevant["u2"]; ok {
+		t.Fatal("users below minRatings must not be evaluated")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	relevant := map[string]map[string]struct{}{
+		"u1": {"s1": {}, "s2": {}},
+	}
+	recommended := map[string][]string{
+		"u1": {"s1", "s3"},
+	}
+
+	m := Evaluate(recommended, relevant, 2, 4, map[string]int{"s1": 1}, 3)
+
+	if m.PrecisionAtK != 0.5 || m.RecallAtK != 0.5 || m.Coverage != 0.5 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+	if m.UsersEvaluated != 1 || m.UsersWithResults != 1 {
+		t.Fatalf("unexpected user counts %+v", m)
+	}
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"recommendation-service/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, cache.Recommendations.Stats())
+}
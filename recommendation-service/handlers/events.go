@@ -21,7 +21,15 @@ func HandleAsyncEvent(c *gin.Context) {
 		return
 	}
 
-	sync.TriggerAsyncRefresh()
+	if !sync.IsSupportedEvent(req.Type) {
+		if Logger != nil {
+			Logger.Application.Debug().Str("event_type", req.Type).Msg("Ignoring unsupported async event")
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "Event ignored"})
+		return
+	}
+
+	sync.EnqueueEvent(sync.Event{Type: req.Type, Data: req.Data})
 
 	if Logger != nil {
 		Logger.Application.Info().
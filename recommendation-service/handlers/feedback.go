@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"recommendation-service/cache"
+	"recommendation-service/models"
+	"recommendation-service/repository"
+	"shared-utils/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AddFeedback(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !validateFeedbackRequest(c, &req) {
+		return
+	}
+
+	stored, err := repository.AddFeedback(c.Request.Context(), userID, req)
+	if err != nil {
+		Logger.Application.Error().Err(err).Str("type", req.Type).Str("id", req.ID).Msg("Failed to store feedback")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store feedback"})
+		return
+	}
+	if !stored {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+
+	cache.Recommendations.InvalidateUser(userID)
+	c.JSON(http.StatusCreated, gin.H{"message": "Feedback saved"})
+}
+
+// RemoveFeedback accepts the item either as a JSON body or as type and id
+// query parameters.
+func RemoveFeedback(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.FeedbackRequest
+	if c.Query("type") != "" || c.Query("id") != "" {
+		req.Type = c.Query("type")
+		req.ID = c.Query("id")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !validateFeedbackRequest(c, &req) {
+		return
+	}
+
+	removed, err := repository.RemoveFeedback(c.Request.Context(), userID, req)
+	if err != nil {
+		Logger.Application.Error().Err(err).Str("type", req.Type).Str("id", req.ID).Msg("Failed to remove feedback")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove feedback"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feedback not found"})
+		return
+	}
+
+	cache.Recommendations.InvalidateUser(userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Feedback removed"})
+}
+
+func GetFeedback(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	items, err := repository.GetFeedback(c.Request.Context(), userID)
+	if err != nil {
+		Logger.Application.Error().Err(err).Msg("Failed to get feedback")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func validateFeedbackRequest(c *gin.Context, req *models.FeedbackRequest) bool {
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	req.ID = strings.TrimSpace(req.ID)
+
+	if !repository.IsFeedbackType(req.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of song, artist, genre"})
+		return false
+	}
+	if req.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return false
+	}
+	if req.Type != models.FeedbackTypeGenre {
+		if err := validation.ValidateObjectIDFormat(req.ID, req.Type+" ID"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+	}
+	return true
+}
+
+func currentUserID(c *gin.Context) (string, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return "", false
+	}
+
+	userIDStr, ok := userID.(string)
+	if !ok || userIDStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return "", false
+	}
+	return userIDStr, true
+}
@@ -1,54 +1,144 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"strconv"
+	"time"
 
+	"recommendation-service/cache"
+	"recommendation-service/config"
 	"recommendation-service/models"
+	"recommendation-service/ranking"
 	"recommendation-service/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-const defaultLimit = 20
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
 
 func GetRecommendations(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	userIDStr, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(c)
+	if !ok {
 		return
 	}
 
-	userIDStr, ok := userID.(string)
-	if !ok || userIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	section := c.Query("section")
+	if section != "" && !ranking.IsSection(section) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section"})
 		return
 	}
 
+	var after *ranking.Cursor
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		cursor, err := ranking.DecodeCursor(cursorStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		if section == "" {
+			section = cursor.Section
+		} else if section != cursor.Section {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor does not belong to the requested section"})
+			return
+		}
+		after = &cursor
+	}
+
 	ctx := c.Request.Context()
 
-	subscribedSongs, err := repository.GetSubscribedGenreSongs(ctx, userIDStr, defaultLimit)
-	if err != nil {
-		Logger.Application.Error().Err(err).Msg("Failed to get subscribed genre recommendations")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+	if section != "" {
+		now := time.Now()
+		if after != nil {
+			now = after.ReferenceTime()
+		}
+
+		ranked, err := rankSection(ctx, section, userIDStr, now)
+		if err != nil {
+			Logger.Application.Error().Err(err).Str("section", section).Msg("Failed to get recommendations")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+			return
+		}
+
+		items, next := ranking.Page(section, ranked, after, now, limit)
+		c.JSON(http.StatusOK, models.RecommendationPage{
+			Section:    section,
+			Items:      items,
+			NextCursor: next,
+		})
 		return
 	}
 
-	discoverSongs, err := repository.GetDiscoverNewSongs(ctx, userIDStr, defaultLimit)
+	now := time.Now()
+	pages := make(map[string][]models.RecommendedSong, len(ranking.Sections))
+	nextCursors := map[string]string{}
+	for _, s := range ranking.Sections {
+		ranked, err := rankSection(ctx, s, userIDStr, now)
+		if err != nil {
+			Logger.Application.Error().Err(err).Str("section", s).Msg("Failed to get recommendations")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
+			return
+		}
+
+		items, next := ranking.Page(s, ranked, nil, now, limit)
+		pages[s] = items
+		if next != "" {
+			nextCursors[s] = next
+		}
+	}
+
+	c.JSON(http.StatusOK, models.RecommendationResponse{
+		SubscribedGenreSongs: pages[ranking.SectionSubscribedGenres],
+		DiscoverNewSongs:     pages[ranking.SectionDiscover],
+		FollowedArtistSongs:  pages[ranking.SectionFollowedArtists],
+		RatedAlbumSongs:      pages[ranking.SectionRatedAlbums],
+		SimilarUsersSongs:    pages[ranking.SectionSimilarUsers],
+		NextCursors:          nextCursors,
+	})
+}
+
+func rankSection(ctx context.Context, section, userID string, now time.Time) ([]models.RecommendedSong, error) {
+	if candidates, ok := cache.Recommendations.Get(userID, section); ok {
+		return ranking.Rank(section, candidates, now), nil
+	}
+	version := cache.Recommendations.Version()
+
+	candidates, err := repository.GetSectionCandidates(ctx, section, userID, config.RecommendationCandidateLimit, collaborativeOptions())
 	if err != nil {
-		Logger.Application.Error().Err(err).Msg("Failed to get discover recommendations")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get recommendations"})
-		return
+		return nil, err
 	}
+	cache.Recommendations.Set(userID, section, version, candidates)
 
-	if subscribedSongs == nil {
-		subscribedSongs = []models.RecommendedSong{}
+	return ranking.Rank(section, candidates, now), nil
+}
+
+// parseLimit reads the optional limit query parameter and responds with 400
+// when it is out of range.
+func parseLimit(c *gin.Context) (int, bool) {
+	limitStr := c.Query("limit")
+	if limitStr == "" {
+		return defaultLimit, true
 	}
-	if discoverSongs == nil {
-		discoverSongs = []models.RecommendedSong{}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > maxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return 0, false
 	}
+	return limit, true
+}
 
-	c.JSON(http.StatusOK, models.RecommendationResponse{
-		SubscribedGenreSongs: subscribedSongs,
-		DiscoverNewSongs:     discoverSongs,
-	})
+func collaborativeOptions() repository.CollaborativeOptions {
+	return repository.CollaborativeOptions{
+		Neighbours:       config.CFNeighbourhoodSize,
+		MinSharedRatings: config.CFMinSharedRatings,
+		Centered:         config.CFSimilarity == "pearson",
+	}
 }
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"recommendation-service/models"
+	"recommendation-service/repository"
+	"shared-utils/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetSimilarSongs(c *gin.Context) {
+	songID := strings.TrimSpace(c.Param("id"))
+	if err := validation.ValidateObjectIDFormat(songID, "song ID"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+
+	exists, err := repository.SongExistsByID(songID)
+	if err != nil {
+		Logger.Application.Error().Err(err).Str("song_id", songID).Msg("Failed to check song")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get similar songs"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Song not found"})
+		return
+	}
+
+	songs, err := repository.GetSimilarSongs(c.Request.Context(), songID, limit)
+	if err != nil {
+		Logger.Application.Error().Err(err).Str("song_id", songID).Msg("Failed to get similar songs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get similar songs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SimilarSongsResponse{SongID: songID, Items: songs})
+}
+
+func GetSimilarArtists(c *gin.Context) {
+	artistID := strings.TrimSpace(c.Param("id"))
+	if err := validation.ValidateObjectIDFormat(artistID, "artist ID"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+
+	exists, err := repository.ArtistExistsByID(artistID)
+	if err != nil {
+		Logger.Application.Error().Err(err).Str("artist_id", artistID).Msg("Failed to check artist")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get similar artists"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Artist not found"})
+		return
+	}
+
+	artists, err := repository.GetSimilarArtists(c.Request.Context(), artistID, limit)
+	if err != nil {
+		Logger.Application.Error().Err(err).Str("artist_id", artistID).Msg("Failed to get similar artists")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get similar artists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SimilarArtistsResponse{ArtistID: artistID, Items: artists})
+}
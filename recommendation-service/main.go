@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"recommendation-service/cache"
 	"recommendation-service/config"
 	"recommendation-service/db"
 	"recommendation-service/handlers"
@@ -41,11 +42,15 @@ func main() {
 	handlers.SetLogger(logger)
 	middleware.SetLogger(logger)
 
+	cache.Init(config.RecommendationCacheSize, config.RecommendationCacheTTL)
+
 	db.ConnectMongo()
 	db.ConnectNeo4j()
 	defer db.CloseNeo4j()
 
 	sync.SyncAll()
+	sync.StartEventWorker()
+	sync.StartReconciliation(config.SyncReconcileInterval)
 
 	r := gin.Default()
 
@@ -63,6 +68,11 @@ func main() {
 	api := r.Group("/api/recommendations")
 	{
 		api.GET("", middleware.AuthMiddleware(), handlers.GetRecommendations)
+		api.GET("/feedback", middleware.AuthMiddleware(), handlers.GetFeedback)
+		api.POST("/feedback", middleware.AuthMiddleware(), handlers.AddFeedback)
+		api.DELETE("/feedback", middleware.AuthMiddleware(), handlers.RemoveFeedback)
+		api.GET("/songs/:id/similar", handlers.GetSimilarSongs)
+		api.GET("/artists/:id/similar", handlers.GetSimilarArtists)
 	}
 
 	internal := r.Group("/api/internal")
@@ -71,6 +81,7 @@ func main() {
 		internal.POST("/events", handlers.HandleAsyncEvent)
 		internal.GET("/songs/:id/exists", handlers.InternalSongExists)
 		internal.GET("/artists/:id/exists", handlers.InternalArtistExists)
+		internal.GET("/cache/stats", handlers.GetCacheStats)
 	}
 
 	fmt.Printf("Recommendation service running on port %s\n", config.Port)
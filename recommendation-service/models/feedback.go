@@ -0,0 +1,21 @@
+package models
+
+const (
+	FeedbackTypeSong   = "song"
+	FeedbackTypeArtist = "artist"
+	FeedbackTypeGenre  = "genre"
+)
+
+// FeedbackRequest marks a song, artist or genre as "not interested". For
+// genres ID is the genre name.
+type FeedbackRequest struct {
+	Type string `json:"type" form:"type"`
+	ID   string `json:"id" form:"id"`
+}
+
+type FeedbackItem struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"createdAt"`
+}
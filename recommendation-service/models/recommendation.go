@@ -1,15 +1,71 @@
 package models
 
 type RecommendedSong struct {
-	ID       string `json:"id"`
-	Title    string `json:"title"`
-	Duration string `json:"duration"`
-	TrackNo  int    `json:"trackNo"`
-	AlbumID  string `json:"albumId"`
-	Genre    string `json:"genre"`
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Duration string   `json:"duration"`
+	TrackNo  int      `json:"trackNo"`
+	AlbumID  string   `json:"albumId"`
+	Genre    string   `json:"genre"`
+	ArtistID string   `json:"artistId,omitempty"`
+	Score    float64  `json:"score"`
+	Reasons  []string `json:"reasons"`
 }
 
 type RecommendationResponse struct {
 	SubscribedGenreSongs []RecommendedSong `json:"subscribedGenreSongs"`
 	DiscoverNewSongs     []RecommendedSong `json:"discoverNewSongs"`
+	FollowedArtistSongs  []RecommendedSong `json:"followedArtistSongs"`
+	RatedAlbumSongs      []RecommendedSong `json:"ratedAlbumSongs"`
+	SimilarUsersSongs    []RecommendedSong `json:"similarUsersSongs"`
+	NextCursors          map[string]string `json:"nextCursors"`
+}
+
+type RecommendationPage struct {
+	Section    string            `json:"section"`
+	Items      []RecommendedSong `json:"items"`
+	NextCursor string            `json:"nextCursor,omitempty"`
+}
+
+// SongCandidate is a song produced by a recommendation query together with
+// the signals used to rank it and explain why it was chosen.
+type SongCandidate struct {
+	ID            string
+	Title         string
+	Duration      string
+	TrackNo       int
+	AlbumID       string
+	ArtistID      string
+	ArtistName    string
+	Genres        []string
+	MatchedGenres []string
+	AvgRating     float64
+	RatingCount   int
+	// ListenerCount is the number of users who played the song.
+	ListenerCount int
+	CreatedAt     int64
+	// Affinity is the section specific strength of the match in [0, 1].
+	Affinity float64
+	// BasisRating is the rating that triggered the pick: the caller's rating
+	// of the album, or the neighbours' average rating of the song.
+	BasisRating float64
+	// Supporters is the number of similar users who rated the song highly.
+	Supporters int
+}
+
+type SimilarArtist struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+type SimilarSongsResponse struct {
+	SongID string            `json:"songId"`
+	Items  []RecommendedSong `json:"items"`
+}
+
+type SimilarArtistsResponse struct {
+	ArtistID string          `json:"artistId"`
+	Items    []SimilarArtist `json:"items"`
 }
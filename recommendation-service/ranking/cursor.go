@@ -0,0 +1,74 @@
+package ranking
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"recommendation-service/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks the last item of a page. It keeps the time the listing was
+// scored at so recency does not shift between pages.
+type Cursor struct {
+	Section string  `json:"s"`
+	Time    int64   `json:"t"`
+	Score   float64 `json:"sc"`
+	ID      string  `json:"id"`
+}
+
+func EncodeCursor(c Cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(value string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if !IsSection(c.Section) || c.Time <= 0 || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+func (c Cursor) ReferenceTime() time.Time {
+	return time.UnixMilli(c.Time)
+}
+
+// Page returns up to limit ranked songs that come after the cursor, and the
+// cursor of the following page if there is one. A nil cursor starts at the top.
+func Page(section string, ranked []models.RecommendedSong, after *Cursor, now time.Time, limit int) ([]models.RecommendedSong, string) {
+	start := 0
+	if after != nil {
+		start = len(ranked)
+		for i, s := range ranked {
+			if s.Score < after.Score || (s.Score == after.Score && s.ID > after.ID) {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := start + limit
+	if end >= len(ranked) {
+		return ranked[start:], ""
+	}
+
+	last := ranked[end-1]
+	next := EncodeCursor(Cursor{
+		Section: section,
+		Time:    now.UnixMilli(),
+		Score:   last.Score,
+		ID:      last.ID,
+	})
+	return ranked[start:end], next
+}
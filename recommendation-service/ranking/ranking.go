@@ -0,0 +1,198 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"recommendation-service/models"
+)
+
+const (
+	SectionSubscribedGenres = "subscribedGenreSongs"
+	SectionDiscover         = "discoverNewSongs"
+	SectionFollowedArtists  = "followedArtistSongs"
+	SectionRatedAlbums      = "ratedAlbumSongs"
+	SectionSimilarUsers     = "similarUsersSongs"
+)
+
+var Sections = []string{
+	SectionSubscribedGenres,
+	SectionDiscover,
+	SectionFollowedArtists,
+	SectionRatedAlbums,
+	SectionSimilarUsers,
+}
+
+func IsSection(name string) bool {
+	for _, s := range Sections {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Weights of the individual signals. They add up to 1 so a score is always
+// in [0, 1].
+const (
+	genreWeight      = 0.30
+	ratingWeight     = 0.25
+	recencyWeight    = 0.15
+	popularityWeight = 0.10
+	affinityWeight   = 0.20
+)
+
+const (
+	// priorRating and priorCount pull the average of rarely rated songs
+	// towards the middle of the scale so a single 5 does not dominate.
+	priorRating = 3.0
+	priorCount  = 2.0
+
+	recencyHalfLifeDays = 30.0
+	popularityCap       = 50.0
+	newReleaseDays      = 14.0
+)
+
+// Score combines the candidate's signals into a deterministic value in [0, 1].
+// now is passed in so every page of one listing is scored identically.
+func Score(c models.SongCandidate, now time.Time) float64 {
+	genre := 0.0
+	if len(c.Genres) > 0 {
+		genre = float64(len(c.MatchedGenres)) / float64(len(c.Genres))
+	}
+
+	count := float64(c.RatingCount)
+	rating := (c.AvgRating*count + priorRating*priorCount) / (count + priorCount) / 5.0
+
+	recency := 0.0
+	if c.CreatedAt > 0 {
+		recency = math.Exp(-ageDays(c.CreatedAt, now) / recencyHalfLifeDays)
+	}
+
+	// Plays are implicit feedback, so listeners count towards popularity too.
+	popularity := math.Min(1, math.Log1p(count+float64(c.ListenerCount))/math.Log1p(popularityCap))
+
+	affinity := math.Max(0, math.Min(1, c.Affinity))
+
+	score := genreWeight*genre +
+		ratingWeight*rating +
+		recencyWeight*recency +
+		popularityWeight*popularity +
+		affinityWeight*affinity
+
+	return math.Round(score*10000) / 10000
+}
+
+// Rank scores the candidates of a section and orders them by score, breaking
+// ties by song id.
+func Rank(section string, candidates []models.SongCandidate, now time.Time) []models.RecommendedSong {
+	songs := make([]models.RecommendedSong, 0, len(candidates))
+	for _, c := range candidates {
+		songs = append(songs, models.RecommendedSong{
+			ID:       c.ID,
+			Title:    c.Title,
+			Duration: c.Duration,
+			TrackNo:  c.TrackNo,
+			AlbumID:  c.AlbumID,
+			Genre:    primaryGenre(c),
+			ArtistID: c.ArtistID,
+			Score:    Score(c, now),
+			Reasons:  Reasons(section, c, now),
+		})
+	}
+
+	sort.SliceStable(songs, func(i, j int) bool {
+		if songs[i].Score != songs[j].Score {
+			return songs[i].Score > songs[j].Score
+		}
+		return songs[i].ID < songs[j].ID
+	})
+	return songs
+}
+
+// Reasons explains why the candidate was picked, starting with the reason
+// specific to its section.
+func Reasons(section string, c models.SongCandidate, now time.Time) []string {
+	reasons := []string{}
+
+	switch section {
+	case SectionSubscribedGenres:
+		for _, g := range c.MatchedGenres {
+			reasons = append(reasons, "subscribed to "+g)
+		}
+	case SectionDiscover:
+		if g := unmatchedGenre(c); g != "" {
+			reasons = append(reasons, "discover "+g)
+		}
+	case SectionFollowedArtists:
+		if c.ArtistName != "" {
+			reasons = append(reasons, "you follow "+c.ArtistName)
+		}
+	case SectionRatedAlbums:
+		if c.BasisRating > 0 {
+			reasons = append(reasons, "from an album you rated "+formatRating(c.BasisRating))
+		}
+	case SectionSimilarUsers:
+		if c.Supporters > 0 {
+			users := "similar users"
+			if c.Supporters == 1 {
+				users = "similar user"
+			}
+			reasons = append(reasons, fmt.Sprintf("rated %s by %d %s", formatRating(c.BasisRating), c.Supporters, users))
+		}
+	}
+
+	if section != SectionSubscribedGenres {
+		for _, g := range c.MatchedGenres {
+			reasons = append(reasons, "matches your "+g+" subscription")
+		}
+	}
+	if c.RatingCount >= 3 && c.AvgRating >= 4 {
+		reasons = append(reasons, fmt.Sprintf("highly rated (%s from %d ratings)", formatRating(c.AvgRating), c.RatingCount))
+	}
+	if c.CreatedAt > 0 && ageDays(c.CreatedAt, now) <= newReleaseDays {
+		reasons = append(reasons, "new release")
+	}
+
+	return reasons
+}
+
+func primaryGenre(c models.SongCandidate) string {
+	if len(c.MatchedGenres) > 0 {
+		return c.MatchedGenres[0]
+	}
+	if len(c.Genres) > 0 {
+		return c.Genres[0]
+	}
+	return ""
+}
+
+func unmatchedGenre(c models.SongCandidate) string {
+	matched := make(map[string]bool, len(c.MatchedGenres))
+	for _, g := range c.MatchedGenres {
+		matched[g] = true
+	}
+	for _, g := range c.Genres {
+		if !matched[g] {
+			return g
+		}
+	}
+	return ""
+}
+
+func ageDays(createdAtMillis int64, now time.Time) float64 {
+	age := now.Sub(time.UnixMilli(createdAtMillis)).Hours() / 24
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+func formatRating(r float64) string {
+	if r == math.Trunc(r) {
+		return fmt.Sprintf("%d", int(r))
+	}
+	return fmt.Sprintf("%.1f", r)
+}
@@ -0,0 +1,57 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+
+	"recommendation-service/models"
+)
+
+func TestRankOrdersByScoreThenID(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	candidates := []models.SongCandidate{
+		{ID: "b", Genres: []string{"Jazz"}, Affinity: 1},
+		{ID: "a", Genres: []string{"Jazz"}, Affinity: 1},
+		{ID: "c", Genres: []string{"Jazz"}, MatchedGenres: []string{"Jazz"}, Affinity: 1},
+	}
+
+	ranked := Rank(SectionSubscribedGenres, candidates, now)
+
+	want := []string{"c", "a", "b"}
+	for i, id := range want {
+		if ranked[i].ID != id {
+			t.Fatalf("position %d: got %s, want %s", i, ranked[i].ID, id)
+		}
+	}
+	if len(ranked[0].Reasons) == 0 || ranked[0].Reasons[0] != "subscribed to Jazz" {
+		t.Errorf("unexpected reasons %v", ranked[0].Reasons)
+	}
+}
+
+func TestPageFollowsCursor(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ranked := []models.RecommendedSong{
+		{ID: "a", Score: 0.9},
+		{ID: "b", Score: 0.5},
+		{ID: "c", Score: 0.5},
+		{ID: "d", Score: 0.1},
+	}
+
+	first, next := Page(SectionDiscover, ranked, nil, now, 2)
+	if len(first) != 2 || next == "" {
+		t.Fatalf("expected a full first page with a cursor, got %d items and %q", len(first), next)
+	}
+
+	cursor, err := DecodeCursor(next)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	second, next := Page(SectionDiscover, ranked, &cursor, cursor.ReferenceTime(), 2)
+	if len(second) != 2 || second[0].ID != "c" || second[1].ID != "d" || next != "" {
+		t.Fatalf("unexpected second page %v, next %q", second, next)
+	}
+
+	if _, err := DecodeCursor("not-a-cursor"); err == nil {
+		t.Error("expected an error for a malformed cursor")
+	}
+}
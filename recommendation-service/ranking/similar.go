@@ -0,0 +1,30 @@
+package ranking
+
+import (
+	"fmt"
+	"strings"
+)
+
+const maxReasonGenres = 2
+
+// SimilarityReasons explains why two songs or two artists were considered
+// similar. Listeners are users who rated (or follow) both items, likers are
+// users who rated both 4 or higher.
+func SimilarityReasons(sharedGenres []string, sharedListeners, sharedLikers int) []string {
+	reasons := []string{}
+
+	if len(sharedGenres) > 0 {
+		genres := sharedGenres
+		if len(genres) > maxReasonGenres {
+			genres = genres[:maxReasonGenres]
+		}
+		reasons = append(reasons, "also "+strings.Join(genres, ", "))
+	}
+	if sharedLikers > 0 {
+		reasons = append(reasons, fmt.Sprintf("liked by %d of the same listeners", sharedLikers))
+	} else if sharedListeners > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d shared listeners", sharedListeners))
+	}
+
+	return reasons
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"recommendation-service/models"
+)
+
+type CollaborativeOptions struct {
+	// Neighbours is the number of most similar users whose ratings are used.
+	Neighbours int
+	// MinSharedRatings is the number of songs two users must both have rated
+	// before their similarity is considered meaningful.
+	MinSharedRatings int
+	// Centered selects Pearson correlation (ratings centered on each user's
+	// mean) instead of plain cosine similarity.
+	Centered bool
+}
+
+// GetSimilarUsersCandidates returns songs rated highly by the users whose
+// rating vectors are most similar to the caller's. Affinity is the similarity
+// weighted average rating of those neighbours, scaled to [0, 1].
+func GetSimilarUsersCandidates(ctx context.Context, userID string, limit int, opts CollaborativeOptions) ([]models.SongCandidate, error) {
+	cypher := `
+		MATCH (u:User {mongoId: $userId})-[ur:RATED]->(:Song)
+		WITH u, CASE WHEN $centered THEN avg(toFloat(ur.rating)) ELSE 0.0 END AS uMean
+		MATCH (u)-[r1:RATED]->(:Song)<-[r2:RATED]-(other:User)
+		WHERE other <> u
+		WITH u, uMean, other, collect({mine: r1.rating, theirs: r2.rating}) AS pairs
+		WHERE size(pairs) >= $minShared
+		MATCH (other)-[orr:RATED]->(:Song)
+		WITH u, uMean, other, pairs,
+		     CASE WHEN $centered THEN avg(toFloat(orr.rating)) ELSE 0.0 END AS oMean
+		WITH u, other,
+		     reduce(acc = 0.0, p IN pairs | acc + (p.mine - uMean) * (p.theirs - oMean)) AS dot,
+		     reduce(acc = 0.0, p IN pairs | acc + (p.mine - uMean) ^ 2) AS mineNorm,
+		     reduce(acc = 0.0, p IN pairs | acc + (p.theirs - oMean) ^ 2) AS theirsNorm
+		WHERE mineNorm > 0 AND theirsNorm > 0
+		WITH u, other, dot / sqrt(mineNorm * theirsNorm) AS similarity
+		WHERE similarity > 0
+		ORDER BY similarity DESC, other.mongoId
+		LIMIT $neighbours
+		MATCH (other)-[r:RATED]->(s:Song)
+		WHERE r.rating >= 4 AND NOT EXISTS {
+			MATCH (u)-[:RATED|LISTENED]->(s)
+		}
+		WITH u, s,
+		     sum(similarity * r.rating) / sum(similarity) AS predicted,
+		     avg(toFloat(r.rating)) AS basisRating,
+		     count(other) AS supporters
+		WITH u, s, predicted / 5.0 AS affinity, basisRating, supporters
+	` + candidateTail
+
+	return runCandidateQuery(ctx, cypher, map[string]interface{}{
+		"userId":     userID,
+		"limit":      limit,
+		"neighbours": opts.Neighbours,
+		"minShared":  opts.MinSharedRatings,
+		"centered":   opts.Centered,
+	}, "similar users songs")
+}
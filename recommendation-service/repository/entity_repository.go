@@ -10,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// SongExistsByID reports whether the song exists and is not in the trash.
 func SongExistsByID(id string) (bool, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -19,7 +20,7 @@ func SongExistsByID(id string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	count, err := db.SongsCollection.CountDocuments(ctx, bson.M{"_id": objID})
+	count, err := db.SongsCollection.CountDocuments(ctx, bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}})
 	if err != nil {
 		return false, err
 	}
@@ -27,6 +28,7 @@ func SongExistsByID(id string) (bool, error) {
 	return count > 0, nil
 }
 
+// ArtistExistsByID reports whether the artist exists and is not in the trash.
 func ArtistExistsByID(id string) (bool, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -36,7 +38,7 @@ func ArtistExistsByID(id string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	count, err := db.ArtistsCollection.CountDocuments(ctx, bson.M{"_id": objID})
+	count, err := db.ArtistsCollection.CountDocuments(ctx, bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}})
 	if err != nil {
 		return false, err
 	}
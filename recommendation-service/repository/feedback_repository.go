@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"recommendation-service/db"
+	"recommendation-service/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Hidden songs are stored as HIDES edges, disliked artists and genres as
+// DISLIKES edges. candidateTail drops every song they cover.
+var feedbackPatterns = map[string]struct {
+	match string
+	rel   string
+}{
+	models.FeedbackTypeSong:   {match: "(t:Song {mongoId: $id})", rel: "HIDES"},
+	models.FeedbackTypeArtist: {match: "(t:Artist {mongoId: $id})", rel: "DISLIKES"},
+	models.FeedbackTypeGenre:  {match: "(t:Genre {name: $id})", rel: "DISLIKES"},
+}
+
+func IsFeedbackType(feedbackType string) bool {
+	_, ok := feedbackPatterns[feedbackType]
+	return ok
+}
+
+// AddFeedback stores the "not interested" edge. It returns false when the
+// target is not in the graph.
+func AddFeedback(ctx context.Context, userID string, req models.FeedbackRequest) (bool, error) {
+	pattern, ok := feedbackPatterns[req.Type]
+	if !ok {
+		return false, fmt.Errorf("unsupported feedback type %s", req.Type)
+	}
+
+	cypher := fmt.Sprintf(`
+		MATCH %s
+		MERGE (u:User {mongoId: $userId})
+		MERGE (u)-[r:%s]->(t)
+		ON CREATE SET r.createdAt = $now
+		RETURN count(r) AS stored
+	`, pattern.match, pattern.rel)
+
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, cypher, map[string]interface{}{
+		"userId": userID,
+		"id":     req.ID,
+		"now":    time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to store feedback: %w", err)
+	}
+
+	stored := false
+	if result.Next(ctx) {
+		stored = getIntValue(result.Record(), "stored") > 0
+	}
+	if err := result.Err(); err != nil {
+		return false, fmt.Errorf("failed to store feedback: %w", err)
+	}
+
+	return stored, nil
+}
+
+// RemoveFeedback deletes the "not interested" edge. It returns false when
+// there was nothing to undo.
+func RemoveFeedback(ctx context.Context, userID string, req models.FeedbackRequest) (bool, error) {
+	pattern, ok := feedbackPatterns[req.Type]
+	if !ok {
+		return false, fmt.Errorf("unsupported feedback type %s", req.Type)
+	}
+
+	cypher := fmt.Sprintf(`
+		MATCH (:User {mongoId: $userId})-[r:%s]->%s
+		DELETE r
+		RETURN count(r) AS removed
+	`, pattern.rel, pattern.match)
+
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, cypher, map[string]interface{}{
+		"userId": userID,
+		"id":     req.ID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to remove feedback: %w", err)
+	}
+
+	removed := false
+	if result.Next(ctx) {
+		removed = getIntValue(result.Record(), "removed") > 0
+	}
+	if err := result.Err(); err != nil {
+		return false, fmt.Errorf("failed to remove feedback: %w", err)
+	}
+
+	return removed, nil
+}
+
+func GetFeedback(ctx context.Context, userID string) ([]models.FeedbackItem, error) {
+	cypher := `
+		MATCH (:User {mongoId: $userId})-[r:HIDES|DISLIKES]->(t)
+		RETURN CASE
+		         WHEN t:Song THEN 'song'
+		         WHEN t:Artist THEN 'artist'
+		         ELSE 'genre'
+		       END AS type,
+		       coalesce(t.mongoId, t.name) AS id,
+		       coalesce(t.title, t.name) AS name,
+		       r.createdAt AS createdAt
+		ORDER BY createdAt DESC, id
+	`
+
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, cypher, map[string]interface{}{
+		"userId": userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feedback: %w", err)
+	}
+
+	items := []models.FeedbackItem{}
+	for result.Next(ctx) {
+		record := result.Record()
+		items = append(items, models.FeedbackItem{
+			Type:      getStringValue(record, "type"),
+			ID:        getStringValue(record, "id"),
+			Name:      getStringValue(record, "name"),
+			CreatedAt: int64(getIntValue(record, "createdAt")),
+		})
+	}
+
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feedback: %w", err)
+	}
+
+	return items, nil
+}
@@ -6,98 +6,169 @@ import (
 
 	"recommendation-service/db"
 	"recommendation-service/models"
+	"recommendation-service/ranking"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
-func GetSubscribedGenreSongs(ctx context.Context, userID string, limit int) ([]models.RecommendedSong, error) {
-	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
+// candidateTail expects u (the caller, possibly null), s (a distinct song)
+// and the section signals affinity, basisRating and supporters, and returns
+// the features the ranking stage needs for every candidate. Songs the caller
+// hid, or whose artist or genre they marked as not interesting, are dropped.
+const candidateTail = `
+		WITH u, s, affinity, basisRating, supporters
+		WHERE u IS NULL OR NOT (
+			EXISTS { MATCH (u)-[:HIDES]->(s) } OR
+			EXISTS { MATCH (u)-[:DISLIKES]->(:Artist)<-[:CREATED_BY]-(s) } OR
+			EXISTS { MATCH (u)-[:DISLIKES]->(:Genre)<-[:BELONGS_TO]-(s) }
+		)
+		OPTIONAL MATCH (s)-[:CREATED_BY]->(a:Artist)
+		WITH u, s, affinity, basisRating, supporters, head(collect(a)) AS a
+		OPTIONAL MATCH (s)-[:BELONGS_TO]->(g:Genre)
+		WITH u, s, affinity, basisRating, supporters, a, collect(DISTINCT g.name) AS genres
+		OPTIONAL MATCH (u)-[:SUBSCRIBED_TO]->(sg:Genre)
+		WHERE sg.name IN genres
+		WITH u, s, affinity, basisRating, supporters, a, genres, collect(DISTINCT sg.name) AS matchedGenres
+		OPTIONAL MATCH (:User)-[rr:RATED]->(s)
+		WITH s, affinity, basisRating, supporters, a, genres, matchedGenres,
+		     avg(toFloat(rr.rating)) AS avgRating, count(rr) AS ratingCount
+		RETURN s.mongoId AS id, s.title AS title, s.duration AS duration,
+		       s.trackNo AS trackNo, s.albumId AS albumId,
+		       a.mongoId AS artistId, a.name AS artistName,
+		       genres, matchedGenres, avgRating, ratingCount,
+		       size([(s)<-[:LISTENED]-(:User) | 1]) AS listenerCount,
+		       s.createdAt AS createdAt, affinity, basisRating, supporters
+		ORDER BY affinity DESC, createdAt DESC, id
+		LIMIT $limit
+`
 
+func GetSubscribedGenreCandidates(ctx context.Context, userID string, limit int) ([]models.SongCandidate, error) {
 	cypher := `
-		MATCH (u:User {mongoId: $userId})-[:SUBSCRIBED_TO]->(g:Genre)<-[:BELONGS_TO]-(s:Song)
+		MATCH (u:User {mongoId: $userId})-[:SUBSCRIBED_TO]->(:Genre)<-[:BELONGS_TO]-(s:Song)
 		WHERE NOT EXISTS {
 			MATCH (u)-[r:RATED]->(s) WHERE r.rating < 4
 		}
-		RETURN DISTINCT s.mongoId AS id, s.title AS title, s.duration AS duration,
-		       s.trackNo AS trackNo, s.albumId AS albumId, g.name AS genre
-		LIMIT $limit
-	`
+		WITH DISTINCT u, s
+		WITH u, s, 1.0 AS affinity, 0.0 AS basisRating, 0 AS supporters
+	` + candidateTail
 
-	result, err := session.Run(ctx, cypher, map[string]interface{}{
+	return runCandidateQuery(ctx, cypher, map[string]interface{}{
 		"userId": userID,
 		"limit":  limit,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to query subscribed genre songs: %w", err)
-	}
+	}, "subscribed genre songs")
+}
 
-	var songs []models.RecommendedSong
-	for result.Next(ctx) {
-		record := result.Record()
-		song := models.RecommendedSong{
-			ID:       getStringValue(record, "id"),
-			Title:    getStringValue(record, "title"),
-			Duration: getStringValue(record, "duration"),
-			TrackNo:  getIntValue(record, "trackNo"),
-			AlbumID:  getStringValue(record, "albumId"),
-			Genre:    getStringValue(record, "genre"),
+func GetDiscoverCandidates(ctx context.Context, userID string, limit int) ([]models.SongCandidate, error) {
+	cypher := `
+		OPTIONAL MATCH (u:User {mongoId: $userId})
+		MATCH (s:Song)-[:BELONGS_TO]->(g:Genre)
+		WHERE NOT EXISTS {
+			MATCH (:User {mongoId: $userId})-[:SUBSCRIBED_TO]->(g)
 		}
-		songs = append(songs, song)
-	}
+		WITH DISTINCT u, s
+		OPTIONAL MATCH ()-[r:RATED]->(s)
+		WITH u, s, count(r) AS totalRatings
+		WHERE totalRatings <= 5 AND (u IS NULL OR NOT EXISTS {
+			MATCH (u)-[:LISTENED]->(s)
+		})
+		WITH u, s, 1.0 AS affinity, 0.0 AS basisRating, 0 AS supporters
+	` + candidateTail
+
+	return runCandidateQuery(ctx, cypher, map[string]interface{}{
+		"userId": userID,
+		"limit":  limit,
+	}, "discover new songs")
+}
 
-	if err := result.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating subscribed genre songs: %w", err)
-	}
+func GetFollowedArtistCandidates(ctx context.Context, userID string, limit int) ([]models.SongCandidate, error) {
+	cypher := `
+		MATCH (u:User {mongoId: $userId})-[:FOLLOWS]->(:Artist)<-[:CREATED_BY]-(s:Song)
+		WHERE NOT EXISTS {
+			MATCH (u)-[:RATED]->(s)
+		}
+		WITH DISTINCT u, s
+		WITH u, s, 1.0 AS affinity, 0.0 AS basisRating, 0 AS supporters
+	` + candidateTail
 
-	return songs, nil
+	return runCandidateQuery(ctx, cypher, map[string]interface{}{
+		"userId": userID,
+		"limit":  limit,
+	}, "followed artist songs")
 }
 
-func GetDiscoverNewSongs(ctx context.Context, userID string, limit int) ([]models.RecommendedSong, error) {
-	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
-
+func GetRatedAlbumCandidates(ctx context.Context, userID string, limit int) ([]models.SongCandidate, error) {
 	cypher := `
-		MATCH (s:Song)-[:BELONGS_TO]->(g:Genre)
+		MATCH (u:User {mongoId: $userId})-[r:RATED]->(:Song)-[:PART_OF]->(al:Album)
+		WHERE r.rating >= 4
+		WITH u, al, avg(toFloat(r.rating)) AS albumRating
+		MATCH (al)<-[:PART_OF]-(s:Song)
 		WHERE NOT EXISTS {
-			MATCH (:User {mongoId: $userId})-[:SUBSCRIBED_TO]->(g)
+			MATCH (u)-[:RATED]->(s)
 		}
-		WITH s, g
-		OPTIONAL MATCH ()-[r:RATED]->(s)
-		WITH s, g, count(r) AS totalRatings
-		WHERE totalRatings <= 5
-		RETURN DISTINCT s.mongoId AS id, s.title AS title, s.duration AS duration,
-		       s.trackNo AS trackNo, s.albumId AS albumId, g.name AS genre
-		LIMIT $limit
-	`
+		WITH u, s, albumRating / 5.0 AS affinity, albumRating AS basisRating, 0 AS supporters
+	` + candidateTail
 
-	result, err := session.Run(ctx, cypher, map[string]interface{}{
+	return runCandidateQuery(ctx, cypher, map[string]interface{}{
 		"userId": userID,
 		"limit":  limit,
-	})
+	}, "rated album songs")
+}
+
+// GetSectionCandidates returns the candidates of one recommendation section.
+func GetSectionCandidates(ctx context.Context, section, userID string, limit int, cf CollaborativeOptions) ([]models.SongCandidate, error) {
+	switch section {
+	case ranking.SectionSubscribedGenres:
+		return GetSubscribedGenreCandidates(ctx, userID, limit)
+	case ranking.SectionDiscover:
+		return GetDiscoverCandidates(ctx, userID, limit)
+	case ranking.SectionFollowedArtists:
+		return GetFollowedArtistCandidates(ctx, userID, limit)
+	case ranking.SectionRatedAlbums:
+		return GetRatedAlbumCandidates(ctx, userID, limit)
+	case ranking.SectionSimilarUsers:
+		return GetSimilarUsersCandidates(ctx, userID, limit, cf)
+	default:
+		return nil, fmt.Errorf("unknown recommendation section %s", section)
+	}
+}
+
+func runCandidateQuery(ctx context.Context, cypher string, params map[string]interface{}, what string) ([]models.SongCandidate, error) {
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, cypher, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query discover new songs: %w", err)
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
 	}
 
-	var songs []models.RecommendedSong
+	var candidates []models.SongCandidate
 	for result.Next(ctx) {
 		record := result.Record()
-		song := models.RecommendedSong{
-			ID:       getStringValue(record, "id"),
-			Title:    getStringValue(record, "title"),
-			Duration: getStringValue(record, "duration"),
-			TrackNo:  getIntValue(record, "trackNo"),
-			AlbumID:  getStringValue(record, "albumId"),
-			Genre:    getStringValue(record, "genre"),
-		}
-		songs = append(songs, song)
+		candidates = append(candidates, models.SongCandidate{
+			ID:            getStringValue(record, "id"),
+			Title:         getStringValue(record, "title"),
+			Duration:      getStringValue(record, "duration"),
+			TrackNo:       getIntValue(record, "trackNo"),
+			AlbumID:       getStringValue(record, "albumId"),
+			ArtistID:      getStringValue(record, "artistId"),
+			ArtistName:    getStringValue(record, "artistName"),
+			Genres:        getStringListValue(record, "genres"),
+			MatchedGenres: getStringListValue(record, "matchedGenres"),
+			AvgRating:     getFloatValue(record, "avgRating"),
+			RatingCount:   getIntValue(record, "ratingCount"),
+			ListenerCount: getIntValue(record, "listenerCount"),
+			CreatedAt:     int64(getIntValue(record, "createdAt")),
+			Affinity:      getFloatValue(record, "affinity"),
+			BasisRating:   getFloatValue(record, "basisRating"),
+			Supporters:    getIntValue(record, "supporters"),
+		})
 	}
 
 	if err := result.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating discover new songs: %w", err)
+		return nil, fmt.Errorf("error iterating %s: %w", what, err)
 	}
 
-	return songs, nil
+	return candidates, nil
 }
 
 func getStringValue(record *neo4j.Record, key string) string {
@@ -128,3 +199,38 @@ func getIntValue(record *neo4j.Record, key string) int {
 		return 0
 	}
 }
+
+func getStringListValue(record *neo4j.Record, key string) []string {
+	val, ok := record.Get(key)
+	if !ok || val == nil {
+		return nil
+	}
+	items, ok := val.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func getFloatValue(record *neo4j.Record, key string) float64 {
+	val, ok := record.Get(key)
+	if !ok || val == nil {
+		return 0
+	}
+	switch v := val.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"recommendation-service/db"
+	"recommendation-service/models"
+	"recommendation-service/ranking"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// GetSimilarSongs returns the songs closest to songID, combining the genres
+// they share (Jaccard), how many users rated or played both (cosine over the
+// listeners) and how closely the ratings of those who rated both agree.
+func GetSimilarSongs(ctx context.Context, songID string, limit int) ([]models.RecommendedSong, error) {
+	cypher := `
+		MATCH (s0:Song {mongoId: $songId})
+		OPTIONAL MATCH (s0)<-[:RATED|LISTENED]-(u0:User)
+		WITH s0, [(s0)-[:BELONGS_TO]->(g:Genre) | g.name] AS g0s, count(DISTINCT u0) AS n0
+		OPTIONAL MATCH (s0)-[:BELONGS_TO]->(:Genre)<-[:BELONGS_TO]-(gs:Song)
+		WHERE gs <> s0
+		WITH s0, g0s, n0, collect(DISTINCT gs) AS byGenre
+		OPTIONAL MATCH (s0)<-[:RATED|LISTENED]-(:User)-[:RATED|LISTENED]->(rs:Song)
+		WHERE rs <> s0
+		WITH s0, g0s, n0, byGenre + collect(DISTINCT rs) AS candidates
+		UNWIND candidates AS s
+		WITH DISTINCT s0, g0s, n0, s
+		OPTIONAL MATCH (s)<-[:RATED|LISTENED]-(un:User)
+		WITH s0, g0s, n0, s, count(DISTINCT un) AS n
+		OPTIONAL MATCH (s0)<-[:RATED|LISTENED]-(u:User)-[:RATED|LISTENED]->(s)
+		WITH s0, g0s, n0, s, n, count(DISTINCT u) AS listeners
+		OPTIONAL MATCH (s0)<-[a:RATED]-(:User)-[b:RATED]->(s)
+		WITH s, g0s, n0, n, listeners,
+		     [(s)-[:BELONGS_TO]->(g:Genre) | g.name] AS gs,
+		     sum(CASE WHEN a.rating >= 4 AND b.rating >= 4 THEN 1 ELSE 0 END) AS likers,
+		     avg(1.0 - abs(a.rating - b.rating) / 4.0) AS agreement
+		WITH s, n0, n, listeners, likers, agreement, gs,
+		     [g IN gs WHERE g IN g0s] AS sharedGenres, size(g0s) AS g0Count
+		WITH s, n0, n, listeners, likers, agreement, sharedGenres,
+		     size(gs) + g0Count - size(sharedGenres) AS unionGenres
+		WITH s, sharedGenres, listeners, likers,
+		     CASE WHEN unionGenres > 0 THEN toFloat(size(sharedGenres)) / unionGenres ELSE 0.0 END AS genreScore,
+		     CASE WHEN n0 > 0 AND n > 0 THEN listeners / sqrt(toFloat(n0 * n)) ELSE 0.0 END AS listenerScore,
+		     coalesce(agreement, 0.0) AS agreement
+		WITH s, sharedGenres, listeners, likers,
+		     0.4 * genreScore + 0.3 * listenerScore + 0.3 * listenerScore * agreement AS score
+		WHERE score > 0
+		OPTIONAL MATCH (s)-[:CREATED_BY]->(ar:Artist)
+		WITH s, sharedGenres, listeners, likers, score, head(collect(ar.mongoId)) AS artistId
+		RETURN s.mongoId AS id, s.title AS title, s.duration AS duration,
+		       s.trackNo AS trackNo, s.albumId AS albumId, artistId,
+		       head(sharedGenres + [(s)-[:BELONGS_TO]->(g:Genre) | g.name]) AS genre,
+		       sharedGenres, listeners, likers, score
+		ORDER BY score DESC, id
+		LIMIT $limit
+	`
+
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, cypher, map[string]interface{}{
+		"songId": songID,
+		"limit":  limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query similar songs: %w", err)
+	}
+
+	songs := []models.RecommendedSong{}
+	for result.Next(ctx) {
+		record := result.Record()
+		songs = append(songs, models.RecommendedSong{
+			ID:       getStringValue(record, "id"),
+			Title:    getStringValue(record, "title"),
+			Duration: getStringValue(record, "duration"),
+			TrackNo:  getIntValue(record, "trackNo"),
+			AlbumID:  getStringValue(record, "albumId"),
+			Genre:    getStringValue(record, "genre"),
+			ArtistID: getStringValue(record, "artistId"),
+			Score:    roundScore(getFloatValue(record, "score")),
+			Reasons: ranking.SimilarityReasons(
+				getStringListValue(record, "sharedGenres"),
+				getIntValue(record, "listeners"),
+				getIntValue(record, "likers"),
+			),
+		})
+	}
+
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating similar songs: %w", err)
+	}
+
+	return songs, nil
+}
+
+// GetSimilarArtists returns the artists closest to artistID, combining the
+// genres of their songs, their shared audience (followers and raters) and the
+// users who liked songs of both. Only artists sharing a genre or an audience
+// member with artistID can score above zero, so only those are scored.
+func GetSimilarArtists(ctx context.Context, artistID string, limit int) ([]models.SimilarArtist, error) {
+	cypher := `
+		MATCH (a0:Artist {mongoId: $artistId})
+		WITH a0,
+		     [(a0)<-[:CREATED_BY]-(:Song)-[:BELONGS_TO]->(g:Genre) | g.name] AS g0raw,
+		     [(a0)<-[:FOLLOWS]-(u:User) | u.mongoId] +
+		     [(a0)<-[:CREATED_BY]-(:Song)<-[:RATED]-(u:User) | u.mongoId] AS aud0raw,
+		     [(a0)<-[:CREATED_BY]-(:Song)<-[r:RATED]-(u:User) WHERE r.rating >= 4 | u.mongoId] AS likers0raw
+		WITH a0,
+		     reduce(acc = [], x IN g0raw | CASE WHEN x IN acc THEN acc ELSE acc + x END) AS g0s,
+		     reduce(acc = [], x IN aud0raw | CASE WHEN x IN acc THEN acc ELSE acc + x END) AS aud0,
+		     reduce(acc = [], x IN likers0raw | CASE WHEN x IN acc THEN acc ELSE acc + x END) AS likers0
+		OPTIONAL MATCH (a0)<-[:CREATED_BY]-(:Song)-[:BELONGS_TO]->(:Genre)<-[:BELONGS_TO]-(:Song)-[:CREATED_BY]->(ga:Artist)
+		WITH a0, g0s, aud0, likers0, collect(DISTINCT ga) AS byGenre
+		OPTIONAL MATCH (fu:User)-[:FOLLOWS]->(fa:Artist)
+		WHERE fu.mongoId IN aud0
+		WITH a0, g0s, aud0, likers0, byGenre + collect(DISTINCT fa) AS byFollowers
+		OPTIONAL MATCH (ru:User)-[:RATED]->(:Song)-[:CREATED_BY]->(ra:Artist)
+		WHERE ru.mongoId IN aud0
+		WITH a0, g0s, aud0, likers0, byFollowers + collect(DISTINCT ra) AS candidates
+		UNWIND candidates AS a
+		WITH DISTINCT a0, g0s, aud0, likers0, a
+		WHERE a <> a0
+		WITH a0, g0s, aud0, likers0, a,
+		     [(a)<-[:CREATED_BY]-(:Song)-[:BELONGS_TO]->(g:Genre) | g.name] AS graw,
+		     [(a)<-[:FOLLOWS]-(u:User) | u.mongoId] +
+		     [(a)<-[:CREATED_BY]-(:Song)<-[:RATED]-(u:User) | u.mongoId] AS audraw,
+		     [(a)<-[:CREATED_BY]-(:Song)<-[r:RATED]-(u:User) WHERE r.rating >= 4 | u.mongoId] AS likersraw
+		WITH g0s, aud0, likers0, a,
+		     reduce(acc = [], x IN graw | CASE WHEN x IN acc THEN acc ELSE acc + x END) AS gs,
+		     reduce(acc = [], x IN audraw | CASE WHEN x IN acc THEN acc ELSE acc + x END) AS aud,
+		     reduce(acc = [], x IN likersraw | CASE WHEN x IN acc THEN acc ELSE acc + x END) AS likers
+		WITH a, g0s, aud0, likers0, gs, aud, likers,
+		     [g IN gs WHERE g IN g0s] AS sharedGenres,
+		     size([x IN aud WHERE x IN aud0]) AS listeners,
+		     size([x IN likers WHERE x IN likers0]) AS sharedLikers
+		WITH a, sharedGenres, listeners, sharedLikers,
+		     CASE WHEN size(gs) + size(g0s) - size(sharedGenres) > 0
+		          THEN toFloat(size(sharedGenres)) / (size(gs) + size(g0s) - size(sharedGenres))
+		          ELSE 0.0 END AS genreScore,
+		     CASE WHEN size(aud0) > 0 AND size(aud) > 0
+		          THEN listeners / sqrt(toFloat(size(aud0) * size(aud)))
+		          ELSE 0.0 END AS listenerScore,
+		     CASE WHEN size(likers0) > 0 AND size(likers) > 0
+		          THEN sharedLikers / sqrt(toFloat(size(likers0) * size(likers)))
+		          ELSE 0.0 END AS likerScore
+		WITH a, sharedGenres, listeners, sharedLikers,
+		     0.4 * genreScore + 0.35 * listenerScore + 0.25 * likerScore AS score
+		WHERE score > 0
+		RETURN a.mongoId AS id, a.name AS name, sharedGenres, listeners, sharedLikers, score
+		ORDER BY score DESC, id
+		LIMIT $limit
+	`
+
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, cypher, map[string]interface{}{
+		"artistId": artistID,
+		"limit":    limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query similar artists: %w", err)
+	}
+
+	artists := []models.SimilarArtist{}
+	for result.Next(ctx) {
+		record := result.Record()
+		artists = append(artists, models.SimilarArtist{
+			ID:    getStringValue(record, "id"),
+			Name:  getStringValue(record, "name"),
+			Score: roundScore(getFloatValue(record, "score")),
+			Reasons: ranking.SimilarityReasons(
+				getStringListValue(record, "sharedGenres"),
+				getIntValue(record, "listeners"),
+				getIntValue(record, "sharedLikers"),
+			),
+		})
+	}
+
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating similar artists: %w", err)
+	}
+
+	return artists, nil
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*10000) / 10000
+}
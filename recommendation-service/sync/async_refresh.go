@@ -2,6 +2,7 @@ package sync
 
 import (
 	"sync"
+	"time"
 )
 
 var (
@@ -36,3 +37,19 @@ func TriggerAsyncRefresh() {
 		}
 	}()
 }
+
+// StartReconciliation periodically rebuilds the graph from MongoDB to repair
+// any drift left by missed or failed events.
+func StartReconciliation(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			TriggerAsyncRefresh()
+		}
+	}()
+}
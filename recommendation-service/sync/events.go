@@ -0,0 +1,483 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"recommendation-service/db"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const eventQueueSize = 512
+
+type Event struct {
+	Type string
+	Data map[string]interface{}
+}
+
+var eventQueue = make(chan Event, eventQueueSize)
+
+// StartEventWorker applies queued events to the graph one at a time so that
+// updates for the same entity are never reordered.
+func StartEventWorker() {
+	go func() {
+		for evt := range eventQueue {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			err := applyEvent(ctx, evt)
+			cancel()
+			if err != nil {
+				fmt.Printf("Failed applying event %s, scheduling full resync: %v\n", evt.Type, err)
+				TriggerAsyncRefresh()
+				continue
+			}
+			invalidateCache(evt)
+		}
+	}()
+}
+
+// EnqueueEvent hands an event to the worker. When the queue is full the graph
+// is rebuilt from MongoDB instead, so no change is ever lost.
+func EnqueueEvent(evt Event) {
+	select {
+	case eventQueue <- evt:
+	default:
+		fmt.Printf("Event queue full, dropping %s and scheduling full resync\n", evt.Type)
+		TriggerAsyncRefresh()
+	}
+}
+
+func IsSupportedEvent(eventType string) bool {
+	_, ok := eventHandlers[eventType]
+	return ok
+}
+
+var eventHandlers = map[string]func(context.Context, map[string]interface{}) error{
+	"user.created":                   applyUserCreated,
+	"artist.created":                 applyArtistUpserted,
+	"artist.updated":                 applyArtistUpserted,
+	"artist.deleted":                 applyArtistDeleted,
+	"album.created":                  applyAlbumUpserted,
+	"album.updated":                  applyAlbumUpserted,
+	"album.deleted":                  applyAlbumDeleted,
+	"song.created":                   applySongUpserted,
+	"song.updated":                   applySongUpserted,
+	"song.deleted":                   applySongDeleted,
+	"artist.subscription.created":    applyArtistSubscriptionCreated,
+	"artist.subscription.deleted":    applyArtistSubscriptionDeleted,
+	"genre.subscription.created":     applyGenreSubscriptionCreated,
+	"genre.subscription.deleted":     applyGenreSubscriptionDeleted,
+	"song.rating.created_or_updated": applyRatingUpserted,
+	"song.rating.deleted":            applyRatingDeleted,
+	"song.played":                    applySongPlayed,
+}
+
+func applyEvent(ctx context.Context, evt Event) error {
+	handler, ok := eventHandlers[evt.Type]
+	if !ok {
+		return nil
+	}
+
+	graphMu.Lock()
+	defer graphMu.Unlock()
+
+	return handler(ctx, evt.Data)
+}
+
+func applyUserCreated(ctx context.Context, data map[string]interface{}) error {
+	userID, err := requireStringField(data, "userId")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `MERGE (:User {mongoId: $userId})`, map[string]interface{}{
+		"userId": userID,
+	})
+}
+
+func applyArtistUpserted(ctx context.Context, data map[string]interface{}) error {
+	artistID, err := requireStringField(data, "artistId")
+	if err != nil {
+		return err
+	}
+	name, _ := stringField(data, "name")
+
+	return runWrite(ctx, `
+		MERGE (a:Artist {mongoId: $artistId})
+		SET a.name = CASE WHEN $name <> '' THEN $name ELSE a.name END
+	`, map[string]interface{}{
+		"artistId": artistID,
+		"name":     name,
+	})
+}
+
+// retireNode expects n, a song or artist that was deleted or moved to the
+// trash. Only the HIDES and DISLIKES feedback users left on it is kept, so
+// it applies again when the entity is restored; without any the node goes.
+// Its properties go too, so album updates cannot link the song back.
+const retireNode = `
+		OPTIONAL MATCH (n)-[r]-()
+		WHERE NOT type(r) IN ['HIDES', 'DISLIKES']
+		DELETE r
+		WITH DISTINCT n
+		SET n = {mongoId: n.mongoId}
+		WITH n
+		WHERE NOT EXISTS { MATCH (n)<-[:HIDES|DISLIKES]-(:User) }
+		DELETE n
+`
+
+func applyArtistDeleted(ctx context.Context, data map[string]interface{}) error {
+	artistID, err := requireStringField(data, "artistId")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `MATCH (n:Artist {mongoId: $artistId})`+retireNode, map[string]interface{}{
+		"artistId": artistID,
+	})
+}
+
+// applyAlbumUpserted refreshes the album node and re-links every song of the
+// album, because album genres and the owning artist are also stored on the
+// songs' relationships.
+func applyAlbumUpserted(ctx context.Context, data map[string]interface{}) error {
+	albumID, err := requireStringField(data, "albumId")
+	if err != nil {
+		return err
+	}
+
+	album, err := loadAlbum(ctx, albumID)
+	if err != nil {
+		return err
+	}
+	if album == nil {
+		return nil
+	}
+
+	params := map[string]interface{}{
+		"albumId":     albumID,
+		"title":       album.Title,
+		"releaseDate": album.ReleaseDate,
+		"artistId":    album.ArtistID.Hex(),
+		"genres":      nonEmptyStrings(album.Genres),
+	}
+
+	if err := runWrite(ctx, `
+		MERGE (album:Album {mongoId: $albumId})
+		SET album.title = $title, album.releaseDate = $releaseDate
+		WITH album
+		OPTIONAL MATCH (album)-[old:RELEASED_BY]->()
+		DELETE old
+		WITH DISTINCT album
+		MERGE (a:Artist {mongoId: $artistId})
+		MERGE (album)-[:RELEASED_BY]->(a)
+	`, params); err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `
+		MATCH (song:Song {albumId: $albumId})
+		OPTIONAL MATCH (song)-[old:BELONGS_TO|CREATED_BY]->()
+		DELETE old
+		WITH DISTINCT song
+		MATCH (album:Album {mongoId: $albumId})
+		MERGE (song)-[:PART_OF]->(album)
+		MERGE (a:Artist {mongoId: $artistId})
+		MERGE (song)-[:CREATED_BY]->(a)
+		WITH song
+		UNWIND $genres AS genreName
+		MERGE (g:Genre {name: genreName})
+		MERGE (song)-[:BELONGS_TO]->(g)
+	`, params)
+}
+
+func applyAlbumDeleted(ctx context.Context, data map[string]interface{}) error {
+	albumID, err := requireStringField(data, "albumId")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `
+		OPTIONAL MATCH (al:Album {mongoId: $albumId})
+		DETACH DELETE al
+		WITH count(*) AS deletedAlbums
+		MATCH (n:Song {albumId: $albumId})
+	`+retireNode, map[string]interface{}{
+		"albumId": albumID,
+	})
+}
+
+func applySongUpserted(ctx context.Context, data map[string]interface{}) error {
+	songID, err := requireStringField(data, "songId")
+	if err != nil {
+		return err
+	}
+
+	song, err := loadSong(ctx, songID)
+	if err != nil {
+		return err
+	}
+	if song == nil {
+		return nil
+	}
+
+	genres := []string{}
+	artistID := ""
+	albumNodeID := ""
+	album, err := loadAlbum(ctx, song.AlbumID.Hex())
+	if err != nil {
+		return err
+	}
+	if album != nil {
+		genres = nonEmptyStrings(album.Genres)
+		artistID = album.ArtistID.Hex()
+		albumNodeID = album.ID.Hex()
+	}
+
+	return runWrite(ctx, `
+		MERGE (song:Song {mongoId: $songId})
+		SET song.title = $title, song.duration = $duration,
+		    song.trackNo = $trackNo, song.albumId = $albumId,
+		    song.createdAt = $createdAt
+		WITH song
+		OPTIONAL MATCH (song)-[old:BELONGS_TO|CREATED_BY|PART_OF]->()
+		DELETE old
+		WITH DISTINCT song
+		FOREACH (_ IN CASE WHEN $artistId <> '' THEN [1] ELSE [] END |
+			MERGE (a:Artist {mongoId: $artistId})
+			MERGE (song)-[:CREATED_BY]->(a)
+		)
+		FOREACH (_ IN CASE WHEN $albumNodeId <> '' THEN [1] ELSE [] END |
+			MERGE (al:Album {mongoId: $albumNodeId})
+			MERGE (song)-[:PART_OF]->(al)
+		)
+		WITH song
+		UNWIND $genres AS genreName
+		MERGE (g:Genre {name: genreName})
+		MERGE (song)-[:BELONGS_TO]->(g)
+	`, map[string]interface{}{
+		"songId":      songID,
+		"title":       song.Title,
+		"duration":    song.Duration,
+		"trackNo":     song.TrackNo,
+		"albumId":     song.AlbumID.Hex(),
+		"albumNodeId": albumNodeID,
+		"createdAt":   song.ID.Timestamp().UnixMilli(),
+		"artistId":    artistID,
+		"genres":      genres,
+	})
+}
+
+func applySongDeleted(ctx context.Context, data map[string]interface{}) error {
+	songID, err := requireStringField(data, "songId")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `MATCH (n:Song {mongoId: $songId})`+retireNode, map[string]interface{}{
+		"songId": songID,
+	})
+}
+
+func applyArtistSubscriptionCreated(ctx context.Context, data map[string]interface{}) error {
+	userID, err := requireStringField(data, "userId")
+	if err != nil {
+		return err
+	}
+	artistID, err := requireStringField(data, "artistId")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `
+		MERGE (u:User {mongoId: $userId})
+		MERGE (a:Artist {mongoId: $artistId})
+		MERGE (u)-[:FOLLOWS]->(a)
+	`, map[string]interface{}{
+		"userId":   userID,
+		"artistId": artistID,
+	})
+}
+
+func applyArtistSubscriptionDeleted(ctx context.Context, data map[string]interface{}) error {
+	userID, err := requireStringField(data, "userId")
+	if err != nil {
+		return err
+	}
+	artistID, err := requireStringField(data, "artistId")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `
+		MATCH (:User {mongoId: $userId})-[r:FOLLOWS]->(:Artist {mongoId: $artistId})
+		DELETE r
+	`, map[string]interface{}{
+		"userId":   userID,
+		"artistId": artistID,
+	})
+}
+
+func applyGenreSubscriptionCreated(ctx context.Context, data map[string]interface{}) error {
+	userID, err := requireStringField(data, "userId")
+	if err != nil {
+		return err
+	}
+	genre, err := requireStringField(data, "genre")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `
+		MERGE (u:User {mongoId: $userId})
+		MERGE (g:Genre {name: $genre})
+		MERGE (u)-[:SUBSCRIBED_TO]->(g)
+	`, map[string]interface{}{
+		"userId": userID,
+		"genre":  genre,
+	})
+}
+
+func applyGenreSubscriptionDeleted(ctx context.Context, data map[string]interface{}) error {
+	userID, err := requireStringField(data, "userId")
+	if err != nil {
+		return err
+	}
+	genre, err := requireStringField(data, "genre")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `
+		MATCH (:User {mongoId: $userId})-[r:SUBSCRIBED_TO]->(:Genre {name: $genre})
+		DELETE r
+	`, map[string]interface{}{
+		"userId": userID,
+		"genre":  genre,
+	})
+}
+
+func applyRatingUpserted(ctx context.Context, data map[string]interface{}) error {
+	userID, err := requireStringField(data, "userId")
+	if err != nil {
+		return err
+	}
+	songID, err := requireStringField(data, "songId")
+	if err != nil {
+		return err
+	}
+	rating, ok := intField(data, "rating")
+	if !ok {
+		return fmt.Errorf("missing field rating")
+	}
+
+	return runWrite(ctx, `
+		MERGE (u:User {mongoId: $userId})
+		MERGE (s:Song {mongoId: $songId})
+		MERGE (u)-[rel:RATED]->(s)
+		SET rel.rating = $rating
+	`, map[string]interface{}{
+		"userId": userID,
+		"songId": songID,
+		"rating": rating,
+	})
+}
+
+func applySongPlayed(ctx context.Context, data map[string]interface{}) error {
+	userID, err := requireStringField(data, "userId")
+	if err != nil {
+		return err
+	}
+	songID, err := requireStringField(data, "songId")
+	if err != nil {
+		return err
+	}
+
+	playedAt := time.Now()
+	if raw, ok := stringField(data, "playedAt"); ok && raw != "" {
+		if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+			playedAt = parsed
+		}
+	}
+
+	return runWrite(ctx, `
+		MERGE (u:User {mongoId: $userId})
+		MERGE (s:Song {mongoId: $songId})
+		MERGE (u)-[rel:LISTENED]->(s)
+		SET rel.count = coalesce(rel.count, 0) + 1,
+		    rel.lastPlayedAt = CASE
+		        WHEN rel.lastPlayedAt IS NULL OR rel.lastPlayedAt < $playedAt THEN $playedAt
+		        ELSE rel.lastPlayedAt
+		    END
+	`, map[string]interface{}{
+		"userId":   userID,
+		"songId":   songID,
+		"playedAt": playedAt.UnixMilli(),
+	})
+}
+
+func applyRatingDeleted(ctx context.Context, data map[string]interface{}) error {
+	userID, err := requireStringField(data, "userId")
+	if err != nil {
+		return err
+	}
+	songID, err := requireStringField(data, "songId")
+	if err != nil {
+		return err
+	}
+
+	return runWrite(ctx, `
+		MATCH (:User {mongoId: $userId})-[r:RATED]->(:Song {mongoId: $songId})
+		DELETE r
+	`, map[string]interface{}{
+		"userId": userID,
+		"songId": songID,
+	})
+}
+
+func loadAlbum(ctx context.Context, albumID string) (*mongoAlbum, error) {
+	objID, err := primitive.ObjectIDFromHex(albumID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid albumId %q", albumID)
+	}
+
+	var album mongoAlbum
+	if err := db.AlbumsCollection.FindOne(ctx, bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}}).Decode(&album); err != nil {
+		if isNoDocuments(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &album, nil
+}
+
+func loadSong(ctx context.Context, songID string) (*mongoSong, error) {
+	objID, err := primitive.ObjectIDFromHex(songID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid songId %q", songID)
+	}
+
+	var song mongoSong
+	if err := db.SongsCollection.FindOne(ctx, bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}}).Decode(&song); err != nil {
+		if isNoDocuments(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &song, nil
+}
+
+func runWrite(ctx context.Context, cypher string, params map[string]interface{}) error {
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, cypher, params)
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume(ctx)
+	return err
+}
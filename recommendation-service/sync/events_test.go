@@ -0,0 +1,140 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"recommendation-service/db"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// connectTestStores points db at the Neo4j and MongoDB named by
+// TEST_NEO4J_URI and TEST_MONGO_URI, skipping the test when they are unset.
+// MongoDB gets a database of its own, dropped afterwards.
+func connectTestStores(t *testing.T) context.Context {
+	t.Helper()
+	neo4jURI, mongoURI := os.Getenv("TEST_NEO4J_URI"), os.Getenv("TEST_MONGO_URI")
+	if neo4jURI == "" || mongoURI == "" {
+		t.Skip("TEST_NEO4J_URI and TEST_MONGO_URI are not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	driver, err := neo4j.NewDriverWithContext(neo4jURI,
+		neo4j.BasicAuth(os.Getenv("TEST_NEO4J_USER"), os.Getenv("TEST_NEO4J_PASSWORD"), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { driver.Close(context.Background()) })
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := client.Database("recommendation_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		_ = database.Drop(context.Background())
+		_ = client.Disconnect(context.Background())
+	})
+
+	db.Neo4jDriver = driver
+	db.SongsCollection = database.Collection("songs")
+	db.AlbumsCollection = database.Collection("albums")
+	return ctx
+}
+
+func countRows(t *testing.T, ctx context.Context, cypher string, params map[string]interface{}) int64 {
+	t.Helper()
+	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, cypher, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := record.Values[0].(int64)
+	return n
+}
+
+// Trashing a song emits song.deleted and restoring it song.created; a user
+// who hid the song must still have it hidden afterwards.
+func TestTrashAndRestoreKeepHiddenSong(t *testing.T) {
+	ctx := connectTestStores(t)
+
+	userID := primitive.NewObjectID().Hex()
+	artistID, albumID, songID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	genre := "Genre " + songID.Hex()
+	params := map[string]interface{}{"userId": userID, "songId": songID.Hex(), "genre": genre}
+	t.Cleanup(func() {
+		_ = runWrite(context.Background(), `
+			MATCH (n) WHERE n.mongoId IN [$userId, $songId, $artistId, $albumId] OR n.name = $genre
+			DETACH DELETE n
+		`, map[string]interface{}{
+			"userId": userID, "songId": songID.Hex(), "artistId": artistID.Hex(), "albumId": albumID.Hex(), "genre": genre,
+		})
+	})
+
+	if _, err := db.AlbumsCollection.InsertOne(ctx, bson.M{"_id": albumID, "title": "Album", "artistId": artistID, "genres": []string{genre}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SongsCollection.InsertOne(ctx, bson.M{"_id": songID, "title": "Song", "albumId": albumID}); err != nil {
+		t.Fatal(err)
+	}
+
+	songData := map[string]interface{}{"songId": songID.Hex()}
+	if err := applySongUpserted(ctx, songData); err != nil {
+		t.Fatal(err)
+	}
+	if err := runWrite(ctx, `
+		MERGE (u:User {mongoId: $userId})
+		WITH u
+		MATCH (s:Song {mongoId: $songId})
+		MERGE (u)-[:HIDES]->(s)
+	`, params); err != nil {
+		t.Fatal(err)
+	}
+
+	const hidden = `MATCH (:User {mongoId: $userId})-[:HIDES]->(:Song {mongoId: $songId}) RETURN count(*)`
+	const linked = `MATCH (:Song {mongoId: $songId})-[:BELONGS_TO]->(:Genre {name: $genre}) RETURN count(*)`
+
+	if _, err := db.SongsCollection.UpdateByID(ctx, songID, bson.M{"$set": bson.M{"deletedAt": time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySongDeleted(ctx, songData); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, ctx, hidden, params); n != 1 {
+		t.Fatalf("trash dropped the HIDES edge (%d left)", n)
+	}
+	if n := countRows(t, ctx, linked, params); n != 0 {
+		t.Fatalf("trashed song is still in its genre")
+	}
+
+	if _, err := db.SongsCollection.UpdateByID(ctx, songID, bson.M{"$unset": bson.M{"deletedAt": ""}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySongUpserted(ctx, songData); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, ctx, hidden, params); n != 1 {
+		t.Fatalf("restore lost the HIDES edge (%d left)", n)
+	}
+	if n := countRows(t, ctx, linked, params); n != 1 {
+		t.Fatalf("restored song is not back in its genre")
+	}
+}
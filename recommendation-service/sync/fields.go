@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func stringField(data map[string]interface{}, key string) (string, bool) {
+	if data == nil {
+		return "", false
+	}
+	raw, ok := data[key]
+	if !ok {
+		return "", false
+	}
+	val, ok := raw.(string)
+	return strings.TrimSpace(val), ok
+}
+
+func requireStringField(data map[string]interface{}, key string) (string, error) {
+	val, ok := stringField(data, key)
+	if !ok || val == "" {
+		return "", fmt.Errorf("missing field %s", key)
+	}
+	return val, nil
+}
+
+func intField(data map[string]interface{}, key string) (int, bool) {
+	if data == nil {
+		return 0, false
+	}
+	switch v := data[key].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+func nonEmptyStrings(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// notDeleted matches catalog documents that are not in content-service's
+// trash; trashed artists, albums and songs are kept out of the graph.
+func notDeleted() bson.M {
+	return bson.M{"deletedAt": bson.M{"$exists": false}}
+}
+
+func isNoDocuments(err error) bool {
+	return errors.Is(err, mongo.ErrNoDocuments)
+}
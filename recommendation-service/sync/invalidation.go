@@ -0,0 +1,21 @@
+package sync
+
+import "recommendation-service/cache"
+
+// invalidateCache drops cached recommendations affected by an applied event:
+// those of the user it concerns and every result containing its song, album
+// or artist.
+func invalidateCache(evt Event) {
+	if userID, ok := stringField(evt.Data, "userId"); ok {
+		cache.Recommendations.InvalidateUser(userID)
+	}
+	if songID, ok := stringField(evt.Data, "songId"); ok && songID != "" {
+		cache.Recommendations.InvalidateSong(songID)
+	}
+	if albumID, ok := stringField(evt.Data, "albumId"); ok && albumID != "" {
+		cache.Recommendations.InvalidateAlbum(albumID)
+	}
+	if artistID, ok := stringField(evt.Data, "artistId"); ok && artistID != "" {
+		cache.Recommendations.InvalidateArtist(artistID)
+	}
+}
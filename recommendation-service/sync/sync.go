@@ -3,8 +3,11 @@ package sync
 import (
 	"context"
 	"fmt"
+	"strings"
+	gosync "sync"
 	"time"
 
+	"recommendation-service/cache"
 	"recommendation-service/db"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
@@ -18,10 +21,11 @@ type mongoArtist struct {
 }
 
 type mongoAlbum struct {
-	ID       primitive.ObjectID `bson:"_id"`
-	Title    string             `bson:"title"`
-	Genres   []string           `bson:"genres"`
-	ArtistID primitive.ObjectID `bson:"artistId"`
+	ID          primitive.ObjectID `bson:"_id"`
+	Title       string             `bson:"title"`
+	ReleaseDate string             `bson:"releaseDate"`
+	Genres      []string           `bson:"genres"`
+	ArtistID    primitive.ObjectID `bson:"artistId"`
 }
 
 type mongoSong struct {
@@ -38,32 +42,86 @@ type mongoRating struct {
 	Rating int                `bson:"rating"`
 }
 
+type mongoListen struct {
+	Key struct {
+		UserID primitive.ObjectID `bson:"userId"`
+		SongID primitive.ObjectID `bson:"songId"`
+	} `bson:"_id"`
+	Count        int       `bson:"count"`
+	LastPlayedAt time.Time `bson:"lastPlayedAt"`
+}
+
 type mongoGenreSubscription struct {
 	UserID primitive.ObjectID `bson:"userId"`
 	Genre  string             `bson:"genre"`
 }
 
+type mongoArtistSubscription struct {
+	UserID   primitive.ObjectID `bson:"userId"`
+	ArtistID primitive.ObjectID `bson:"artistId"`
+}
+
+// graphMu serialises full reconciliation with incremental event handlers so
+// that a rebuild never prunes a relationship an event has just written.
+var graphMu gosync.Mutex
+
+// SyncAll reconciles the whole graph with MongoDB. Relationships are merged and
+// tagged with the current run, and only relationships left untouched by the run
+// are removed afterwards, so readers never observe an empty graph.
 func SyncAll() {
+	graphMu.Lock()
+	defer graphMu.Unlock()
+
 	fmt.Println("Starting MongoDB -> Neo4j data sync...")
 	start := time.Now()
 
 	ctx := context.Background()
+	run := fmt.Sprintf("%d", start.UnixNano())
 
 	createConstraints(ctx)
-	clearGraphRelationships(ctx)
 
-	artistIDs := syncArtists(ctx)
-	songIDs, songGenres, artistIDsFromSongs := syncSongsAndGenres(ctx)
-	subUserIDs, subGenres := syncGenreSubscriptions(ctx)
-	ratingUserIDs := syncRatings(ctx)
+	artistIDs, artistsOK := syncArtists(ctx)
+	albumIDs, artistIDsFromAlbums, albumsOK := syncAlbums(ctx, run)
+	songIDs, songGenres, artistIDsFromSongs, songsOK := syncSongsAndGenres(ctx, run)
+	subUserIDs, subGenres, subsOK := syncGenreSubscriptions(ctx, run)
+	followUserIDs, followedArtistIDs, followsOK := syncArtistSubscriptions(ctx, run)
+	ratingUserIDs, ratingsOK := syncRatings(ctx, run)
+	listenUserIDs, listensOK := syncListens(ctx, run)
 
-	pruneStaleNodes(
-		ctx,
-		songIDs,
-		uniqueStrings(append(artistIDs, artistIDsFromSongs...)),
-		uniqueStrings(append(songGenres, subGenres...)),
-		uniqueStrings(append(subUserIDs, ratingUserIDs...)),
-	)
+	if albumsOK {
+		pruneStaleRelationships(ctx, run, "RELEASED_BY")
+	}
+	if songsOK {
+		pruneStaleRelationships(ctx, run, "BELONGS_TO", "CREATED_BY", "PART_OF")
+	}
+	if subsOK {
+		pruneStaleRelationships(ctx, run, "SUBSCRIBED_TO")
+	}
+	if followsOK {
+		pruneStaleRelationships(ctx, run, "FOLLOWS")
+	}
+	if ratingsOK {
+		pruneStaleRelationships(ctx, run, "RATED")
+	}
+	if listensOK {
+		pruneStaleRelationships(ctx, run, "LISTENED")
+	}
+
+	if artistsOK && albumsOK && songsOK && subsOK && followsOK && ratingsOK && listensOK {
+		allArtistIDs := append(append(artistIDs, artistIDsFromAlbums...), artistIDsFromSongs...)
+		pruneStaleNodes(
+			ctx,
+			songIDs,
+			albumIDs,
+			uniqueStrings(append(allArtistIDs, followedArtistIDs...)),
+			uniqueStrings(append(songGenres, subGenres...)),
+			uniqueStrings(append(append(append(subUserIDs, followUserIDs...), ratingUserIDs...), listenUserIDs...)),
+		)
+	} else {
+		fmt.Println("Skipping stale node pruning because part of the sync failed")
+	}
+
+	cache.Recommendations.Purge()
 
 	fmt.Printf("Data sync completed in %v\n", time.Since(start))
 }
@@ -77,6 +135,7 @@ func createConstraints(ctx context.Context) {
 		"CREATE CONSTRAINT IF NOT EXISTS FOR (g:Genre) REQUIRE g.name IS UNIQUE",
 		"CREATE CONSTRAINT IF NOT EXISTS FOR (u:User) REQUIRE u.mongoId IS UNIQUE",
 		"CREATE CONSTRAINT IF NOT EXISTS FOR (a:Artist) REQUIRE a.mongoId IS UNIQUE",
+		"CREATE CONSTRAINT IF NOT EXISTS FOR (al:Album) REQUIRE al.mongoId IS UNIQUE",
 	}
 
 	for _, cypher := range constraints {
@@ -89,29 +148,11 @@ func createConstraints(ctx context.Context) {
 	fmt.Println("Neo4j constraints created")
 }
 
-func clearGraphRelationships(ctx context.Context) {
-	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
-
-	queries := []string{
-		"MATCH ()-[r:BELONGS_TO]->() DELETE r",
-		"MATCH ()-[r:CREATED_BY]->() DELETE r",
-		"MATCH ()-[r:SUBSCRIBED_TO]->() DELETE r",
-		"MATCH ()-[r:RATED]->() DELETE r",
-	}
-
-	for _, q := range queries {
-		if _, err := session.Run(ctx, q, nil); err != nil {
-			fmt.Printf("Warning: failed clearing relationships (%s): %v\n", q, err)
-		}
-	}
-}
-
-func syncArtists(ctx context.Context) []string {
-	cursor, err := db.ArtistsCollection.Find(ctx, bson.M{})
+func syncArtists(ctx context.Context) ([]string, bool) {
+	cursor, err := db.ArtistsCollection.Find(ctx, notDeleted())
 	if err != nil {
 		fmt.Printf("Error fetching artists: %v\n", err)
-		return nil
+		return nil, false
 	}
 	defer cursor.Close(ctx)
 
@@ -132,32 +173,80 @@ func syncArtists(ctx context.Context) []string {
 
 	if len(rows) == 0 {
 		fmt.Println("No artists to sync")
-		return uniqueStrings(artistIDs)
+		return uniqueStrings(artistIDs), true
 	}
 
-	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
-
 	cypher := `
 		UNWIND $artists AS a
 		MERGE (artist:Artist {mongoId: a.mongoId})
 		SET artist.name = a.name
 	`
 
-	if _, err := session.Run(ctx, cypher, map[string]interface{}{"artists": rows}); err != nil {
+	if err := runWrite(ctx, cypher, map[string]interface{}{"artists": rows}); err != nil {
 		fmt.Printf("Error syncing artists to Neo4j: %v\n", err)
-		return uniqueStrings(artistIDs)
+		return uniqueStrings(artistIDs), false
 	}
 
 	fmt.Printf("Synced %d artists to Neo4j\n", len(rows))
-	return uniqueStrings(artistIDs)
+	return uniqueStrings(artistIDs), true
 }
 
-func syncSongsAndGenres(ctx context.Context) ([]string, []string, []string) {
-	albumCursor, err := db.AlbumsCollection.Find(ctx, bson.M{})
+func syncAlbums(ctx context.Context, run string) ([]string, []string, bool) {
+	cursor, err := db.AlbumsCollection.Find(ctx, notDeleted())
 	if err != nil {
 		fmt.Printf("Error fetching albums: %v\n", err)
-		return nil, nil, nil
+		return nil, nil, false
+	}
+	defer cursor.Close(ctx)
+
+	var rows []map[string]interface{}
+	var albumIDs []string
+	var artistIDs []string
+	for cursor.Next(ctx) {
+		var album mongoAlbum
+		if err := cursor.Decode(&album); err != nil {
+			continue
+		}
+		id := album.ID.Hex()
+		artistID := album.ArtistID.Hex()
+		albumIDs = append(albumIDs, id)
+		artistIDs = append(artistIDs, artistID)
+		rows = append(rows, map[string]interface{}{
+			"mongoId":     id,
+			"title":       album.Title,
+			"releaseDate": album.ReleaseDate,
+			"artistId":    artistID,
+		})
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No albums to sync")
+		return albumIDs, artistIDs, true
+	}
+
+	cypher := `
+		UNWIND $albums AS al
+		MERGE (album:Album {mongoId: al.mongoId})
+		SET album.title = al.title, album.releaseDate = al.releaseDate
+		MERGE (a:Artist {mongoId: al.artistId})
+		MERGE (album)-[rb:RELEASED_BY]->(a)
+		SET rb.syncRun = $run
+	`
+
+	if err := runWrite(ctx, cypher, map[string]interface{}{"albums": rows, "run": run}); err != nil {
+		fmt.Printf("Error syncing albums to Neo4j: %v\n", err)
+		return uniqueStrings(albumIDs), uniqueStrings(artistIDs), false
+	}
+
+	fmt.Printf("Synced %d albums to Neo4j\n", len(rows))
+	return uniqueStrings(albumIDs), uniqueStrings(artistIDs), true
+}
+
+func syncSongsAndGenres(ctx context.Context, run string) ([]string, []string, []string, bool) {
+	albumCursor, err := db.AlbumsCollection.Find(ctx, notDeleted())
+	if err != nil {
+		fmt.Printf("Error fetching albums: %v\n", err)
+		return nil, nil, nil, false
 	}
 	defer albumCursor.Close(ctx)
 
@@ -190,10 +279,10 @@ func syncSongsAndGenres(ctx context.Context) ([]string, []string, []string) {
 		}
 	}
 
-	songCursor, err := db.SongsCollection.Find(ctx, bson.M{})
+	songCursor, err := db.SongsCollection.Find(ctx, notDeleted())
 	if err != nil {
 		fmt.Printf("Error fetching songs: %v\n", err)
-		return nil, mapKeys(genreSet), mapKeys(artistIDsSet)
+		return nil, mapKeys(genreSet), mapKeys(artistIDsSet), false
 	}
 	defer songCursor.Close(ctx)
 
@@ -211,60 +300,69 @@ func syncSongsAndGenres(ctx context.Context) ([]string, []string, []string) {
 		meta, ok := albumData[song.AlbumID.Hex()]
 		genres := []string{}
 		artistID := ""
+		albumNodeID := ""
 		if ok {
 			genres = meta.genres
 			artistID = meta.artistID
+			albumNodeID = song.AlbumID.Hex()
 		}
 
 		songs = append(songs, map[string]interface{}{
-			"mongoId":  songID,
-			"title":    song.Title,
-			"duration": song.Duration,
-			"trackNo":  song.TrackNo,
-			"albumId":  song.AlbumID.Hex(),
-			"genres":   genres,
-			"artistId": artistID,
+			"mongoId":     songID,
+			"title":       song.Title,
+			"duration":    song.Duration,
+			"trackNo":     song.TrackNo,
+			"albumId":     song.AlbumID.Hex(),
+			"albumNodeId": albumNodeID,
+			"createdAt":   song.ID.Timestamp().UnixMilli(),
+			"genres":      genres,
+			"artistId":    artistID,
 		})
 	}
 
 	if len(songs) == 0 {
 		fmt.Println("No songs to sync")
-		return uniqueStrings(songIDs), mapKeys(genreSet), mapKeys(artistIDsSet)
+		return uniqueStrings(songIDs), mapKeys(genreSet), mapKeys(artistIDsSet), true
 	}
 
-	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
-
 	cypher := `
 		UNWIND $songs AS s
 		MERGE (song:Song {mongoId: s.mongoId})
 		SET song.title = s.title, song.duration = s.duration,
-		    song.trackNo = s.trackNo, song.albumId = s.albumId
+		    song.trackNo = s.trackNo, song.albumId = s.albumId,
+		    song.createdAt = s.createdAt
 		WITH song, s
 		FOREACH (_ IN CASE WHEN s.artistId <> '' THEN [1] ELSE [] END |
 			MERGE (a:Artist {mongoId: s.artistId})
-			MERGE (song)-[:CREATED_BY]->(a)
+			MERGE (song)-[cb:CREATED_BY]->(a)
+			SET cb.syncRun = $run
+		)
+		FOREACH (_ IN CASE WHEN s.albumNodeId <> '' THEN [1] ELSE [] END |
+			MERGE (al:Album {mongoId: s.albumNodeId})
+			MERGE (song)-[po:PART_OF]->(al)
+			SET po.syncRun = $run
 		)
 		WITH song, s
 		UNWIND s.genres AS genreName
 		MERGE (g:Genre {name: genreName})
-		MERGE (song)-[:BELONGS_TO]->(g)
+		MERGE (song)-[bt:BELONGS_TO]->(g)
+		SET bt.syncRun = $run
 	`
 
-	if _, err := session.Run(ctx, cypher, map[string]interface{}{"songs": songs}); err != nil {
+	if err := runWrite(ctx, cypher, map[string]interface{}{"songs": songs, "run": run}); err != nil {
 		fmt.Printf("Error syncing songs to Neo4j: %v\n", err)
-		return uniqueStrings(songIDs), mapKeys(genreSet), mapKeys(artistIDsSet)
+		return uniqueStrings(songIDs), mapKeys(genreSet), mapKeys(artistIDsSet), false
 	}
 
 	fmt.Printf("Synced %d songs with genres/artists to Neo4j\n", len(songs))
-	return uniqueStrings(songIDs), mapKeys(genreSet), mapKeys(artistIDsSet)
+	return uniqueStrings(songIDs), mapKeys(genreSet), mapKeys(artistIDsSet), true
 }
 
-func syncGenreSubscriptions(ctx context.Context) ([]string, []string) {
+func syncGenreSubscriptions(ctx context.Context, run string) ([]string, []string, bool) {
 	cursor, err := db.GenreSubscriptionsCollection.Find(ctx, bson.M{})
 	if err != nil {
 		fmt.Printf("Error fetching genre subscriptions: %v\n", err)
-		return nil, nil
+		return nil, nil, false
 	}
 	defer cursor.Close(ctx)
 
@@ -292,33 +390,82 @@ func syncGenreSubscriptions(ctx context.Context) ([]string, []string) {
 
 	if len(subs) == 0 {
 		fmt.Println("No genre subscriptions to sync")
-		return mapKeys(userIDSet), mapKeys(genreSet)
+		return mapKeys(userIDSet), mapKeys(genreSet), true
 	}
 
-	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
-
 	cypher := `
 		UNWIND $subs AS sub
 		MERGE (u:User {mongoId: sub.userId})
 		MERGE (g:Genre {name: sub.genre})
-		MERGE (u)-[:SUBSCRIBED_TO]->(g)
+		MERGE (u)-[st:SUBSCRIBED_TO]->(g)
+		SET st.syncRun = $run
 	`
 
-	if _, err := session.Run(ctx, cypher, map[string]interface{}{"subs": subs}); err != nil {
+	if err := runWrite(ctx, cypher, map[string]interface{}{"subs": subs, "run": run}); err != nil {
 		fmt.Printf("Error syncing genre subscriptions to Neo4j: %v\n", err)
-		return mapKeys(userIDSet), mapKeys(genreSet)
+		return mapKeys(userIDSet), mapKeys(genreSet), false
 	}
 
 	fmt.Printf("Synced %d genre subscriptions to Neo4j\n", len(subs))
-	return mapKeys(userIDSet), mapKeys(genreSet)
+	return mapKeys(userIDSet), mapKeys(genreSet), true
 }
 
-func syncRatings(ctx context.Context) []string {
+func syncArtistSubscriptions(ctx context.Context, run string) ([]string, []string, bool) {
+	cursor, err := db.ArtistSubscriptionsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		fmt.Printf("Error fetching artist subscriptions: %v\n", err)
+		return nil, nil, false
+	}
+	defer cursor.Close(ctx)
+
+	var follows []map[string]interface{}
+	userIDSet := make(map[string]struct{})
+	artistIDSet := make(map[string]struct{})
+
+	for cursor.Next(ctx) {
+		var sub mongoArtistSubscription
+		if err := cursor.Decode(&sub); err != nil {
+			continue
+		}
+
+		userID := sub.UserID.Hex()
+		artistID := sub.ArtistID.Hex()
+		userIDSet[userID] = struct{}{}
+		artistIDSet[artistID] = struct{}{}
+
+		follows = append(follows, map[string]interface{}{
+			"userId":   userID,
+			"artistId": artistID,
+		})
+	}
+
+	if len(follows) == 0 {
+		fmt.Println("No artist subscriptions to sync")
+		return mapKeys(userIDSet), mapKeys(artistIDSet), true
+	}
+
+	cypher := `
+		UNWIND $follows AS f
+		MERGE (u:User {mongoId: f.userId})
+		MERGE (a:Artist {mongoId: f.artistId})
+		MERGE (u)-[fo:FOLLOWS]->(a)
+		SET fo.syncRun = $run
+	`
+
+	if err := runWrite(ctx, cypher, map[string]interface{}{"follows": follows, "run": run}); err != nil {
+		fmt.Printf("Error syncing artist subscriptions to Neo4j: %v\n", err)
+		return mapKeys(userIDSet), mapKeys(artistIDSet), false
+	}
+
+	fmt.Printf("Synced %d artist subscriptions to Neo4j\n", len(follows))
+	return mapKeys(userIDSet), mapKeys(artistIDSet), true
+}
+
+func syncRatings(ctx context.Context, run string) ([]string, bool) {
 	cursor, err := db.UserRatingsCollection.Find(ctx, bson.M{})
 	if err != nil {
 		fmt.Printf("Error fetching ratings: %v\n", err)
-		return nil
+		return nil, false
 	}
 	defer cursor.Close(ctx)
 
@@ -343,50 +490,135 @@ func syncRatings(ctx context.Context) []string {
 
 	if len(ratings) == 0 {
 		fmt.Println("No ratings to sync")
-		return mapKeys(userIDSet)
+		return mapKeys(userIDSet), true
 	}
 
-	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
-	defer session.Close(ctx)
-
 	cypher := `
 		UNWIND $ratings AS r
 		MERGE (u:User {mongoId: r.userId})
 		MERGE (s:Song {mongoId: r.songId})
 		MERGE (u)-[rel:RATED]->(s)
-		SET rel.rating = r.rating
+		SET rel.rating = r.rating, rel.syncRun = $run
 	`
 
-	if _, err := session.Run(ctx, cypher, map[string]interface{}{"ratings": ratings}); err != nil {
+	if err := runWrite(ctx, cypher, map[string]interface{}{"ratings": ratings, "run": run}); err != nil {
 		fmt.Printf("Error syncing ratings to Neo4j: %v\n", err)
-		return mapKeys(userIDSet)
+		return mapKeys(userIDSet), false
 	}
 
 	fmt.Printf("Synced %d ratings to Neo4j\n", len(ratings))
-	return mapKeys(userIDSet)
+	return mapKeys(userIDSet), true
 }
 
-func pruneStaleNodes(ctx context.Context, songIDs []string, artistIDs []string, genreNames []string, userIDs []string) {
-	pruneNodesByValues(ctx, "Song", "mongoId", songIDs)
-	pruneNodesByValues(ctx, "Artist", "mongoId", artistIDs)
-	pruneNodesByValues(ctx, "Genre", "name", genreNames)
-	pruneNodesByValues(ctx, "User", "mongoId", userIDs)
+// syncListens folds the play history into one LISTENED relationship per user
+// and song carrying the play count and the time of the last play.
+func syncListens(ctx context.Context, run string) ([]string, bool) {
+	cursor, err := db.SongPlaysCollection.Aggregate(ctx, []bson.M{
+		{"$group": bson.M{
+			"_id":          bson.M{"userId": "$userId", "songId": "$songId"},
+			"count":        bson.M{"$sum": 1},
+			"lastPlayedAt": bson.M{"$max": "$playedAt"},
+		}},
+	})
+	if err != nil {
+		fmt.Printf("Error fetching plays: %v\n", err)
+		return nil, false
+	}
+	defer cursor.Close(ctx)
+
+	var listens []map[string]interface{}
+	userIDSet := make(map[string]struct{})
+
+	for cursor.Next(ctx) {
+		var l mongoListen
+		if err := cursor.Decode(&l); err != nil {
+			continue
+		}
+
+		userID := l.Key.UserID.Hex()
+		userIDSet[userID] = struct{}{}
+
+		listens = append(listens, map[string]interface{}{
+			"userId":       userID,
+			"songId":       l.Key.SongID.Hex(),
+			"count":        l.Count,
+			"lastPlayedAt": l.LastPlayedAt.UnixMilli(),
+		})
+	}
+
+	if len(listens) == 0 {
+		fmt.Println("No plays to sync")
+		return mapKeys(userIDSet), true
+	}
+
+	cypher := `
+		UNWIND $listens AS l
+		MERGE (u:User {mongoId: l.userId})
+		MERGE (s:Song {mongoId: l.songId})
+		MERGE (u)-[rel:LISTENED]->(s)
+		SET rel.count = l.count, rel.lastPlayedAt = l.lastPlayedAt, rel.syncRun = $run
+	`
+
+	if err := runWrite(ctx, cypher, map[string]interface{}{"listens": listens, "run": run}); err != nil {
+		fmt.Printf("Error syncing plays to Neo4j: %v\n", err)
+		return mapKeys(userIDSet), false
+	}
+
+	fmt.Printf("Synced %d listened songs to Neo4j\n", len(listens))
+	return mapKeys(userIDSet), true
+}
+
+func pruneStaleRelationships(ctx context.Context, run string, relTypes ...string) {
+	for _, relType := range relTypes {
+		cypher := fmt.Sprintf("MATCH ()-[r:%s]->() WHERE r.syncRun IS NULL OR r.syncRun <> $run DELETE r", relType)
+		if err := runWrite(ctx, cypher, map[string]interface{}{"run": run}); err != nil {
+			fmt.Printf("Warning: failed pruning stale %s relationships: %v\n", relType, err)
+		}
+	}
+}
+
+func pruneStaleNodes(ctx context.Context, songIDs []string, albumIDs []string, artistIDs []string, genreNames []string, userIDs []string) {
+	// "Not interested" feedback only lives in the graph, so users who left
+	// some, and the songs, artists and genres it is about, are kept even
+	// when MongoDB no longer has them; trashed entities can come back.
+	pruneNodesByValues(ctx, "Song", "mongoId", songIDs, "(n)<-[:HIDES]-(:User)")
+	pruneNodesByValues(ctx, "Album", "mongoId", albumIDs, "")
+	pruneNodesByValues(ctx, "Artist", "mongoId", artistIDs, "(n)<-[:DISLIKES]-(:User)")
+	pruneNodesByValues(ctx, "Genre", "name", genreNames, "(n)<-[:DISLIKES]-(:User)")
+	pruneNodesByValues(ctx, "User", "mongoId", userIDs, "(n)-[:HIDES|DISLIKES]->()")
 }
 
-func pruneNodesByValues(ctx context.Context, label string, key string, values []string) {
+func pruneNodesByValues(ctx context.Context, label string, key string, values []string, keepPattern string) {
 	session := db.Neo4jDriver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
-	var (
-		cypher string
-		params map[string]interface{}
-	)
-	if len(values) == 0 {
-		cypher = fmt.Sprintf("MATCH (n:%s) DETACH DELETE n", label)
-		params = nil
-	} else {
-		cypher = fmt.Sprintf("MATCH (n:%s) WHERE NOT n.%s IN $values DETACH DELETE n", label, key)
-		params = map[string]interface{}{"values": values}
+	conditions := []string{}
+	params := map[string]interface{}{}
+	if len(values) > 0 {
+		conditions = append(conditions, fmt.Sprintf("NOT n.%s IN $values", key))
+		params["values"] = values
+	}
+	if keepPattern != "" {
+		// Stale nodes kept for their feedback lose everything else, so
+		// they drop out of every recommendation, as retireNode does.
+		stale := append(append([]string{}, conditions...), fmt.Sprintf("EXISTS { MATCH %s }", keepPattern))
+		retire := fmt.Sprintf(`
+			MATCH (n:%s) WHERE %s
+			OPTIONAL MATCH (n)-[r]-()
+			WHERE NOT type(r) IN ['HIDES', 'DISLIKES']
+			DELETE r
+			WITH DISTINCT n
+			SET n = {%s: n.%s}
+		`, label, strings.Join(stale, " AND "), key, key)
+		if _, err := session.Run(ctx, retire, params); err != nil {
+			fmt.Printf("Warning: failed retiring %s nodes: %v\n", label, err)
+		}
+		conditions = append(conditions, fmt.Sprintf("NOT EXISTS { MATCH %s }", keepPattern))
+	}
+
+	cypher := fmt.Sprintf("MATCH (n:%s) DETACH DELETE n", label)
+	if len(conditions) > 0 {
+		cypher = fmt.Sprintf("MATCH (n:%s) WHERE %s DETACH DELETE n", label, strings.Join(conditions, " AND "))
 	}
 
 	if _, err := session.Run(ctx, cypher, params); err != nil {
@@ -1,10 +1,19 @@
 package filevalidation
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"mime"
 	"path/filepath"
+
+	_ "golang.org/x/image/webp"
 )
 
 const (
@@ -43,19 +52,47 @@ func ValidateFileType(filename string, contentType string, allowedTypes map[stri
 }
 
 func ValidateFileSize(size int64) error {
-	if size > MaxFileSize {
-		return errors.New("file size exceeds maximum allowed (10 MB)")
+	return ValidateFileSizeLimit(size, MaxFileSize)
+}
+
+// ValidateFileSizeLimit is ValidateFileSize with a limit other than
+// MaxFileSize, for uploads that are allowed to be larger.
+func ValidateFileSizeLimit(size, limit int64) error {
+	if size > limit {
+		return fmt.Errorf("file size exceeds maximum allowed (%d MB)", limit/(1024*1024))
 	}
-	if size == 0 {
+	if size <= 0 {
 		return errors.New("file is empty")
 	}
 	return nil
 }
 
+// CalculateChecksum returns the hex encoded SHA-256 of everything read from
+// file. Wrap the reader in an io.TeeReader to hash a file while copying it.
 func CalculateChecksum(file io.Reader) (string, error) {
-	return "", errors.New("checksum calculation not implemented")
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// ValidateImageDimensions reads only the image header, so oversized images
+// are rejected before anything decodes their pixels. JPEG, PNG, GIF and WebP
+// are understood; any other format is reported as unsupported.
 func ValidateImageDimensions(file io.Reader, maxWidth, maxHeight int) error {
-	return errors.New("image dimension validation not implemented")
+	cfg, _, err := image.DecodeConfig(file)
+	if err == image.ErrFormat {
+		return errors.New("unsupported image format")
+	}
+	if err != nil {
+		return errors.New("invalid image data")
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return errors.New("image has no dimensions")
+	}
+	if cfg.Width > maxWidth || cfg.Height > maxHeight {
+		return fmt.Errorf("image dimensions exceed maximum allowed (%dx%d)", maxWidth, maxHeight)
+	}
+	return nil
 }
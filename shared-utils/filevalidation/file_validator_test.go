@@ -0,0 +1,84 @@
+package filevalidation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func pngOf(w, h int) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)))
+	return buf.Bytes()
+}
+
+// webpOf returns the header of a lossless WebP, which is all DecodeConfig
+// reads.
+func webpOf(w, h int) []byte {
+	vp8l := make([]byte, 5)
+	vp8l[0] = 0x2f
+	binary.LittleEndian.PutUint32(vp8l[1:], uint32(w-1)|uint32(h-1)<<14)
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(12+len(vp8l)))
+	buf.WriteString("WEBPVP8L")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(vp8l)))
+	buf.Write(vp8l)
+	return buf.Bytes()
+}
+
+func TestValidateImageDimensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"within limits", pngOf(200, 100), false},
+		{"at limits", pngOf(300, 300), false},
+		{"too wide", pngOf(301, 10), true},
+		{"too tall", pngOf(10, 301), true},
+		{"webp", webpOf(200, 100), false},
+		{"webp too wide", webpOf(301, 10), true},
+		{"not an image", []byte("RIFF....WEBPVP8 "), true},
+		{"truncated", pngOf(10, 10)[:20], true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateImageDimensions(bytes.NewReader(tt.data), 300, 300)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateImageDimensions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCalculateChecksum(t *testing.T) {
+	got, err := CalculateChecksum(bytes.NewReader([]byte("abc")))
+	if err != nil {
+		t.Fatalf("CalculateChecksum() error = %v", err)
+	}
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got != want {
+		t.Fatalf("CalculateChecksum() = %s, want %s", got, want)
+	}
+}
+
+func TestValidateFileSizeLimit(t *testing.T) {
+	if err := ValidateFileSizeLimit(MaxFileSize+1, 100*1024*1024); err != nil {
+		t.Fatalf("ValidateFileSizeLimit() under a larger limit: %v", err)
+	}
+	err := ValidateFileSizeLimit(100*1024*1024+1, 100*1024*1024)
+	if err == nil || err.Error() != "file size exceeds maximum allowed (100 MB)" {
+		t.Fatalf("ValidateFileSizeLimit() over the limit = %v", err)
+	}
+	if err := ValidateFileSize(MaxFileSize + 1); err == nil || err.Error() != "file size exceeds maximum allowed (10 MB)" {
+		t.Fatalf("ValidateFileSize() = %v", err)
+	}
+	if err := ValidateFileSizeLimit(0, MaxFileSize); err == nil {
+		t.Fatal("ValidateFileSizeLimit() accepted an empty file")
+	}
+}
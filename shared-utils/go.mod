@@ -8,6 +8,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/rs/zerolog v1.32.0
 	github.com/sony/gobreaker v1.0.0
+	golang.org/x/image v0.29.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 )
 